@@ -0,0 +1,74 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Bucket of Config.WALArchive receiving WAL segments
+const walArchiveBucket = "wal"
+
+// Interval between checks of the archiver by ArchiveWAL
+const archiveCheckInterval = 20 * time.Millisecond
+
+// archiveCommand returns archive_command copying WAL segments into the bucket
+// directory, refusing to overwrite segments as PostgreSQL requires
+func archiveCommand(store *ObjectStore) string {
+	return fmt.Sprintf("test ! -f %[1]s/%%f && cp %%p %[1]s/%%f", quotedArchiveDir(store))
+}
+
+// ArchiveWAL switches to a new WAL segment and waits until the completed one
+// is archived to Config.WALArchive, so that everything written so far is in
+// the object store. It returns the name of the archived segment, which is
+// also its key in the "wal" bucket.
+func (p *PG) ArchiveWAL(ctx context.Context) (string, error) {
+	if p.config.WALArchive == nil {
+		return "", fmt.Errorf("WAL archiving is not configured")
+	}
+
+	var segment string
+	if err := p.Pool.QueryRow(ctx, "SELECT pg_walfile_name(pg_switch_wal())").Scan(&segment); err != nil {
+		return "", fmt.Errorf("Failed to switch WAL: %w", err)
+	}
+
+	ticker := time.NewTicker(archiveCheckInterval)
+	defer ticker.Stop()
+	for {
+		var archived, failed string
+		err := p.Pool.QueryRow(ctx, `
+			SELECT coalesce(last_archived_wal, ''), coalesce(last_failed_wal, '')
+			FROM pg_stat_archiver`).Scan(&archived, &failed)
+		if err != nil {
+			return "", fmt.Errorf("Failed to check archiver: %w", err)
+		}
+		// Segment names of a timeline sort in WAL order
+		if archived >= segment {
+			return segment, nil
+		}
+		if failed == segment {
+			return "", fmt.Errorf("Failed to archive WAL segment %s, see ServerOutput", segment)
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("WAL segment %s not archived: %w", segment, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// restoreCommand returns restore_command copying WAL segments from the bucket
+// directory
+func restoreCommand(store *ObjectStore) string {
+	return fmt.Sprintf("cp %s/%%f %%p", quotedArchiveDir(store))
+}
+
+// quotedArchiveDir returns the bucket directory quoted for archive_command
+// and restore_command: % is expanded by PostgreSQL, the rest by the shell
+func quotedArchiveDir(store *ObjectStore) string {
+	dir := filepath.Join(store.Dir, walArchiveBucket)
+	return "'" + strings.ReplaceAll(strings.ReplaceAll(dir, "%", "%%"), "'", `'\''`) + "'"
+}
@@ -0,0 +1,35 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+)
+
+func TestArchiveWAL(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	store, err := NewObjectStore("")
+	if err != nil {
+		t.Fatalf("failed to start object store: %v", err)
+	}
+	defer store.Close()
+
+	pg := StartT(t, Config{WALArchive: store})
+
+	if _, err := pg.Pool.Exec(ctx, "CREATE TABLE t AS SELECT generate_series(1, 1000) AS id"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	segment, err := pg.ArchiveWAL(ctx)
+	if err != nil {
+		t.Fatalf("failed to archive WAL: %v", err)
+	}
+
+	keys, err := store.Keys("wal", segment)
+	if err != nil {
+		t.Fatalf("failed to list archived WAL: %v", err)
+	}
+	if len(keys) != 1 {
+		t.Errorf("expected segment %s to be archived, got %v", segment, keys)
+	}
+}
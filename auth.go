@@ -0,0 +1,33 @@
+package pgxtest
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+)
+
+// Authentication method of TCP connections if Config.Password is set
+const defaultPasswordAuth = "scram-sha-256"
+
+// TCPURL returns a connection URL of the test database over TCP, including
+// Config.Password if it is set and requiring a verified TLS connection if
+// Config.TLS is set. The server must listen on TCP, see Config.ListenTCP.
+func (p *PG) TCPURL() string {
+	if p.external != "" {
+		return p.external
+	}
+	user := url.User(p.User)
+	if p.config.Password != "" {
+		user = url.UserPassword(p.User, p.config.Password)
+	}
+	u := url.URL{
+		Scheme: "postgres",
+		User:   user,
+		Host:   net.JoinHostPort("localhost", strconv.Itoa(p.Port)),
+		Path:   "/" + p.Name,
+	}
+	if p.CAFile != "" {
+		u.RawQuery = url.Values{"sslmode": {"verify-full"}, "sslrootcert": {p.CAFile}}.Encode()
+	}
+	return u.String()
+}
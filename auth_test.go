@@ -0,0 +1,44 @@
+package pgxtest
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestPassword(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{Password: "secret"})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	if err := pg.Pool.Ping(ctx); err != nil {
+		t.Errorf("failed to ping over the UNIX socket: %v", err)
+	}
+
+	conn, err := pgx.Connect(ctx, pg.TCPURL())
+	if err != nil {
+		t.Fatalf("failed to connect with password: %v", err)
+	}
+	conn.Close(ctx)
+
+	u, err := url.Parse(pg.TCPURL())
+	if err != nil {
+		t.Fatalf("failed to parse URL: %v", err)
+	}
+	u.User = url.UserPassword(pg.User, "wrong")
+	if conn, err := pgx.Connect(ctx, u.String()); err == nil {
+		conn.Close(ctx)
+		t.Errorf("expected connection with a wrong password to fail")
+	}
+}
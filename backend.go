@@ -0,0 +1,39 @@
+package pgxtest
+
+import (
+	"context"
+	"os/exec"
+)
+
+// Backend runs the servers of instances started by Start. The backends are
+// LocalBackend, DockerBackend, DownloadBackend and ExternalBackend.
+type Backend interface {
+	start(ctx context.Context, config Config) (*PG, error)
+}
+
+// LocalBackend runs PostgreSQL binaries installed on the machine, found in
+// Config.BinDir or in the usual places
+type LocalBackend struct{}
+
+func (LocalBackend) start(ctx context.Context, config Config) (*PG, error) {
+	return startLocal(ctx, config)
+}
+
+// autoBackend picks the server of PGXTEST_EXTERNAL_URL if it is set, then the
+// local binaries if there are any, and falls back to Docker otherwise
+func autoBackend(config Config) Backend {
+	if b, ok := externalBackend(); ok {
+		return b
+	}
+	if config.BinDir != "" {
+		return LocalBackend{}
+	}
+	if _, err := findBinaries(config); err == nil {
+		return LocalBackend{}
+	}
+	if _, err := exec.LookPath(defaultDockerCommand); err == nil {
+		return DockerBackend{}
+	}
+	// Reports the missing binaries
+	return LocalBackend{}
+}
@@ -0,0 +1,63 @@
+package pgxtest
+
+import (
+	"context"
+)
+
+// CheckpointStats combines pg_stat_bgwriter and pg_stat_checkpointer (split
+// out of pg_stat_bgwriter in PostgreSQL 17)
+type CheckpointStats struct {
+	CheckpointsTimed     int64 // Scheduled checkpoints
+	CheckpointsRequested int64 // Checkpoints requested by backends or CHECKPOINT
+	BuffersCheckpoint    int64 // Buffers written by checkpoints
+	BuffersClean         int64 // Buffers written by the background writer
+	MaxWrittenClean      int64 // Times the background writer stopped because it wrote too many buffers
+	BuffersBackend       int64 // Buffers written by backends directly, zero in PostgreSQL 17+ (see StatIO)
+	BuffersAlloc         int64 // Buffers allocated
+}
+
+// CheckpointStats returns the cumulative checkpointer and background writer
+// statistics
+func (p *PG) CheckpointStats(ctx context.Context) (CheckpointStats, error) {
+	var version int
+	if err := p.Pool.QueryRow(ctx, "SELECT current_setting('server_version_num')::int").Scan(&version); err != nil {
+		return CheckpointStats{}, err
+	}
+
+	var s CheckpointStats
+	if version >= 170000 {
+		err := p.Pool.QueryRow(ctx, `
+			SELECT c.num_timed, c.num_requested, c.buffers_written,
+			       b.buffers_clean, b.maxwritten_clean, b.buffers_alloc
+			FROM pg_stat_checkpointer c, pg_stat_bgwriter b`,
+		).Scan(&s.CheckpointsTimed, &s.CheckpointsRequested, &s.BuffersCheckpoint,
+			&s.BuffersClean, &s.MaxWrittenClean, &s.BuffersAlloc)
+		return s, err
+	}
+
+	err := p.Pool.QueryRow(ctx, `
+		SELECT checkpoints_timed, checkpoints_req, buffers_checkpoint,
+		       buffers_clean, maxwritten_clean, buffers_backend, buffers_alloc
+		FROM pg_stat_bgwriter`,
+	).Scan(&s.CheckpointsTimed, &s.CheckpointsRequested, &s.BuffersCheckpoint,
+		&s.BuffersClean, &s.MaxWrittenClean, &s.BuffersBackend, &s.BuffersAlloc)
+	return s, err
+}
+
+// DiffSince returns the activity between the baseline and s
+func (s CheckpointStats) DiffSince(baseline CheckpointStats) CheckpointStats {
+	return CheckpointStats{
+		CheckpointsTimed:     s.CheckpointsTimed - baseline.CheckpointsTimed,
+		CheckpointsRequested: s.CheckpointsRequested - baseline.CheckpointsRequested,
+		BuffersCheckpoint:    s.BuffersCheckpoint - baseline.BuffersCheckpoint,
+		BuffersClean:         s.BuffersClean - baseline.BuffersClean,
+		MaxWrittenClean:      s.MaxWrittenClean - baseline.MaxWrittenClean,
+		BuffersBackend:       s.BuffersBackend - baseline.BuffersBackend,
+		BuffersAlloc:         s.BuffersAlloc - baseline.BuffersAlloc,
+	}
+}
+
+// Checkpoints returns the total number of checkpoints
+func (s CheckpointStats) Checkpoints() int64 {
+	return s.CheckpointsTimed + s.CheckpointsRequested
+}
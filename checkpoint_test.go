@@ -0,0 +1,58 @@
+package pgxtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCheckpointStats(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	baseline, err := pg.CheckpointStats(ctx)
+	if err != nil {
+		t.Fatalf("failed to get stats: %v", err)
+	}
+
+	if _, err := pg.Pool.Exec(ctx, "CREATE TABLE test AS SELECT generate_series(1, 1000) AS id"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	if _, err := pg.Pool.Exec(ctx, "CHECKPOINT"); err != nil {
+		t.Fatalf("failed to checkpoint: %v", err)
+	}
+
+	// Statistics are published asynchronously
+	err = retry(func() error {
+		stats, err := pg.CheckpointStats(ctx)
+		if err != nil {
+			return err
+		}
+		if diff := stats.DiffSince(baseline); diff.CheckpointsRequested < 1 {
+			return errors.New("no checkpoint recorded yet")
+		}
+		return nil
+	}, 50, 100*time.Millisecond)
+	if err != nil {
+		t.Errorf("expected a requested checkpoint: %v", err)
+	}
+}
+
+func TestCheckpointStatsDiffSince(t *testing.T) {
+	diff := CheckpointStats{CheckpointsTimed: 5, CheckpointsRequested: 3, BuffersCheckpoint: 100}.
+		DiffSince(CheckpointStats{CheckpointsTimed: 4, CheckpointsRequested: 1, BuffersCheckpoint: 40})
+	if diff.Checkpoints() != 3 || diff.BuffersCheckpoint != 60 {
+		t.Errorf("unexpected diff: %+v", diff)
+	}
+}
@@ -0,0 +1,95 @@
+package pgxtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ClientResult is the outcome of running a PostgreSQL client program
+type ClientResult struct {
+	Stdout   []byte
+	Stderr   []byte
+	ExitCode int
+}
+
+// RunClient runs a client program (psql, pg_dump, ...) from binDir against the
+// test database, e.g. an older or newer version than the server to validate
+// compatibility during staged upgrades.
+//
+// Connection settings are passed via PGHOST, PGPORT, PGUSER and PGDATABASE. A non-zero
+// exit code is reported in the result, not as an error.
+func (p *PG) RunClient(ctx context.Context, binDir string, program string, args ...string) (ClientResult, error) {
+	cmd := exec.CommandContext(ctx, filepath.Join(binDir, program), args...)
+	cmd.Env = append(os.Environ(), p.clientEnv()...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	res := ClientResult{Stdout: stdout.Bytes(), Stderr: stderr.Bytes()}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		res.ExitCode = exitErr.ExitCode()
+		return res, nil
+	}
+	return res, err
+}
+
+// clientEnv returns environment variables for libpq-based programs to connect
+// to the test database
+func (p *PG) clientEnv() []string {
+	env := []string{
+		"PGHOST=" + p.Host,
+		"PGPORT=" + strconv.Itoa(p.Port),
+		"PGUSER=" + p.User,
+		"PGDATABASE=" + p.Name,
+	}
+	if password := p.Pool.Config().ConnConfig.Password; password != "" {
+		env = append(env, "PGPASSWORD="+password)
+	}
+	return env
+}
+
+// URL returns a connection URL of the test database, e.g. for pgx.Connect,
+// database/sql or psql
+func (p *PG) URL() string {
+	if p.external != "" {
+		return p.external
+	}
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.User(p.User),
+		Host:     "localhost:" + strconv.Itoa(p.Port),
+		Path:     "/" + p.Name,
+		RawQuery: url.Values{"host": {p.Host}}.Encode(),
+	}
+	return u.String()
+}
+
+// DSN returns a keyword/value connection string of the test database, for
+// tools that don't accept URLs
+func (p *PG) DSN() string {
+	if p.external != "" {
+		return "dbname=" + quoteDSN(p.external)
+	}
+	return fmt.Sprintf("host=%s port=%d user=%s dbname=%s",
+		quoteDSN(p.Host), p.Port, quoteDSN(p.User), quoteDSN(p.Name))
+}
+
+// quoteDSN quotes a value of a keyword/value connection string
+func quoteDSN(s string) string {
+	if s != "" && !strings.ContainsAny(s, ` '\`) {
+		return s
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
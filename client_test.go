@@ -0,0 +1,66 @@
+package pgxtest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestRunClient(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	binDir, err := findBinPath("")
+	if err != nil {
+		t.Skipf("PostgreSQL is not installed: %v", err)
+	}
+
+	pg, err := Start(ctx, Config{})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	res, err := pg.RunClient(ctx, binDir, "psql", "-At", "-c", "SELECT current_database()")
+	if err != nil {
+		t.Fatalf("failed to run psql: %v", err)
+	}
+	if res.ExitCode != 0 || strings.TrimSpace(string(res.Stdout)) != pg.Name {
+		t.Errorf("unexpected psql result: %+v", res)
+	}
+
+	res, err = pg.RunClient(ctx, binDir, "psql", "-c", "SELECT * FROM missing")
+	if err != nil {
+		t.Fatalf("failed to run psql: %v", err)
+	}
+	if res.ExitCode == 0 || !strings.Contains(string(res.Stderr), "missing") {
+		t.Errorf("expected psql to fail, got %+v", res)
+	}
+}
+
+func TestConnectionStrings(t *testing.T) {
+	pg := &PG{Host: "/tmp/my dir", Port: 5432, User: "test", Name: "test"}
+
+	if url := pg.URL(); url != "postgres://test@localhost:5432/test?host=%2Ftmp%2Fmy+dir" {
+		t.Errorf("unexpected URL %q", url)
+	}
+	if dsn := pg.DSN(); dsn != "host='/tmp/my dir' port=5432 user=test dbname=test" {
+		t.Errorf("unexpected DSN %q", dsn)
+	}
+
+	for _, s := range []string{pg.URL(), pg.DSN()} {
+		conf, err := pgconn.ParseConfig(s)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", s, err)
+		}
+		if conf.Host != pg.Host || conf.Port != 5432 || conf.Database != "test" {
+			t.Errorf("unexpected config parsed from %q: %+v", s, conf)
+		}
+	}
+}
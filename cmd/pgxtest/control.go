@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/dottedmag/pgxtest"
+	"github.com/jackc/pgx/v5"
+)
+
+// Control API of the dev instance, for test suites in other languages
+// sharing the instance:
+//
+//	GET    /                  connection settings of the test database
+//	POST   /databases/NAME    create an empty database
+//	DELETE /databases/NAME    drop a database other than the test database and
+//	                          those of the server (postgres, template0/1)
+//	POST   /snapshots/NAME    save the test database as snapshot NAME
+//	POST   /reset?snapshot=N  recreate the test database, from snapshot N if given
+//	POST   /stop              stop the instance
+//
+// Requests must carry the token printed at startup as "Authorization: Bearer
+// TOKEN". Requests from browsers, recognized by the Origin header, are
+// rejected so that web pages can't reach the API. Responses are JSON, errors
+// are reported as {"error": "..."} with a non-2xx status.
+type controlServer struct {
+	pg    *pgxtest.PG
+	stop  func()
+	token string
+
+	mu            sync.Mutex
+	snapshotNames map[string]bool // Snapshots saved through /snapshots
+}
+
+// Databases of the server the control API must not drop or replace
+var systemDatabases = map[string]bool{"postgres": true, "template0": true, "template1": true}
+
+// validDatabaseName reports whether clients may create, drop or replace the
+// database
+func (s *controlServer) validDatabaseName(name string) bool {
+	return name != "" && name != s.pg.Name && !systemDatabases[name]
+}
+
+// setSnapshot records whether the database is a snapshot
+func (s *controlServer) setSnapshot(name string, snapshot bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.snapshotNames == nil {
+		s.snapshotNames = map[string]bool{}
+	}
+	if snapshot {
+		s.snapshotNames[name] = true
+	} else {
+		delete(s.snapshotNames, name)
+	}
+}
+
+func (s *controlServer) isSnapshot(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snapshotNames[name]
+}
+
+type connectionInfo struct {
+	Host     string `json:"host"`
+	User     string `json:"user"`
+	Database string `json:"database"`
+	URL      string `json:"url"`
+}
+
+// newControlToken returns a random token for the control API
+func newControlToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// listenControl starts the control API on addr, which is either host:port with
+// a loopback host or unix:PATH. It returns the URL of the API.
+func listenControl(ctx context.Context, addr string, s *controlServer) (string, error) {
+	network, address := "tcp", addr
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		network, address = "unix", path
+	} else if err := checkLoopback(address); err != nil {
+		return "", err
+	}
+
+	l, err := net.Listen(network, address)
+	if err != nil {
+		return "", err
+	}
+
+	srv := &http.Server{Handler: s.handler()}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+	go func() {
+		_ = srv.Serve(l)
+	}()
+
+	if network == "unix" {
+		return "unix:" + address, nil
+	}
+	return "http://" + l.Addr().String(), nil
+}
+
+// checkLoopback fails unless the host of host:port only accepts local
+// connections
+func checkLoopback(address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return fmt.Errorf("address %s is not a loopback address, the control API must not be reachable from other hosts", address)
+	}
+	return nil
+}
+
+func (s *controlServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.info)
+	mux.HandleFunc("/databases/", s.databases)
+	mux.HandleFunc("/snapshots/", s.snapshots)
+	mux.HandleFunc("/reset", s.reset)
+	mux.HandleFunc("/stop", s.stopInstance)
+	return s.authorize(mux)
+}
+
+// authorize rejects requests from browsers and those without the token
+func (s *controlServer) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Origin") != "" {
+			writeError(w, http.StatusForbidden, fmt.Errorf("requests from browsers are not allowed"))
+			return
+		}
+		auth := []byte(r.Header.Get("Authorization"))
+		if s.token == "" || subtle.ConstantTimeCompare(auth, []byte("Bearer "+s.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("missing or invalid token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *controlServer) info(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown endpoint %s", r.URL.Path))
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+		return
+	}
+	writeJSON(w, http.StatusOK, s.connectionInfo(s.pg.Name))
+}
+
+func (s *controlServer) databases(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/databases/")
+	if !s.validDatabaseName(name) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid database name %q", name))
+		return
+	}
+
+	var err error
+	switch r.Method {
+	case http.MethodPost:
+		err = s.admin(r.Context(), func(conn *pgx.Conn) error {
+			_, err := conn.Exec(r.Context(), "CREATE DATABASE "+pgx.Identifier{name}.Sanitize())
+			return err
+		})
+	case http.MethodDelete:
+		err = s.admin(r.Context(), func(conn *pgx.Conn) error {
+			_, err := conn.Exec(r.Context(), "DROP DATABASE "+pgx.Identifier{name}.Sanitize()+" WITH (FORCE)")
+			return err
+		})
+		if err == nil {
+			s.setSnapshot(name, false)
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.connectionInfo(name))
+}
+
+func (s *controlServer) snapshots(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/snapshots/")
+	if !s.validDatabaseName(name) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid snapshot name %q", name))
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+		return
+	}
+
+	err := s.admin(r.Context(), func(conn *pgx.Conn) error {
+		if err := s.disconnect(r.Context(), conn); err != nil {
+			return err
+		}
+		_, err := conn.Exec(r.Context(), fmt.Sprintf("DROP DATABASE IF EXISTS %s", pgx.Identifier{name}.Sanitize()))
+		if err != nil {
+			return err
+		}
+		_, err = conn.Exec(r.Context(), fmt.Sprintf("CREATE DATABASE %s TEMPLATE %s",
+			pgx.Identifier{name}.Sanitize(), pgx.Identifier{s.pg.Name}.Sanitize()))
+		return err
+	})
+	// The snapshot is gone if it was dropped but not created again
+	s.setSnapshot(name, err == nil)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"snapshot": name})
+}
+
+func (s *controlServer) reset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+		return
+	}
+
+	template := r.URL.Query().Get("snapshot")
+	if template == "" {
+		template = "template1"
+	} else if !s.isSnapshot(template) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown snapshot %q", template))
+		return
+	}
+
+	err := s.admin(r.Context(), func(conn *pgx.Conn) error {
+		if err := s.disconnect(r.Context(), conn); err != nil {
+			return err
+		}
+		_, err := conn.Exec(r.Context(), fmt.Sprintf("DROP DATABASE %s WITH (FORCE)", pgx.Identifier{s.pg.Name}.Sanitize()))
+		if err != nil {
+			return err
+		}
+		_, err = conn.Exec(r.Context(), fmt.Sprintf("CREATE DATABASE %s TEMPLATE %s",
+			pgx.Identifier{s.pg.Name}.Sanitize(), pgx.Identifier{template}.Sanitize()))
+		return err
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.connectionInfo(s.pg.Name))
+}
+
+func (s *controlServer) stopInstance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "stopping"})
+	s.stop()
+}
+
+// disconnect closes all sessions connected to the test database, which is
+// required to use it as a template or drop it
+func (s *controlServer) disconnect(ctx context.Context, conn *pgx.Conn) error {
+	s.pg.Pool.Reset()
+	_, err := conn.Exec(ctx,
+		"SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE datname = $1 AND pid <> pg_backend_pid()",
+		s.pg.Name)
+	return err
+}
+
+// admin runs fn with a connection to the maintenance database
+func (s *controlServer) admin(ctx context.Context, fn func(conn *pgx.Conn) error) error {
+	conn, err := pgx.Connect(ctx, s.connectionInfo("postgres").URL)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+	return fn(conn)
+}
+
+func (s *controlServer) connectionInfo(database string) connectionInfo {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.User(s.pg.User),
+		Path:     "/" + database,
+		RawQuery: url.Values{"host": {s.pg.Host}}.Encode(),
+	}
+	return connectionInfo{
+		Host:     s.pg.Host,
+		User:     s.pg.User,
+		Database: database,
+		URL:      u.String(),
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
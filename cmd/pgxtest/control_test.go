@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dottedmag/pgxtest"
+)
+
+func TestControlAuthorization(t *testing.T) {
+	s := &controlServer{pg: &pgxtest.PG{Host: "/tmp/sock", User: "test", Name: "test"}, token: "secret"}
+	h := s.handler()
+
+	for _, tc := range []struct {
+		name     string
+		header   http.Header
+		expected int
+	}{
+		{"no token", http.Header{}, http.StatusUnauthorized},
+		{"wrong token", http.Header{"Authorization": {"Bearer wrong"}}, http.StatusUnauthorized},
+		{"browser", http.Header{"Authorization": {"Bearer secret"}, "Origin": {"https://example.com"}}, http.StatusForbidden},
+		{"token", http.Header{"Authorization": {"Bearer secret"}}, http.StatusOK},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header = tc.header
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tc.expected {
+			t.Errorf("%s: expected status %d, got %d: %s", tc.name, tc.expected, w.Code, w.Body)
+		}
+	}
+
+	// Without a token nothing is allowed
+	s.token = ""
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer ")
+	w := httptest.NewRecorder()
+	s.handler().ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected an empty token to be rejected, got %d", w.Code)
+	}
+}
+
+func TestControlHandlers(t *testing.T) {
+	stopped := false
+	s := &controlServer{
+		pg:    &pgxtest.PG{Host: "/tmp/sock", User: "test", Name: "test"},
+		stop:  func() { stopped = true },
+		token: "secret",
+	}
+	h := s.handler()
+
+	request := func(method string, target string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, nil)
+		r.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := request(http.MethodGet, "/")
+	var info connectionInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &info); err != nil {
+		t.Fatalf("failed to decode %s: %v", w.Body, err)
+	}
+	if info.Database != "test" || info.URL != "postgres://test@/test?host=%2Ftmp%2Fsock" {
+		t.Errorf("unexpected connection info %+v", info)
+	}
+
+	for _, tc := range []struct {
+		method   string
+		target   string
+		expected int
+	}{
+		{http.MethodGet, "/unknown", http.StatusNotFound},
+		{http.MethodPost, "/", http.StatusMethodNotAllowed},
+		{http.MethodDelete, "/databases/test", http.StatusBadRequest},
+		{http.MethodDelete, "/databases/template1", http.StatusBadRequest},
+		{http.MethodPost, "/databases/", http.StatusBadRequest},
+		{http.MethodGet, "/databases/other", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/snapshots/postgres", http.StatusBadRequest},
+		{http.MethodGet, "/reset", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/reset?snapshot=template0", http.StatusBadRequest},
+		{http.MethodGet, "/stop", http.StatusMethodNotAllowed},
+	} {
+		if w := request(tc.method, tc.target); w.Code != tc.expected {
+			t.Errorf("%s %s: expected status %d, got %d: %s", tc.method, tc.target, tc.expected, w.Code, w.Body)
+		}
+	}
+	if stopped {
+		t.Errorf("expected the instance not to be stopped yet")
+	}
+
+	if w := request(http.MethodPost, "/stop"); w.Code != http.StatusOK || !stopped {
+		t.Errorf("expected /stop to stop the instance, got %d", w.Code)
+	}
+}
+
+func TestListenControl(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := &controlServer{pg: &pgxtest.PG{Host: "/tmp/sock", User: "test", Name: "test"}, token: "secret"}
+
+	for _, addr := range []string{":0", "0.0.0.0:0", "192.0.2.1:0", "example.com:0"} {
+		if _, err := listenControl(ctx, addr, s); err == nil {
+			t.Errorf("expected %s to be rejected", addr)
+		}
+	}
+
+	u, err := listenControl(ctx, "127.0.0.1:0", s)
+	if err != nil {
+		t.Fatalf("failed to listen on loopback: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to call the control API: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+}
+
+func TestNewControlToken(t *testing.T) {
+	a, err := newControlToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := newControlToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a) != 32 || a == b {
+		t.Errorf("expected distinct random tokens, got %q and %q", a, b)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dottedmag/pgxtest"
+)
+
+// How often the dev instance is checked for being alive
+const devHealthInterval = 5 * time.Second
+
+// First line of the env file, marking it as safe to overwrite and remove
+const envHeader = "# Written by pgxtest dev, removed when it stops"
+
+// Options of the dev command
+type devOptions struct {
+	dir      string
+	envFile  string
+	binDir   string
+	control  string
+	keep     bool
+	postgres []string // Arguments of postgres
+}
+
+func parseDevFlags(args []string) (devOptions, error) {
+	var opts devOptions
+	flags := flag.NewFlagSet("dev", flag.ContinueOnError)
+	flags.StringVar(&opts.dir, "dir", ".pgxtest", "directory for the instance data and socket")
+	flags.StringVar(&opts.envFile, "env", ".env.pgxtest", "file to write connection settings to, an existing file is only replaced if pgxtest wrote it")
+	flags.StringVar(&opts.binDir, "bin-dir", "", "directory with PostgreSQL binaries")
+	flags.StringVar(&opts.control, "control", "", "serve the control API on `address` (host:port or unix:path)")
+	flags.BoolVar(&opts.keep, "keep", os.Getenv("PGXTEST_PERSISTENT") != "", "keep the data between runs (default from PGXTEST_PERSISTENT)")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: pgxtest dev [flags] [-- postgres arguments]\n\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return devOptions{}, err
+	}
+	opts.postgres = flags.Args()
+	return opts, nil
+}
+
+func dev(args []string) error {
+	opts, err := parseDevFlags(args)
+	if err != nil {
+		return err
+	}
+
+	instanceDir, err := filepath.Abs(opts.dir)
+	if err != nil {
+		return err
+	}
+
+	// Leftovers of a previous run that was not shut down cleanly
+	if _, err := os.Stat(filepath.Join(instanceDir, "pgxtest.json")); err == nil && !opts.keep {
+		if err := os.RemoveAll(instanceDir); err != nil {
+			return err
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pg, err := pgxtest.Start(ctx, pgxtest.Config{
+		BinDir:         opts.binDir,
+		Dir:            instanceDir,
+		Persistent:     opts.keep,
+		AdditionalArgs: opts.postgres,
+		Labels:         map[string]string{"command": "dev"},
+	})
+	if err != nil {
+		return err
+	}
+	defer pg.Stop()
+
+	token, err := newControlToken()
+	if err != nil {
+		return err
+	}
+	s := &controlServer{pg: pg, stop: cancel, token: token}
+	env := map[string]string{
+		"PGHOST":       pg.Host,
+		"PGUSER":       pg.User,
+		"PGDATABASE":   pg.Name,
+		"DATABASE_URL": s.connectionInfo(pg.Name).URL,
+	}
+
+	if opts.control != "" {
+		controlURL, err := listenControl(ctx, opts.control, s)
+		if err != nil {
+			return fmt.Errorf("failed to start control API: %w", err)
+		}
+		env["PGXTEST_CONTROL_URL"] = controlURL
+		env["PGXTEST_CONTROL_TOKEN"] = token
+		fmt.Printf("Control API is available at %s with the token %s\n", controlURL, token)
+	}
+
+	if err := writeEnv(opts.envFile, env); err != nil {
+		return err
+	}
+	defer os.Remove(opts.envFile)
+
+	fmt.Printf("PostgreSQL is running, connection settings are in %s\n\n", opts.envFile)
+	fmt.Printf("  psql -h %s -U %s %s\n\n", pg.Host, pg.User, pg.Name)
+	fmt.Println("Press Ctrl-C to stop")
+
+	ticker := time.NewTicker(devHealthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := pg.Pool.Ping(ctx); err != nil && ctx.Err() == nil {
+				return fmt.Errorf("PostgreSQL is not responding: %w", err)
+			}
+		}
+	}
+}
+
+// writeEnv writes the connection settings to path. It refuses to replace a
+// file not written by pgxtest, such as the .env of the project.
+func writeEnv(path string, env map[string]string) error {
+	existing, err := os.ReadFile(path)
+	if err == nil && !strings.HasPrefix(string(existing), envHeader+"\n") {
+		return fmt.Errorf("%s was not written by pgxtest, choose another file with -env", path)
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(envHeader + "\n")
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s=%s\n", k, env[k])
+	}
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
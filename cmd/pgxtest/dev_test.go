@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDevFlags(t *testing.T) {
+	t.Setenv("PGXTEST_PERSISTENT", "")
+
+	opts, err := parseDevFlags(nil)
+	if err != nil {
+		t.Fatalf("failed to parse flags: %v", err)
+	}
+	if opts.dir != ".pgxtest" || opts.envFile != ".env.pgxtest" || opts.keep || opts.control != "" {
+		t.Errorf("unexpected defaults %+v", opts)
+	}
+
+	opts, err = parseDevFlags([]string{"-env", "dev.env", "-keep", "-control", "unix:/tmp/c", "--", "-c", "fsync=on"})
+	if err != nil {
+		t.Fatalf("failed to parse flags: %v", err)
+	}
+	expected := devOptions{
+		dir:      ".pgxtest",
+		envFile:  "dev.env",
+		control:  "unix:/tmp/c",
+		keep:     true,
+		postgres: []string{"-c", "fsync=on"},
+	}
+	if !reflect.DeepEqual(opts, expected) {
+		t.Errorf("expected %+v, got %+v", expected, opts)
+	}
+
+	t.Setenv("PGXTEST_PERSISTENT", "1")
+	if opts, err := parseDevFlags(nil); err != nil || !opts.keep {
+		t.Errorf("expected PGXTEST_PERSISTENT to keep the data (%v)", err)
+	}
+
+	if _, err := parseDevFlags([]string{"-no-such-flag"}); err == nil {
+		t.Errorf("expected an unknown flag to fail")
+	}
+}
+
+func TestWriteEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env.pgxtest")
+	if err := writeEnv(path, map[string]string{"PGUSER": "test", "PGHOST": "/tmp/sock"}); err != nil {
+		t.Fatalf("failed to write env: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := envHeader + "\nPGHOST=/tmp/sock\nPGUSER=test\n"
+	if string(data) != expected {
+		t.Errorf("expected %q, got %q", expected, data)
+	}
+
+	// A file left by a previous run is replaced
+	if err := writeEnv(path, map[string]string{"PGUSER": "other"}); err != nil {
+		t.Errorf("failed to replace env: %v", err)
+	}
+}
+
+func TestWriteEnvKeepsForeignFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("SECRET=1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := writeEnv(path, map[string]string{"PGUSER": "test"})
+	if err == nil || !strings.Contains(err.Error(), "not written by pgxtest") {
+		t.Errorf("expected an error for a file not written by pgxtest, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "SECRET=1\n" {
+		t.Errorf("expected the file to be kept, got %q", data)
+	}
+}
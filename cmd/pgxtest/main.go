@@ -0,0 +1,52 @@
+// Command pgxtest manages PostgreSQL instances outside of Go tests.
+//
+// Usage:
+//
+//	pgxtest dev [flags] [-- postgres arguments]
+//
+// The dev command starts an instance for local development, writes its
+// connection settings to a .env.pgxtest file and keeps it running until interrupted.
+// With -control it also serves an HTTP API for creating databases, taking
+// snapshots and resetting the test database from other processes. The API
+// only listens on loopback addresses or UNIX sockets and requires the token
+// printed at startup, also written to the env file as PGXTEST_CONTROL_TOKEN.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags]\n\nCommands:\n  dev   run a development instance until interrupted\n", os.Args[0])
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "dev":
+		err = dev(os.Args[2:])
+	case "-h", "-help", "--help", "help":
+		usage()
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pgxtest: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,90 @@
+package pgxtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// CollationMismatch describes a database whose recorded collation version
+// differs from the version provided by the operating system
+type CollationMismatch struct {
+	Database string
+	Recorded string
+	Actual   string
+}
+
+// CollationMismatchError is returned by Start if the data directory was
+// created with a different version of the collation library, typically before
+// an operating system upgrade. Indexes on text columns of such databases may be
+// silently corrupted.
+type CollationMismatchError struct {
+	Mismatches []CollationMismatch
+}
+
+func (e *CollationMismatchError) Error() string {
+	var b strings.Builder
+	b.WriteString("collation version mismatch, the data directory was created with a different collation library:")
+	for _, m := range e.Mismatches {
+		fmt.Fprintf(&b, "\n  database %q: recorded %s, actual %s", m.Database, m.Recorded, m.Actual)
+	}
+	b.WriteString("\nRemove the data directory to rebuild it from scratch, or run REINDEX DATABASE and ALTER DATABASE ... REFRESH COLLATION VERSION")
+	return b.String()
+}
+
+// checkCollationVersions returns CollationMismatchError if any database has a
+// collation version mismatch. Servers before PostgreSQL 15 do not record
+// database collation versions and are not checked.
+func checkCollationVersions(ctx context.Context, pool *pgxpool.Pool) error {
+	rows, err := pool.Query(ctx, `
+		SELECT datname, datcollversion, pg_database_collation_actual_version(oid)
+		FROM pg_database
+		WHERE datallowconn
+		  AND datcollversion IS DISTINCT FROM pg_database_collation_actual_version(oid)
+		ORDER BY datname`)
+	if isUndefinedObject(err) { // PostgreSQL < 15
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var mismatches []CollationMismatch
+	for rows.Next() {
+		var m CollationMismatch
+		var recorded, actual *string
+		if err := rows.Scan(&m.Database, &recorded, &actual); err != nil {
+			return err
+		}
+		if recorded == nil || actual == nil {
+			// Collation provider does not report versions
+			continue
+		}
+		m.Recorded, m.Actual = *recorded, *actual
+		mismatches = append(mismatches, m)
+	}
+	err = rows.Err()
+	if isUndefinedObject(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(mismatches) > 0 {
+		return &CollationMismatchError{Mismatches: mismatches}
+	}
+	return nil
+}
+
+// isUndefinedObject reports undefined_column and undefined_function errors
+// caused by catalog features missing in older PostgreSQL versions
+func isUndefinedObject(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == "42703" || pgErr.Code == "42883")
+}
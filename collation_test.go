@@ -0,0 +1,22 @@
+package pgxtest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCollationMismatchError(t *testing.T) {
+	err := fmt.Errorf("Failed to check collation versions: %w", &CollationMismatchError{
+		Mismatches: []CollationMismatch{{Database: "test", Recorded: "2.31", Actual: "2.36"}},
+	})
+
+	var mismatch *CollationMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("expected CollationMismatchError in %v", err)
+	}
+	if !strings.Contains(err.Error(), `database "test": recorded 2.31, actual 2.36`) {
+		t.Errorf("unexpected error message: %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package pgxtest
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCopyFrom(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg := StartT(t, Config{
+		InitScripts: []string{"CREATE TABLE t (id int, name text)"},
+	})
+
+	inputs := []struct {
+		format CopyFormat
+		data   string
+	}{
+		{CopyText, "1\tone\n2\t\\N\n"},
+		{CopyCSV, "3,three\n4,\n"},
+		{CopyCSVHeader, "id,name\n5,five\n"},
+	}
+	for _, in := range inputs {
+		if _, err := pg.CopyFrom(ctx, "t", strings.NewReader(in.data), in.format); err != nil {
+			t.Fatalf("failed to copy %s: %v", in.format, err)
+		}
+	}
+
+	// Round trip through the binary format
+	var binary bytes.Buffer
+	conn, err := pg.Pool.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("failed to acquire connection: %v", err)
+	}
+	_, err = conn.Conn().PgConn().CopyTo(ctx, &binary, "COPY t TO STDOUT WITH (FORMAT binary)")
+	conn.Release()
+	if err != nil {
+		t.Fatalf("failed to copy out: %v", err)
+	}
+	n, err := pg.CopyFrom(ctx, "public.t", &binary, CopyBinary)
+	if err != nil {
+		t.Fatalf("failed to copy binary: %v", err)
+	}
+	if n != 5 {
+		t.Errorf("expected 5 rows copied, got %d", n)
+	}
+
+	var count, nulls int
+	if err := pg.Pool.QueryRow(ctx, "SELECT count(*), count(*) FILTER (WHERE name IS NULL) FROM t").Scan(&count, &nulls); err != nil {
+		t.Fatalf("failed to count rows: %v", err)
+	}
+	if count != 10 || nulls != 4 {
+		t.Errorf("expected 10 rows with 4 NULL names, got %d and %d", count, nulls)
+	}
+}
+
+func TestCopyFromUnknownFormat(t *testing.T) {
+	pg := &PG{}
+	if _, err := pg.CopyFrom(context.Background(), "t", strings.NewReader(""), "xml"); err == nil {
+		t.Errorf("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,94 @@
+package pgxtest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// collectCoreDumps moves core files left in the data directory by crashed
+// server processes to a subdirectory of Config.CoreDumpDir named after the
+// instance, and describes the server in its info.txt
+func (p *PG) collectCoreDumps() error {
+	if p.config.CoreDumpDir == "" {
+		return nil
+	}
+
+	cores, err := findCoreDumps(p.dataDir)
+	if err != nil || len(cores) == 0 {
+		return err
+	}
+
+	dest := filepath.Join(p.config.CoreDumpDir, filepath.Base(p.dir))
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return fmt.Errorf("Failed to collect core dumps: %w", err)
+	}
+	for _, core := range cores {
+		if err := moveFile(core, filepath.Join(dest, filepath.Base(core))); err != nil {
+			return fmt.Errorf("Failed to collect core dump %s: %w", core, err)
+		}
+	}
+
+	postgres := filepath.Join(p.binPath, "postgres")
+	version, err := exec.Command(postgres, "--version").Output()
+	if err != nil {
+		version = []byte(fmt.Sprintf("unknown: %v\n", err))
+	}
+	info := fmt.Sprintf("binary: %s\nversion: %s", postgres, version)
+	if err := os.WriteFile(filepath.Join(dest, "info.txt"), []byte(info), 0644); err != nil {
+		return fmt.Errorf("Failed to collect core dumps: %w", err)
+	}
+	return nil
+}
+
+// findCoreDumps returns the core files in dir, named "core" or "core.*"
+func findCoreDumps(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cores []string
+	for _, e := range entries {
+		if e.Type().IsRegular() && (e.Name() == "core" || strings.HasPrefix(e.Name(), "core.")) {
+			cores = append(cores, filepath.Join(dir, e.Name()))
+		}
+	}
+	return cores, nil
+}
+
+// moveFile renames src to dst, copying it if they are on different
+// filesystems
+func moveFile(src, dst string) error {
+	err := os.Rename(src, dst)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
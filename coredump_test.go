@@ -0,0 +1,50 @@
+package pgxtest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCollectCoreDumps(t *testing.T) {
+	dir := t.TempDir()
+	dataDir := filepath.Join(dir, "pgxtest123", "data")
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		t.Fatalf("failed to create data directory: %v", err)
+	}
+	for _, name := range []string{"core.4242", "postgresql.conf"} {
+		if err := os.WriteFile(filepath.Join(dataDir, name), []byte("x"), 0644); err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+	}
+
+	coreDumpDir := filepath.Join(dir, "cores")
+	p := &PG{
+		dir:     filepath.Join(dir, "pgxtest123"),
+		dataDir: dataDir,
+		binPath: "/nonexistent",
+		config:  Config{CoreDumpDir: coreDumpDir},
+	}
+	if err := p.collectCoreDumps(); err != nil {
+		t.Fatalf("failed to collect core dumps: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(coreDumpDir, "pgxtest123", "core.4242")); err != nil {
+		t.Errorf("expected core dump to be collected: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, "core.4242")); !os.IsNotExist(err) {
+		t.Errorf("expected core dump to be moved, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(coreDumpDir, "pgxtest123", "postgresql.conf")); !os.IsNotExist(err) {
+		t.Errorf("expected only core dumps to be collected, got %v", err)
+	}
+
+	info, err := os.ReadFile(filepath.Join(coreDumpDir, "pgxtest123", "info.txt"))
+	if err != nil {
+		t.Fatalf("failed to read info: %v", err)
+	}
+	if !strings.Contains(string(info), "/nonexistent/postgres") {
+		t.Errorf("expected binary path in info, got %q", info)
+	}
+}
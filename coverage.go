@@ -0,0 +1,111 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// FunctionCoverage describes a procedural language function in the test
+// database and how many times it was called.
+type FunctionCoverage struct {
+	Schema    string
+	Name      string
+	Signature string // Function name with argument types, e.g. public.f(integer)
+	Language  string
+	Trigger   bool     // Function returns trigger
+	Triggers  []string // Triggers using the function, as table.trigger
+	Calls     int64
+}
+
+// Covered reports whether the function was called at least once.
+func (c FunctionCoverage) Covered() bool {
+	return c.Calls > 0
+}
+
+// FunctionCoverage reports call counts of all procedural language functions
+// (including trigger functions) in the user schemas of the test database.
+//
+// Requires Config.TrackFunctions. PostgreSQL publishes function statistics
+// asynchronously, so calls made during the last second might not be counted
+// yet.
+func (p *PG) FunctionCoverage(ctx context.Context) ([]FunctionCoverage, error) {
+	var tracking string
+	if err := p.Pool.QueryRow(ctx, "SHOW track_functions").Scan(&tracking); err != nil {
+		return nil, err
+	}
+	if tracking == "none" {
+		return nil, fmt.Errorf("function statistics are not collected, enable Config.TrackFunctions")
+	}
+
+	rows, err := p.Pool.Query(ctx, `
+		SELECT n.nspname, f.proname,
+		       n.nspname || '.' || f.proname || '(' || pg_get_function_identity_arguments(f.oid) || ')',
+		       l.lanname,
+		       f.prorettype = 'trigger'::regtype,
+		       coalesce(array_agg(t.tgrelid::regclass::text || '.' || t.tgname ORDER BY t.tgname)
+		                FILTER (WHERE t.tgname IS NOT NULL), '{}'),
+		       coalesce(max(s.calls), 0)
+		FROM pg_proc f
+		JOIN pg_namespace n ON n.oid = f.pronamespace
+		JOIN pg_language l ON l.oid = f.prolang
+		LEFT JOIN pg_trigger t ON t.tgfoid = f.oid AND NOT t.tgisinternal
+		LEFT JOIN pg_stat_user_functions s ON s.funcid = f.oid
+		WHERE l.lanname NOT IN ('c', 'internal', 'sql')
+		  AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+		  AND n.nspname NOT LIKE 'pg_toast%'
+		  AND NOT EXISTS (
+		      SELECT 1 FROM pg_depend d
+		      WHERE d.classid = 'pg_proc'::regclass AND d.objid = f.oid AND d.deptype = 'e'
+		  )
+		GROUP BY n.nspname, f.proname, f.oid, l.lanname, f.prorettype
+		ORDER BY 1, 3`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var report []FunctionCoverage
+	for rows.Next() {
+		var c FunctionCoverage
+		if err := rows.Scan(&c.Schema, &c.Name, &c.Signature, &c.Language, &c.Trigger, &c.Triggers, &c.Calls); err != nil {
+			return nil, err
+		}
+		report = append(report, c)
+	}
+	return report, rows.Err()
+}
+
+// WriteFunctionCoverage writes a human-readable coverage report, listing
+// functions that were never called first.
+func WriteFunctionCoverage(w io.Writer, report []FunctionCoverage) error {
+	covered := 0
+	for _, c := range report {
+		if c.Covered() {
+			covered++
+		}
+	}
+	if _, err := fmt.Fprintf(w, "functions covered: %d/%d\n", covered, len(report)); err != nil {
+		return err
+	}
+
+	for _, uncovered := range []bool{true, false} {
+		for _, c := range report {
+			if c.Covered() == uncovered {
+				continue
+			}
+			mark := "+"
+			if !c.Covered() {
+				mark = "-"
+			}
+			line := fmt.Sprintf("%s %s calls=%d", mark, c.Signature, c.Calls)
+			for _, trigger := range c.Triggers {
+				line += " trigger=" + trigger
+			}
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
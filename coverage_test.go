@@ -0,0 +1,62 @@
+package pgxtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFunctionCoverage(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{TrackFunctions: true})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	_, err = pg.Pool.Exec(ctx, `
+		CREATE FUNCTION called() RETURNS int LANGUAGE plpgsql AS $$ BEGIN RETURN 1; END $$;
+		CREATE FUNCTION uncalled() RETURNS int LANGUAGE plpgsql AS $$ BEGIN RETURN 2; END $$;
+	`)
+	if err != nil {
+		t.Fatalf("failed to create functions: %v", err)
+	}
+	if _, err := pg.Pool.Exec(ctx, "SELECT called()"); err != nil {
+		t.Fatalf("failed to call function: %v", err)
+	}
+
+	var report []FunctionCoverage
+	err = retry(func() error {
+		var err error
+		report, err = pg.FunctionCoverage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, c := range report {
+			if c.Name == "called" && c.Covered() {
+				return nil
+			}
+		}
+		return errors.New("function is not covered yet")
+	}, 100, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("failed to get coverage: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteFunctionCoverage(&buf, report); err != nil {
+		t.Fatalf("failed to write report: %v", err)
+	}
+	if !strings.Contains(buf.String(), "- public.uncalled()") {
+		t.Errorf("expected uncalled function in report, got:\n%s", buf.String())
+	}
+}
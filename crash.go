@@ -0,0 +1,73 @@
+package pgxtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Time allowed for listing the server processes before a crash
+const crashListTimeout = 5 * time.Second
+
+// Crash kills the postmaster and the processes it has started with SIGKILL,
+// like a power loss: transactions in flight are lost, the next start runs WAL
+// recovery. Connections of the Pool fail until StartAfterCrash.
+//
+// Stop can be called on a crashed server without starting it again.
+func (p *PG) Crash() error {
+	if p.cmd == nil {
+		return fmt.Errorf("the server is not owned by this instance")
+	}
+	if p.crashed {
+		return nil
+	}
+	if err := p.pauseExpiry(); err != nil {
+		return err
+	}
+	defer p.resumeExpiry()
+
+	ctx, cancel := context.WithTimeout(context.Background(), crashListTimeout)
+	defer cancel()
+	// Backends outlive a killed postmaster until they notice, kill them too
+	pids, err := p.serverProcesses(ctx)
+	if err != nil {
+		pids = []int{p.cmd.Process.Pid}
+	}
+
+	var errs []error
+	for _, pid := range pids {
+		if proc, err := os.FindProcess(pid); err == nil {
+			if err := proc.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+				errs = append(errs, err)
+			}
+		}
+	}
+	// Doesn't matter that the server exits with an error
+	_ = p.cmd.Wait()
+	p.serverLog.Close()
+	p.crashed = true
+	p.Pool.Reset()
+	return errors.Join(errs...)
+}
+
+// StartAfterCrash starts the server killed by Crash again on the same data
+// directory and waits until it has recovered and accepts connections. The
+// Pool is kept and reconnects.
+func (p *PG) StartAfterCrash(ctx context.Context) error {
+	if !p.crashed {
+		return fmt.Errorf("the server has not crashed")
+	}
+	if err := p.pauseExpiry(); err != nil {
+		return err
+	}
+	defer p.resumeExpiry()
+	if err := p.restartServer(ctx); err != nil {
+		return fmt.Errorf("Failed to recover PostgreSQL: %w", err)
+	}
+	p.crashed = false
+	// Drop connections broken by the crash
+	p.Pool.Reset()
+	return nil
+}
@@ -0,0 +1,70 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCrashRecovery(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	if _, err := pg.Pool.Exec(ctx, "CREATE TABLE committed (val int); INSERT INTO committed VALUES (1)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	tx, err := pg.Pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("failed to begin: %v", err)
+	}
+	if _, err := tx.Exec(ctx, "INSERT INTO committed VALUES (2)"); err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+
+	if err := pg.Crash(); err != nil {
+		t.Fatalf("failed to crash: %v", err)
+	}
+	if err := tx.Commit(ctx); err == nil {
+		t.Errorf("expected the commit to fail after the crash")
+	}
+	if err := pg.Pool.Ping(ctx); err == nil {
+		t.Errorf("expected ping to fail after the crash")
+	}
+
+	if err := pg.StartAfterCrash(ctx); err != nil {
+		t.Fatalf("failed to start after crash: %v", err)
+	}
+	var n int
+	if err := pg.Pool.QueryRow(ctx, "SELECT count(*) FROM committed").Scan(&n); err != nil {
+		t.Fatalf("failed to count rows: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expected only the committed row to survive, got %d rows", n)
+	}
+}
+
+func TestStopAfterCrash(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	if err := pg.Crash(); err != nil {
+		t.Errorf("failed to crash: %v", err)
+	}
+	if err := pg.Stop(); err != nil {
+		t.Errorf("failed to stop pgxtest: %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package pgxtest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Time allowed for sessions of the template database to go away
+const cloneTimeout = 5 * time.Second
+
+// CreateDatabase clones a fresh database from the database of p and returns a
+// PG connected to it. The database is dropped in t.Cleanup, which also runs if
+// the test panics.
+//
+// Start one server, create the schema and seed data in its database, and call
+// CreateDatabase in every parallel test to get an isolated copy of it for the
+// cost of CREATE DATABASE. PostgreSQL refuses to copy a database while other
+// sessions are connected to it: idle connections of p.Pool are closed for the
+// duration of the copy, so avoid holding connections of p.Pool while tests
+// clone it.
+//
+// The database is named after the test, see PG.Name, to make it easy to find
+// in pg_stat_activity and server logs.
+func (p *PG) CreateDatabase(ctx context.Context, t testing.TB) *PG {
+	t.Helper()
+
+	var name string
+	for {
+		name = testDatabaseName(t.Name())
+		err := p.cloneDatabase(ctx, p.Name, name)
+		if err == nil {
+			break
+		}
+		// Left behind by a test process that crashed before cleaning up
+		if !hasSQLState(err, "42P04") {
+			t.Fatalf("failed to clone database %s: %v", p.Name, err)
+		}
+	}
+
+	clone := &PG{
+		Host: p.Host,
+		Port: p.Port,
+		User: p.User,
+		Name: name,
+
+		MajorVersion: p.MajorVersion,
+		CAFile:       p.CAFile,
+
+		config: p.config,
+	}
+	clone.release = func() error {
+		return withAdminConn(context.Background(), p.Host, p.Port, func(conn *pgx.Conn) error {
+			return dropDatabase(context.Background(), conn, name)
+		})
+	}
+	t.Cleanup(func() {
+		if err := clone.Stop(); err != nil {
+			t.Errorf("failed to drop database %s: %v", name, err)
+		}
+	})
+
+	poolConf, err := testPoolConfig(p.Host, p.Port, name, p.config)
+	if err == nil {
+		clone.Pool, err = pgxpool.NewWithConfig(ctx, poolConf)
+	}
+	if err != nil {
+		t.Fatalf("failed to connect to database %s: %v", name, err)
+	}
+	return clone
+}
+
+// Longest database name accepted by PostgreSQL
+const maxDatabaseName = 63
+
+var testDatabaseNames struct {
+	mu   sync.Mutex
+	used map[string]int
+}
+
+// testDatabaseName returns a database name derived from the name of the test.
+// The sanitized test name is truncated if needed and followed by a hash of
+// the full test name and the process, and by a counter if the test creates
+// several databases.
+func testDatabaseName(testName string) string {
+	h := sha256.Sum256([]byte(fmt.Sprintf("%s %d", testName, os.Getpid())))
+	suffix := "_" + hex.EncodeToString(h[:4])
+
+	var b strings.Builder
+	underscore := true
+	for _, r := range strings.ToLower(testName) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			underscore = false
+		} else if !underscore {
+			b.WriteByte('_')
+			underscore = true
+		}
+	}
+	// Leave room for the counter
+	base := b.String()
+	base = strings.TrimRight(base[:min(len(base), maxDatabaseName-len(suffix)-4)], "_")
+	if base == "" {
+		base = "test"
+	}
+	base += suffix
+
+	testDatabaseNames.mu.Lock()
+	defer testDatabaseNames.mu.Unlock()
+
+	if testDatabaseNames.used == nil {
+		testDatabaseNames.used = map[string]int{}
+	}
+	n := testDatabaseNames.used[base]
+	testDatabaseNames.used[base]++
+	if n == 0 {
+		return base
+	}
+	return fmt.Sprintf("%s_%d", base, n+1)
+}
+
+// cloneDatabase creates database name from the template, waiting for
+// sessions of p connected to it to go away
+func (p *PG) cloneDatabase(ctx context.Context, template string, name string) error {
+	deadline := time.Now().Add(cloneTimeout)
+	for {
+		p.Pool.Reset()
+		err := withAdminConn(ctx, p.Host, p.Port, func(conn *pgx.Conn) error {
+			return createDatabase(ctx, conn, name, template)
+		})
+		if err == nil || !isObjectInUse(err) || time.Now().After(deadline) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// isObjectInUse reports errors caused by other sessions connected to the
+// template database
+func isObjectInUse(err error) bool {
+	return hasSQLState(err, "55006")
+}
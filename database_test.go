@@ -0,0 +1,80 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCreateDatabase(t *testing.T) {
+	ctx := context.Background()
+
+	pg, err := Start(ctx, Config{})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	if _, err := pg.Pool.Exec(ctx, "CREATE TABLE test (val text); INSERT INTO test VALUES ('seed')"); err != nil {
+		t.Fatalf("failed to seed database: %v", err)
+	}
+
+	t.Run("group", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			i := i
+			t.Run(fmt.Sprintf("clone%d", i), func(t *testing.T) {
+				t.Parallel()
+
+				db := pg.CreateDatabase(ctx, t)
+				if !strings.HasPrefix(db.Name, fmt.Sprintf("testcreatedatabase_group_clone%d_", i)) {
+					t.Fatalf("expected a database named after the test, got %q", db.Name)
+				}
+
+				if _, err := db.Pool.Exec(ctx, "INSERT INTO test VALUES ('clone')"); err != nil {
+					t.Fatalf("failed to insert: %v", err)
+				}
+				var n int
+				if err := db.Pool.QueryRow(ctx, "SELECT count(*) FROM test").Scan(&n); err != nil {
+					t.Fatalf("failed to count rows: %v", err)
+				}
+				if n != 2 {
+					t.Errorf("expected seed and own row, got %d rows", n)
+				}
+			})
+		}
+	})
+
+	var n int
+	if err := pg.Pool.QueryRow(ctx, "SELECT count(*) FROM pg_database WHERE datname LIKE 'testcreatedatabase\\_%'").Scan(&n); err != nil {
+		t.Fatalf("failed to count databases: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("expected cloned databases to be dropped, got %d", n)
+	}
+}
+
+func TestTestDatabaseName(t *testing.T) {
+	name := testDatabaseName("TestFoo/with spaces,and-punctuation")
+	if !strings.HasPrefix(name, "testfoo_with_spaces_and_punctuation_") {
+		t.Errorf("unexpected name %q", name)
+	}
+	if again := testDatabaseName("TestFoo/with spaces,and-punctuation"); again != name+"_2" {
+		t.Errorf("expected %q for the second database, got %q", name+"_2", again)
+	}
+
+	long := testDatabaseName(strings.Repeat("TestLong/", 20))
+	if len(long) > maxDatabaseName {
+		t.Errorf("name %q is longer than %d", long, maxDatabaseName)
+	}
+	if strings.Contains(long, "__") {
+		t.Errorf("unexpected double underscore in %q", long)
+	}
+	if other := testDatabaseName(strings.Repeat("TestLong/", 19) + "Other"); other[:len(other)-9] != long[:len(long)-9] || other == long {
+		t.Errorf("expected names differing only by hash, got %q and %q", long, other)
+	}
+}
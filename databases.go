@@ -0,0 +1,97 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// validateDatabases checks the names of Config.Databases
+func validateDatabases(names []string) error {
+	seen := map[string]bool{"test": true}
+	for _, name := range names {
+		if name == "" || len(name) > maxDatabaseName {
+			return fmt.Errorf("Invalid database name %q", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("Duplicate database %s", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// createDatabases creates the additional databases of Config.Databases
+func createDatabases(ctx context.Context, host string, port int, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	return withAdminConn(ctx, host, port, func(conn *pgx.Conn) error {
+		for _, name := range names {
+			if _, err := conn.Exec(ctx, "CREATE DATABASE "+pgx.Identifier{name}.Sanitize()); err != nil {
+				return fmt.Errorf("Failed to create database %s: %w", name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// PoolFor returns a pool connected to the named database on the server, such
+// as one of Config.Databases. The pool of the test database is the Pool
+// field. Pools are created on first use and closed by Stop.
+func (p *PG) PoolFor(name string) (*pgxpool.Pool, error) {
+	if name == p.Name {
+		return p.Pool, nil
+	}
+	return p.pool(name, Role{})
+}
+
+// Key of the pools created by PoolFor and PoolAs
+type poolKey struct {
+	database string
+	role     string
+}
+
+// pool returns the pool connected to the database as the role, or as the
+// test user if role is empty
+func (p *PG) pool(name string, role Role) (*pgxpool.Pool, error) {
+	p.poolsMu.Lock()
+	defer p.poolsMu.Unlock()
+	key := poolKey{database: name, role: role.Name}
+	if pool, ok := p.pools[key]; ok {
+		return pool, nil
+	}
+
+	conf, err := testPoolConfig(p.Host, p.Port, name, p.config)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create pgx pool config: %w", err)
+	}
+	if role.Name != "" {
+		conf.ConnConfig.User = role.Name
+		conf.ConnConfig.Password = role.Password
+	}
+	if p.expired != nil {
+		conf.BeforeConnect = refuseExpired(p.expired)
+	}
+	pool, err := pgxpool.NewWithConfig(context.Background(), conf)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect to database %s: %w", name, err)
+	}
+	if p.pools == nil {
+		p.pools = map[poolKey]*pgxpool.Pool{}
+	}
+	p.pools[key] = pool
+	return pool, nil
+}
+
+// closePools closes the pools created by PoolFor
+func (p *PG) closePools() {
+	p.poolsMu.Lock()
+	defer p.poolsMu.Unlock()
+	for _, pool := range p.pools {
+		pool.Close()
+	}
+	p.pools = nil
+}
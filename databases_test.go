@@ -0,0 +1,55 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDatabases(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{Databases: []string{"audit", "analytics"}})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	audit, err := pg.PoolFor("audit")
+	if err != nil {
+		t.Fatalf("failed to get pool: %v", err)
+	}
+	if _, err := audit.Exec(ctx, "CREATE TABLE events (val text)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	for name, want := range map[string]bool{"test": false, "audit": true, "analytics": false} {
+		pool, err := pg.PoolFor(name)
+		if err != nil {
+			t.Fatalf("failed to get pool: %v", err)
+		}
+		var db string
+		var exists bool
+		if err := pool.QueryRow(ctx, "SELECT current_database(), to_regclass('events') IS NOT NULL").Scan(&db, &exists); err != nil {
+			t.Fatalf("failed to query %s: %v", name, err)
+		}
+		if db != name || exists != want {
+			t.Errorf("database %s: got %s with events table %t", name, db, exists)
+		}
+	}
+}
+
+func TestValidateDatabases(t *testing.T) {
+	for _, names := range [][]string{{"test"}, {""}, {"a", "a"}} {
+		if err := validateDatabases(names); err == nil {
+			t.Errorf("%q: expected an error", names)
+		}
+	}
+	if err := validateDatabases([]string{"audit", "analytics"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
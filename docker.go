@@ -0,0 +1,203 @@
+package pgxtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Docker CLI used by DockerBackend unless configured otherwise
+const defaultDockerCommand = "docker"
+
+// Image run by DockerBackend unless configured otherwise, or Config.Version
+// is set
+const defaultDockerImage = "postgres:16"
+
+// DockerBackend runs the server in a container of the official postgres
+// image, for machines without PostgreSQL installed. The container is removed
+// on Stop.
+//
+// The server is reached over TCP on a port of localhost. Features that need
+// the files or binaries of the server (e.g. WALSince, Upgrade, WALDir,
+// InitDBCache, CoreDumpDir, Config.TTL) are not available.
+type DockerBackend struct {
+	Image   string // Default "postgres:" followed by plain Config.Version, or "postgres:16"
+	Command string // Docker-compatible CLI, e.g. podman, default "docker"
+}
+
+func (b DockerBackend) start(ctx context.Context, config Config) (_ *PG, err error) {
+	docker := b.Command
+	if docker == "" {
+		docker = defaultDockerCommand
+	}
+
+	if err := validateSettings(config.Settings); err != nil {
+		return nil, err
+	}
+	if config.Password != "" {
+		return nil, fmt.Errorf("Config.Password is not supported by DockerBackend")
+	}
+	if config.HBA != nil {
+		return nil, fmt.Errorf("Config.HBA is not supported by DockerBackend")
+	}
+	if config.TLS {
+		return nil, fmt.Errorf("Config.TLS is not supported by DockerBackend")
+	}
+	if config.PITR {
+		return nil, fmt.Errorf("Config.PITR is not supported by DockerBackend")
+	}
+
+	out, err := exec.CommandContext(ctx, docker, dockerRunArgs(b.Image, config)...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to start PostgreSQL container: %w%s", err, exitStderr(err))
+	}
+	id := strings.TrimSpace(string(out))
+
+	remove := func() error {
+		out, err := exec.Command(docker, "rm", "-f", "-v", id).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("Failed to remove PostgreSQL container: %w: %s", err, out)
+		}
+		return nil
+	}
+
+	// Follow the output of the server like that of a local one
+	stdout := newRingBuffer(config.OutputLimit)
+	stderr := newRingBuffer(config.OutputLimit)
+	logs := exec.Command(docker, "logs", "-f", id)
+	serverLog := newServerLogWriter(config.ServerLogger)
+	if logs.Stdout, logs.Stderr, err = serverWriters(config, serverLog, stdout, stderr); err != nil {
+		return nil, errors.Join(err, remove())
+	}
+	if err := logs.Start(); err != nil {
+		return nil, fmt.Errorf("Failed to follow PostgreSQL container logs: %w", errors.Join(err, remove()))
+	}
+	release := func() error {
+		err := remove()
+		_ = logs.Wait()
+		serverLog.Close()
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = release()
+			err = fmt.Errorf("%w\nOUT: %s\nERR: %s", err, stdout, stderr)
+		}
+	}()
+
+	out, err = exec.CommandContext(ctx, docker, "port", id, "5432/tcp").Output()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find port of PostgreSQL container: %w%s", err, exitStderr(err))
+	}
+	host, port, err := parseDockerPort(out)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := waitReady(ctx, host, port); err != nil {
+		return nil, fmt.Errorf("PostgreSQL did not become ready: %w", err)
+	}
+
+	pool, expired, err := openTestPool(ctx, host, port, config, true)
+	if err != nil {
+		return nil, err
+	}
+	majorVersion, err := serverMajorVersion(ctx, pool)
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	pg := &PG{
+		Pool: pool,
+
+		Host: host,
+		Port: port,
+		User: "test",
+		Name: "test",
+
+		MajorVersion: majorVersion,
+
+		initStdout: newRingBuffer(config.OutputLimit),
+		initStderr: newRingBuffer(config.OutputLimit),
+		stdout:     stdout,
+		stderr:     stderr,
+
+		config:  config,
+		release: release,
+		expired: expired,
+	}
+	if config.PoolStatsInterval > 0 {
+		pg.poolSampler = startPoolSampler(pool, config.PoolStatsInterval)
+	}
+	return pg, nil
+}
+
+// dockerRunArgs returns arguments of docker run starting the server. The
+// image creates the superuser test and the database test.
+func dockerRunArgs(image string, config Config) []string {
+	if image == "" && config.Version != "" && !strings.ContainsAny(config.Version, "<>=,") {
+		image = "postgres:" + config.Version
+	}
+	if image == "" {
+		image = defaultDockerImage
+	}
+
+	args := []string{
+		"run", "-d",
+		"-p", "127.0.0.1::5432",
+		"-e", "POSTGRES_USER=test",
+		"-e", "POSTGRES_HOST_AUTH_METHOD=trust",
+		"--tmpfs", "/var/lib/postgresql/data",
+		"--label", "pgxtest=1",
+	}
+	var keys []string
+	for k := range config.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		args = append(args, "--label", "pgxtest."+k+"="+config.Labels[k])
+	}
+
+	args = append(args, image, "postgres", "-F")
+	if config.TrackFunctions {
+		args = append(args, "-c", "track_functions=pl")
+	}
+	if libs := extensionLibraries(config); len(libs) > 0 {
+		args = append(args, "-c", "shared_preload_libraries="+strings.Join(libs, ","))
+	}
+	if config.ServerLogger != nil {
+		args = append(args, "-c", "log_line_prefix="+serverLogPrefix)
+	}
+	args = append(args, settingArgs(config.Settings)...)
+	return append(args, config.AdditionalArgs...)
+}
+
+// parseDockerPort parses the output of docker port, e.g. 127.0.0.1:49153
+func parseDockerPort(out []byte) (string, int, error) {
+	line, _, _ := bytes.Cut(bytes.TrimSpace(out), []byte("\n"))
+	host, portStr, err := net.SplitHostPort(string(line))
+	if err != nil {
+		return "", 0, fmt.Errorf("Failed to parse port of PostgreSQL container %q: %w", out, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return "", 0, fmt.Errorf("Failed to parse port of PostgreSQL container %q: %w", out, err)
+	}
+	return host, port, nil
+}
+
+// exitStderr returns the standard error of a failed command run by Output
+func exitStderr(err error) string {
+	if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+		return ": " + strings.TrimSpace(string(exitErr.Stderr))
+	}
+	return ""
+}
@@ -0,0 +1,64 @@
+package pgxtest
+
+import (
+	"context"
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+func TestDockerBackend(t *testing.T) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		t.Skip("docker is not installed")
+	}
+	ctx := context.Background()
+	t.Parallel()
+
+	pg := StartT(t, Config{Backend: DockerBackend{Image: "postgres:16-alpine"}})
+
+	var one int
+	if err := pg.Pool.QueryRow(ctx, "SELECT 1").Scan(&one); err != nil {
+		t.Fatalf("failed to query: %v", err)
+	}
+	if pg.Name != "test" || pg.User != "test" {
+		t.Errorf("expected test database and user, got %s and %s", pg.Name, pg.User)
+	}
+}
+
+func TestDockerRunArgs(t *testing.T) {
+	args := dockerRunArgs("", Config{
+		TrackFunctions: true,
+		AdditionalArgs: []string{"-c", "work_mem=64MB"},
+		Labels:         map[string]string{"test": "TestX", "pkg": "db"},
+	})
+	expected := []string{
+		"run", "-d",
+		"-p", "127.0.0.1::5432",
+		"-e", "POSTGRES_USER=test",
+		"-e", "POSTGRES_HOST_AUTH_METHOD=trust",
+		"--tmpfs", "/var/lib/postgresql/data",
+		"--label", "pgxtest=1",
+		"--label", "pgxtest.pkg=db",
+		"--label", "pgxtest.test=TestX",
+		"postgres:16", "postgres", "-F",
+		"-c", "track_functions=pl",
+		"-c", "work_mem=64MB",
+	}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("expected %v, got %v", expected, args)
+	}
+}
+
+func TestParseDockerPort(t *testing.T) {
+	host, port, err := parseDockerPort([]byte("127.0.0.1:49153\n"))
+	if err != nil {
+		t.Fatalf("failed to parse port: %v", err)
+	}
+	if host != "127.0.0.1" || port != 49153 {
+		t.Errorf("expected 127.0.0.1:49153, got %s:%d", host, port)
+	}
+
+	if _, _, err := parseDockerPort([]byte("garbage")); err == nil {
+		t.Errorf("expected error for invalid output")
+	}
+}
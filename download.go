@@ -0,0 +1,314 @@
+package pgxtest
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Repository with PostgreSQL binaries packaged by zonkyio/embedded-postgres
+const defaultDownloadURL = "https://repo1.maven.org/maven2/io/zonky/test/postgres"
+
+// Release downloaded by DownloadBackend unless Config.Version is set
+const defaultDownloadVersion = "16.4.0"
+
+// DownloadBackend runs official PostgreSQL binaries downloaded on first use,
+// as packaged by zonkyio/embedded-postgres, so that tests don't depend on a
+// system installation. Config.Version picks the release: a full version
+// (16.4.0) or a constraint matching the latest such release (16, >=15).
+//
+// Binaries are cached per version and platform, and verified against the
+// checksum published next to them before they are extracted. Extracting them
+// requires tar and xz. Windows is not supported.
+type DownloadBackend struct {
+	CacheDir string // Default is pgxtest in the user cache directory
+	URL      string // Maven repository path of the binaries, default Maven Central
+}
+
+func (b DownloadBackend) start(ctx context.Context, config Config) (*PG, error) {
+	binDir, err := b.binaries(ctx, config.Version)
+	if err != nil {
+		return nil, err
+	}
+	config.BinDir = binDir
+	return startLocal(ctx, config)
+}
+
+// binaries returns the bin directory of the release, downloading it if it is
+// not cached yet
+func (b DownloadBackend) binaries(ctx context.Context, version string) (string, error) {
+	platform, err := downloadPlatform(runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return "", err
+	}
+	cacheDir := b.CacheDir
+	if cacheDir == "" {
+		userCache, err := os.UserCacheDir()
+		if err != nil {
+			return "", err
+		}
+		cacheDir = filepath.Join(userCache, "pgxtest")
+	}
+	baseURL := b.URL
+	if baseURL == "" {
+		baseURL = defaultDownloadURL
+	}
+	artifact := baseURL + "/embedded-postgres-binaries-" + platform
+
+	if version == "" {
+		version = defaultDownloadVersion
+	}
+	if err := validVersionConstraint(version); err != nil {
+		return "", err
+	}
+	if !isExactVersion(version) {
+		if version, err = latestRelease(ctx, artifact, version); err != nil {
+			return "", err
+		}
+	}
+
+	dir := filepath.Join(cacheDir, "postgresql-"+version+"-"+platform)
+	binDir := filepath.Join(dir, "bin")
+	if _, err := os.Stat(filepath.Join(binDir, "initdb")); err == nil {
+		return binDir, nil
+	}
+
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return "", err
+	}
+	// Concurrent downloads extract into their own directories, the first one
+	// to rename its directory into place wins
+	tmp, err := os.MkdirTemp(cacheDir, "download")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+
+	jar := filepath.Join(tmp, "binaries.jar")
+	url := fmt.Sprintf("%s/%s/embedded-postgres-binaries-%s-%s.jar", artifact, version, platform, version)
+	if err := download(ctx, url, jar); err != nil {
+		return "", fmt.Errorf("Failed to download PostgreSQL %s: %w", version, err)
+	}
+	if err := verifyChecksum(ctx, url, jar); err != nil {
+		return "", fmt.Errorf("Failed to verify PostgreSQL %s: %w", version, err)
+	}
+	if err := extractBinaries(jar, filepath.Join(tmp, "postgresql")); err != nil {
+		return "", fmt.Errorf("Failed to extract PostgreSQL %s: %w", version, err)
+	}
+
+	if err := os.Rename(filepath.Join(tmp, "postgresql"), dir); err != nil {
+		if _, statErr := os.Stat(filepath.Join(binDir, "initdb")); statErr != nil {
+			return "", fmt.Errorf("Failed to cache PostgreSQL %s: %w", version, err)
+		}
+	}
+	return binDir, nil
+}
+
+// downloadPlatform returns the platform name used by embedded-postgres
+func downloadPlatform(goos, goarch string) (string, error) {
+	arch, ok := map[string]string{
+		"amd64":   "amd64",
+		"arm64":   "arm64v8",
+		"386":     "i386",
+		"ppc64le": "ppc64le",
+	}[goarch]
+	// The Windows binaries are packaged too, but pgxtest needs UNIX sockets
+	if !ok || (goos != "linux" && goos != "darwin") {
+		return "", fmt.Errorf("No PostgreSQL binaries to download for %s/%s", goos, goarch)
+	}
+	return goos + "-" + arch, nil
+}
+
+// latestRelease returns the latest release of the artifact matching the
+// version constraint, e.g. 16 or >=15
+func latestRelease(ctx context.Context, artifact, constraint string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, artifact+"/maven-metadata.xml", nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("Failed to list PostgreSQL releases: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Failed to list PostgreSQL releases: %s", resp.Status)
+	}
+
+	var metadata struct {
+		Versions []string `xml:"versioning>versions>version"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+		return "", fmt.Errorf("Failed to parse PostgreSQL releases: %w", err)
+	}
+
+	var latest string
+	for _, v := range metadata.Versions {
+		if matchVersion(v, constraint) && (latest == "" || compareVersions(v, latest) > 0) {
+			latest = v
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("No PostgreSQL release matches version %s", constraint)
+	}
+	return latest, nil
+}
+
+// compareVersions compares dot-separated numeric versions
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func download(ctx context.Context, url, path string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", url, resp.Status)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Checksums Maven repositories publish next to artifacts, strongest first
+var downloadChecksums = []struct {
+	ext  string
+	hash func() hash.Hash
+}{
+	{".sha256", sha256.New},
+	{".sha1", sha1.New},
+}
+
+// verifyChecksum checks the file downloaded from url against the strongest
+// checksum published for it
+func verifyChecksum(ctx context.Context, url, path string) error {
+	for _, c := range downloadChecksums {
+		expected, err := fetchChecksum(ctx, url+c.ext)
+		if err != nil {
+			return err
+		}
+		if expected == "" {
+			continue
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		h := c.hash()
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		if actual := hex.EncodeToString(h.Sum(nil)); !strings.EqualFold(actual, expected) {
+			return fmt.Errorf("%s checksum mismatch: expected %s, got %s", strings.TrimPrefix(c.ext, "."), expected, actual)
+		}
+		return nil
+	}
+	return fmt.Errorf("No checksum published for %s", url)
+}
+
+// fetchChecksum returns the hex checksum at url, or "" if there is none
+func fetchChecksum(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return "", err
+	}
+	// Some checksum files are followed by the file name
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("%s: empty checksum", url)
+	}
+	return fields[0], nil
+}
+
+// extractBinaries extracts the .txz archive packaged in the jar into dir
+func extractBinaries(jar, dir string) error {
+	zr, err := zip.OpenReader(jar)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		if !strings.HasSuffix(f.Name, ".txz") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		// The standard library can't decompress xz
+		tar := exec.Command("tar", "-xJf", "-", "-C", dir)
+		tar.Stdin = rc
+		if out, err := tar.CombinedOutput(); err != nil {
+			return fmt.Errorf("%w: %s", err, out)
+		}
+		return nil
+	}
+	return fmt.Errorf("No .txz archive in %s", filepath.Base(jar))
+}
@@ -0,0 +1,154 @@
+package pgxtest
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDownloadBackend(t *testing.T) {
+	if _, err := exec.LookPath("xz"); err != nil {
+		t.Skip("xz is not installed")
+	}
+	platform, err := downloadPlatform(runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		t.Skip(err)
+	}
+
+	// A release with a fake initdb, packaged like embedded-postgres does
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "bin"), 0755); err != nil {
+		t.Fatalf("failed to create bin: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "bin", "initdb"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("failed to write initdb: %v", err)
+	}
+	txz, err := exec.Command("tar", "-cJf", "-", "-C", src, "bin").Output()
+	if err != nil {
+		t.Fatalf("failed to create archive: %v", err)
+	}
+	var jar bytes.Buffer
+	zw := zip.NewWriter(&jar)
+	w, err := zw.Create("postgres-" + platform + ".txz")
+	if err != nil {
+		t.Fatalf("failed to create jar: %v", err)
+	}
+	if _, err := w.Write(txz); err != nil {
+		t.Fatalf("failed to write jar: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to write jar: %v", err)
+	}
+
+	sum := sha256.Sum256(jar.Bytes())
+	checksum := hex.EncodeToString(sum[:])
+
+	var downloads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/maven-metadata.xml"):
+			w.Write([]byte(`<metadata><versioning><versions>
+				<version>15.8.0</version><version>16.2.0</version><version>16.10.0</version><version>17.0.0</version>
+			</versions></versioning></metadata>`))
+		case strings.HasSuffix(r.URL.Path, "/16.10.0/embedded-postgres-binaries-"+platform+"-16.10.0.jar"):
+			downloads.Add(1)
+			w.Write(jar.Bytes())
+		case strings.HasSuffix(r.URL.Path, "-16.10.0.jar.sha256"):
+			w.Write([]byte(checksum))
+		case strings.HasSuffix(r.URL.Path, "/17.0.0/embedded-postgres-binaries-"+platform+"-17.0.0.jar"):
+			w.Write(jar.Bytes())
+		case strings.HasSuffix(r.URL.Path, "-17.0.0.jar.sha256"):
+			w.Write([]byte(strings.Repeat("0", 64)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	b := DownloadBackend{CacheDir: t.TempDir(), URL: server.URL}
+	for i := 0; i < 2; i++ {
+		binDir, err := b.binaries(context.Background(), "16")
+		if err != nil {
+			t.Fatalf("failed to get binaries: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(binDir, "initdb")); err != nil {
+			t.Errorf("expected initdb in %s: %v", binDir, err)
+		}
+	}
+	if n := downloads.Load(); n != 1 {
+		t.Errorf("expected 1 download, got %d", n)
+	}
+
+	if _, err := b.binaries(context.Background(), "18"); err == nil {
+		t.Errorf("expected error for missing release")
+	}
+	if _, err := b.binaries(context.Background(), "17.0.0"); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("expected a checksum mismatch, got %v", err)
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "binaries.jar")
+	if err := os.WriteFile(path, []byte("binaries"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha1.Sum([]byte("binaries"))
+
+	// Without .sha256, .sha1 is used
+	files := map[string]string{"/a.jar.sha1": hex.EncodeToString(sum[:]) + "  a.jar\n", "/b.jar.sha1": "0000"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if data, ok := files[r.URL.Path]; ok {
+			w.Write([]byte(data))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	if err := verifyChecksum(ctx, server.URL+"/a.jar", path); err != nil {
+		t.Errorf("failed to verify a matching checksum: %v", err)
+	}
+	if err := verifyChecksum(ctx, server.URL+"/b.jar", path); err == nil {
+		t.Errorf("expected a checksum mismatch")
+	}
+	if err := verifyChecksum(ctx, server.URL+"/c.jar", path); err == nil {
+		t.Errorf("expected an error without a checksum")
+	}
+}
+
+func TestDownloadPlatform(t *testing.T) {
+	if p, err := downloadPlatform("linux", "arm64"); err != nil || p != "linux-arm64v8" {
+		t.Errorf("expected linux-arm64v8, got %q (%v)", p, err)
+	}
+	if _, err := downloadPlatform("windows", "amd64"); err == nil {
+		t.Errorf("expected windows to be unsupported")
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	for _, c := range []struct {
+		a, b     string
+		expected int
+	}{
+		{"16.10.0", "16.2.0", 1},
+		{"15.8.0", "16.2.0", -1},
+		{"16.4", "16.4.0", 0},
+	} {
+		if got := compareVersions(c.a, c.b); got != c.expected {
+			t.Errorf("compareVersions(%s, %s): expected %d, got %d", c.a, c.b, c.expected, got)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package pgxtest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// DumpFormat is an output format of pg_dump
+type DumpFormat string
+
+const (
+	DumpPlain  DumpFormat = "plain"  // SQL script
+	DumpCustom DumpFormat = "custom" // Compressed archive for pg_restore
+	DumpTar    DumpFormat = "tar"    // Tar archive for pg_restore
+)
+
+// DumpOptions configures Dump
+type DumpOptions struct {
+	Format        DumpFormat // Default DumpPlain
+	SchemaOnly    bool
+	DataOnly      bool
+	Tables        []string // Dump only these tables (patterns of pg_dump -t)
+	ExcludeTables []string // Skip these tables (patterns of pg_dump -T)
+	NoOwner       bool     // Skip ownership and privileges, for restoring as another user
+	Args          []string // Additional arguments of pg_dump
+}
+
+// args returns the arguments of pg_dump for the options
+func (o DumpOptions) args() []string {
+	format := o.Format
+	if format == "" {
+		format = DumpPlain
+	}
+	args := []string{"--format=" + string(format)}
+	if o.SchemaOnly {
+		args = append(args, "--schema-only")
+	}
+	if o.DataOnly {
+		args = append(args, "--data-only")
+	}
+	for _, t := range o.Tables {
+		args = append(args, "--table="+t)
+	}
+	for _, t := range o.ExcludeTables {
+		args = append(args, "--exclude-table="+t)
+	}
+	if o.NoOwner {
+		args = append(args, "--no-owner", "--no-privileges")
+	}
+	return append(args, o.Args...)
+}
+
+// Dump writes a dump of the test database made by pg_dump to w, e.g. for
+// comparing the schema to a golden file or seeding other instances with
+// RestoreDump. pg_dump is taken from the binaries of the server, or found
+// like those of LocalBackend.
+func (p *PG) Dump(ctx context.Context, w io.Writer, opts DumpOptions) error {
+	pgDump, err := p.clientProgram("pg_dump")
+	if err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, pgDump, opts.args()...)
+	cmd.Env = append(os.Environ(), p.clientEnv()...)
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("Failed to dump test DB: %w\n%s", err, stderr.Bytes())
+	}
+	return nil
+}
+
+// RestoreDump loads a dump made by Dump or pg_dump into the test database,
+// stopping at the first error. Plain dumps are run by psql, so unlike
+// Config.InitScripts they may use COPY FROM stdin and meta-commands; custom
+// and tar archives are restored by pg_restore.
+func (p *PG) RestoreDump(ctx context.Context, r io.Reader) error {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(512)
+
+	var cmd *exec.Cmd
+	if archiveDump(head) {
+		pgRestore, err := p.clientProgram("pg_restore")
+		if err != nil {
+			return err
+		}
+		cmd = exec.CommandContext(ctx, pgRestore, "--exit-on-error", "--dbname="+p.Name)
+		cmd.Env = append(os.Environ(), p.clientEnv()...)
+	} else {
+		var err error
+		if cmd, err = p.psqlCommand(ctx, "--file=-"); err != nil {
+			return err
+		}
+	}
+	var output bytes.Buffer
+	cmd.Stdin = br
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("Failed to restore dump: %w\n%s", err, output.Bytes())
+	}
+	return nil
+}
+
+// archiveDump reports whether the dump starting with head is an archive of
+// pg_restore (custom or tar format) rather than an SQL script
+func archiveDump(head []byte) bool {
+	if bytes.HasPrefix(head, []byte("PGDMP")) {
+		return true
+	}
+	// Magic of POSIX tar headers
+	return len(head) >= 262 && bytes.Equal(head[257:262], []byte("ustar"))
+}
+
+// clientProgram returns the path of a client program next to the server
+// binaries, or among the binaries LocalBackend would use
+func (p *PG) clientProgram(program string) (string, error) {
+	binPath := p.binPath
+	if binPath == "" {
+		var err error
+		if binPath, err = findBinaries(p.config); err != nil {
+			return "", err
+		}
+	}
+	path := filepath.Join(binPath, program)
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("%s is not installed: %w", program, err)
+	}
+	return path, nil
+}
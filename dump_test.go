@@ -0,0 +1,95 @@
+package pgxtest
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestDumpRestore(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	source := StartT(t, Config{
+		InitScripts: []string{"CREATE TABLE t (val int); INSERT INTO t VALUES (1), (2)"},
+	})
+
+	for _, format := range []DumpFormat{DumpPlain, DumpCustom, DumpTar} {
+		format := format
+		t.Run(string(format), func(t *testing.T) {
+			t.Parallel()
+
+			var dump bytes.Buffer
+			if err := source.Dump(ctx, &dump, DumpOptions{Format: format, NoOwner: true}); err != nil {
+				t.Fatalf("failed to dump: %v", err)
+			}
+
+			target := StartT(t, Config{})
+			if err := target.RestoreDump(ctx, &dump); err != nil {
+				t.Fatalf("failed to restore: %v", err)
+			}
+			var n int
+			if err := target.Pool.QueryRow(ctx, "SELECT count(*) FROM t").Scan(&n); err != nil {
+				t.Fatalf("failed to count rows: %v", err)
+			}
+			if n != 2 {
+				t.Errorf("expected 2 restored rows, got %d", n)
+			}
+		})
+	}
+}
+
+func TestRestoreDumpStopsOnError(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg := StartT(t, Config{})
+	err := pg.RestoreDump(ctx, bytes.NewBufferString("CREATE TABLE a (val int);\nSELECT nonsense;\nCREATE TABLE b (val int);\n"))
+	if err == nil {
+		t.Fatalf("expected an error for a broken dump")
+	}
+	var exists bool
+	if err := pg.Pool.QueryRow(ctx, "SELECT to_regclass('b') IS NOT NULL").Scan(&exists); err != nil {
+		t.Fatalf("failed to query: %v", err)
+	}
+	if exists {
+		t.Errorf("expected restore to stop at the first error")
+	}
+}
+
+func TestDumpOptionsArgs(t *testing.T) {
+	args := DumpOptions{
+		SchemaOnly:    true,
+		Tables:        []string{"public.*"},
+		ExcludeTables: []string{"public.log"},
+		NoOwner:       true,
+		Args:          []string{"--no-comments"},
+	}.args()
+	expected := []string{
+		"--format=plain", "--schema-only",
+		"--table=public.*", "--exclude-table=public.log",
+		"--no-owner", "--no-privileges", "--no-comments",
+	}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("expected %v, got %v", expected, args)
+	}
+}
+
+func TestArchiveDump(t *testing.T) {
+	tarHead := make([]byte, 512)
+	copy(tarHead[257:], "ustar")
+	for _, tc := range []struct {
+		head    []byte
+		archive bool
+	}{
+		{[]byte("PGDMP\x01\x0f"), true},
+		{tarHead, true},
+		{[]byte("--\n-- PostgreSQL database dump\n"), false},
+		{nil, false},
+	} {
+		if got := archiveDump(tc.head); got != tc.archive {
+			t.Errorf("archiveDump(%q) = %v, expected %v", tc.head[:min(len(tc.head), 16)], got, tc.archive)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package pgxtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// EnvironmentSpec declares a topology of instances connected by logical
+// replication, to be defined once (e.g. in a shared test package) and started
+// as a unit by StartEnvironment
+type EnvironmentSpec struct {
+	Instances map[string]Config // Instances by name
+	Links     []Link
+}
+
+// Link replicates tables from one instance of an environment to another with
+// a publication and a subscription. The tables must exist on both sides once
+// the instances are prepared, e.g. created by the same Config.Migrate.
+//
+// wal_level is set to logical on the publishing instance. The subscriber
+// connects over the UNIX socket, so both need to run on LocalBackend.
+type Link struct {
+	From   string   // Publishing instance
+	To     string   // Subscribing instance
+	Tables []string // Tables to replicate, all tables if empty
+	Name   string   // Name of the publication and the subscription, default FROM_to_TO
+}
+
+// Environment is a set of running instances started from an EnvironmentSpec
+type Environment struct {
+	Instances map[string]*PG
+
+	order []string // Instances in the order of starting
+}
+
+// StartEnvironment starts the instances of the spec, in the order of their
+// names, and creates the links between them. Instances started before a
+// failure are stopped.
+func StartEnvironment(ctx context.Context, spec EnvironmentSpec) (_ *Environment, err error) {
+	configs := maps.Clone(spec.Instances)
+	for i, l := range spec.Links {
+		if _, ok := configs[l.From]; !ok {
+			return nil, fmt.Errorf("Link %d: instance %s not found", i, l.From)
+		}
+		if _, ok := configs[l.To]; !ok {
+			return nil, fmt.Errorf("Link %d: instance %s not found", i, l.To)
+		}
+		from := configs[l.From]
+		from.Settings = maps.Clone(from.Settings)
+		if from.Settings == nil {
+			from.Settings = map[string]string{}
+		}
+		from.Settings["wal_level"] = "logical"
+		configs[l.From] = from
+	}
+
+	e := &Environment{Instances: map[string]*PG{}}
+	defer func() {
+		if err != nil {
+			err = errors.Join(err, e.Stop())
+		}
+	}()
+
+	for name := range configs {
+		e.order = append(e.order, name)
+	}
+	sort.Strings(e.order)
+	for _, name := range e.order {
+		pg, err := Start(ctx, configs[name])
+		if err != nil {
+			return nil, fmt.Errorf("Failed to start instance %s: %w", name, err)
+		}
+		e.Instances[name] = pg
+	}
+
+	for _, l := range spec.Links {
+		if err := e.link(ctx, l); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// link creates the publication and the subscription of the link
+func (e *Environment) link(ctx context.Context, l Link) error {
+	name := l.Name
+	if name == "" {
+		name = l.From + "_to_" + l.To
+	}
+	ident := pgx.Identifier{name}.Sanitize()
+
+	tables := "ALL TABLES"
+	if len(l.Tables) > 0 {
+		var quoted []string
+		for _, t := range l.Tables {
+			quoted = append(quoted, pgx.Identifier(strings.Split(t, ".")).Sanitize())
+		}
+		tables = "TABLE " + strings.Join(quoted, ", ")
+	}
+
+	from, to := e.Instances[l.From], e.Instances[l.To]
+	if _, err := from.Pool.Exec(ctx, "CREATE PUBLICATION "+ident+" FOR "+tables); err != nil {
+		return fmt.Errorf("Failed to create publication %s: %w", name, err)
+	}
+	sql := fmt.Sprintf("CREATE SUBSCRIPTION %s CONNECTION %s PUBLICATION %s", ident, quoteLiteral(from.DSN()), ident)
+	if _, err := to.Pool.Exec(ctx, sql); err != nil {
+		return fmt.Errorf("Failed to create subscription %s: %w", name, err)
+	}
+	return nil
+}
+
+// Stop stops the instances in the reverse order of starting
+func (e *Environment) Stop() error {
+	var errs []error
+	for i := len(e.order) - 1; i >= 0; i-- {
+		if pg, ok := e.Instances[e.order[i]]; ok {
+			if err := pg.Stop(); err != nil {
+				errs = append(errs, fmt.Errorf("Failed to stop instance %s: %w", e.order[i], err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,50 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestEnvironment(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	migrate := func(ctx context.Context, pool *pgxpool.Pool) error {
+		_, err := pool.Exec(ctx, "CREATE TABLE orders (id int PRIMARY KEY)")
+		return err
+	}
+	env, err := StartEnvironment(ctx, EnvironmentSpec{
+		Instances: map[string]Config{
+			"primary":   {Migrate: migrate},
+			"analytics": {Migrate: migrate},
+		},
+		Links: []Link{{From: "primary", To: "analytics", Tables: []string{"orders"}}},
+	})
+	if err != nil {
+		t.Fatalf("failed to start environment: %v", err)
+	}
+	defer func() {
+		if err = env.Stop(); err != nil {
+			t.Errorf("failed to stop environment: %v", err)
+		}
+	}()
+
+	if _, err := env.Instances["primary"].Pool.Exec(ctx, "INSERT INTO orders VALUES (1)"); err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+	env.Instances["analytics"].Eventually(ctx, t, 10*time.Second, "SELECT count(*) FROM orders", func(row []any) bool {
+		return row[0] == int64(1)
+	})
+}
+
+func TestEnvironmentUnknownInstance(t *testing.T) {
+	_, err := StartEnvironment(context.Background(), EnvironmentSpec{
+		Links: []Link{{From: "a", To: "b"}},
+	})
+	if err == nil {
+		t.Errorf("expected an error for a link to an unknown instance")
+	}
+}
@@ -0,0 +1,102 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Interval between attempts of Eventually
+const eventuallyInterval = 20 * time.Millisecond
+
+// Eventually polls query until predicate returns true for its first row, or
+// fails the test once timeout expires.
+//
+// predicate receives the values of the first row returned by the query, or nil
+// if the query returned no rows. On failure the row of the last completed
+// attempt is reported together with the column names, so there is no need to
+// log it manually.
+//
+// For SELECT queries the failure message also includes EXPLAIN ANALYZE output
+// and row counts of the tables involved.
+//
+// Useful for asynchronous code: wait until a worker marks a job as done
+// instead of sleeping for a fixed amount of time.
+func (p *PG) Eventually(ctx context.Context, t testing.TB, timeout time.Duration, query string, predicate func(row []any) bool, args ...any) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var (
+		columns  []string
+		last     []any
+		lastErr  error
+		observed bool
+	)
+	for {
+		c, row, err := p.firstRow(ctx, query, args...)
+		if err == nil && predicate(row) {
+			return
+		}
+		// An attempt interrupted by the timeout has observed nothing, report
+		// the last one that completed instead
+		if ctx.Err() == nil || !observed {
+			columns, last, lastErr = c, row, err
+			observed = true
+		}
+
+		select {
+		case <-ctx.Done():
+			t.Fatalf("condition not satisfied within %s\nquery: %s\n%s%s",
+				timeout, query, describeLastRow(columns, last, lastErr),
+				p.explainFailure(ctx, query, args...))
+			return
+		case <-time.After(eventuallyInterval):
+		}
+	}
+}
+
+func (p *PG) firstRow(ctx context.Context, query string, args ...any) ([]string, []any, error) {
+	rows, err := p.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for _, fd := range rows.FieldDescriptions() {
+		columns = append(columns, fd.Name)
+	}
+
+	if !rows.Next() {
+		return columns, nil, rows.Err()
+	}
+	values, err := rows.Values()
+	if err != nil {
+		return columns, nil, err
+	}
+	return columns, values, nil
+}
+
+func describeLastRow(columns []string, row []any, err error) string {
+	if err != nil {
+		return fmt.Sprintf("last error: %v", err)
+	}
+	if row == nil {
+		return "last result: no rows"
+	}
+
+	var b strings.Builder
+	b.WriteString("last row:")
+	for i, v := range row {
+		name := fmt.Sprintf("column%d", i+1)
+		if i < len(columns) {
+			name = columns[i]
+		}
+		fmt.Fprintf(&b, "\n  %s = %#v", name, v)
+	}
+	return b.String()
+}
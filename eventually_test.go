@@ -0,0 +1,88 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventually(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	if _, err := pg.Pool.Exec(ctx, "CREATE TABLE jobs (id int, status text)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	if _, err := pg.Pool.Exec(ctx, "INSERT INTO jobs VALUES (1, 'pending')"); err != nil {
+		t.Fatalf("failed to insert job: %v", err)
+	}
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_, _ = pg.Pool.Exec(ctx, "UPDATE jobs SET status = 'done' WHERE id = 1")
+	}()
+
+	pg.Eventually(ctx, t, 5*time.Second, "SELECT status FROM jobs WHERE id = $1", func(row []any) bool {
+		return row != nil && row[0] == "done"
+	}, 1)
+}
+
+type fatalRecorder struct {
+	testing.TB
+	message string
+}
+
+func (r *fatalRecorder) Fatalf(format string, args ...any) {
+	r.message = fmt.Sprintf(format, args...)
+}
+
+func TestEventuallyReportsCompletedAttempt(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	if _, err := pg.Pool.Exec(ctx, "CREATE SEQUENCE attempts"); err != nil {
+		t.Fatalf("failed to create sequence: %v", err)
+	}
+
+	// Attempts after the first one are still running when the timeout expires
+	r := &fatalRecorder{TB: t}
+	pg.Eventually(ctx, r, 300*time.Millisecond,
+		"SELECT 'pending' AS status FROM pg_sleep(CASE WHEN nextval('attempts') > 1 THEN 2 ELSE 0 END)",
+		func(row []any) bool { return false })
+	if !strings.Contains(r.message, `status = "pending"`) {
+		t.Errorf("expected the completed attempt to be reported, got %s", r.message)
+	}
+}
+
+func TestDescribeLastRow(t *testing.T) {
+	got := describeLastRow([]string{"id", "status"}, []any{int32(1), "pending"}, nil)
+	if !strings.Contains(got, `status = "pending"`) {
+		t.Errorf("expected status in description, got %q", got)
+	}
+
+	if got := describeLastRow(nil, nil, nil); got != "last result: no rows" {
+		t.Errorf("unexpected description for empty result: %q", got)
+	}
+}
@@ -0,0 +1,140 @@
+package pgxtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Time allowed for collecting EXPLAIN output for a failure message
+const explainTimeout = 5 * time.Second
+
+// isSelect reports whether the query is a plain SELECT, which EXPLAIN ANALYZE
+// can execute in a read-only transaction.
+func isSelect(query string) bool {
+	fields := strings.Fields(query)
+	return len(fields) > 0 && strings.EqualFold(fields[0], "SELECT")
+}
+
+// explainFailure returns EXPLAIN ANALYZE output of a SELECT query together with
+// row counts of the tables it reads, for attaching to assertion failures. The
+// query runs in a read-only transaction that is rolled back, so that the
+// diagnostics don't change the database; if it can't run there, e.g. because
+// it calls nextval, the plan is explained without executing the query.
+//
+// Returns an empty string for other statements. Problems collecting the
+// information are reported inline instead of masking the original failure.
+func (p *PG) explainFailure(ctx context.Context, query string, args ...any) string {
+	if !isSelect(query) {
+		return ""
+	}
+
+	// The assertion has likely failed because its context expired
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), explainTimeout)
+	defer cancel()
+
+	var b strings.Builder
+	lines, err := p.explainAnalyze(ctx, query, args...)
+	if err == nil {
+		b.WriteString("\nEXPLAIN ANALYZE:\n")
+	} else {
+		fmt.Fprintf(&b, "\nEXPLAIN (not executed: %v):\n", err)
+		var rows pgx.Rows
+		rows, err = p.Pool.Query(ctx, "EXPLAIN "+query, args...)
+		if err == nil {
+			lines, err = pgx.CollectRows(rows, pgx.RowTo[string])
+		}
+	}
+	for _, line := range lines {
+		b.WriteString("  " + line + "\n")
+	}
+	if err != nil {
+		fmt.Fprintf(&b, "  failed to explain: %v\n", err)
+		return b.String()
+	}
+
+	var plan []byte
+	if err := p.Pool.QueryRow(ctx, "EXPLAIN (VERBOSE, FORMAT JSON) "+query, args...).Scan(&plan); err != nil {
+		fmt.Fprintf(&b, "failed to find tables: %v\n", err)
+		return b.String()
+	}
+	tables, err := planRelations(plan)
+	if err != nil {
+		fmt.Fprintf(&b, "failed to find tables: %v\n", err)
+		return b.String()
+	}
+	if len(tables) == 0 {
+		return b.String()
+	}
+
+	b.WriteString("Table rows:\n")
+	for _, table := range tables {
+		var count int64
+		err := p.Pool.QueryRow(ctx, "SELECT count(*) FROM "+table).Scan(&count)
+		if err != nil {
+			fmt.Fprintf(&b, "  %s: %v\n", table, err)
+			continue
+		}
+		fmt.Fprintf(&b, "  %s: %d\n", table, count)
+	}
+	return b.String()
+}
+
+// explainAnalyze runs EXPLAIN ANALYZE of the query in a read-only transaction
+// and rolls it back
+func (p *PG) explainAnalyze(ctx context.Context, query string, args ...any) ([]string, error) {
+	tx, err := p.Pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, "EXPLAIN ANALYZE "+query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+// planRelations returns quoted names of the tables referenced in a JSON plan
+func planRelations(plan []byte) ([]string, error) {
+	var root any
+	if err := json.Unmarshal(plan, &root); err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case []any:
+			for _, e := range v {
+				walk(e)
+			}
+		case map[string]any:
+			if rel, ok := v["Relation Name"].(string); ok {
+				name := pgx.Identifier{rel}
+				if schema, ok := v["Schema"].(string); ok {
+					name = pgx.Identifier{schema, rel}
+				}
+				seen[name.Sanitize()] = true
+			}
+			for _, e := range v {
+				walk(e)
+			}
+		}
+	}
+	walk(root)
+
+	var tables []string
+	for table := range seen {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	return tables, nil
+}
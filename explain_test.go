@@ -0,0 +1,72 @@
+package pgxtest
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPlanRelations(t *testing.T) {
+	plan := []byte(`[{"Plan": {"Node Type": "Hash Join", "Plans": [
+		{"Node Type": "Seq Scan", "Relation Name": "jobs", "Schema": "public"},
+		{"Node Type": "Seq Scan", "Relation Name": "Workers", "Schema": "app"},
+		{"Node Type": "Seq Scan", "Relation Name": "jobs", "Schema": "public"}
+	]}}]`)
+
+	tables, err := planRelations(plan)
+	if err != nil {
+		t.Fatalf("failed to parse plan: %v", err)
+	}
+	expected := []string{`"app"."Workers"`, `"public"."jobs"`}
+	if !reflect.DeepEqual(tables, expected) {
+		t.Errorf("expected %v, got %v", expected, tables)
+	}
+}
+
+func TestIsSelect(t *testing.T) {
+	if !isSelect("\n  select status FROM jobs") {
+		t.Errorf("SELECT not recognized")
+	}
+	if isSelect("UPDATE jobs SET status = 'done'") {
+		t.Errorf("UPDATE recognized as SELECT")
+	}
+}
+
+func TestExplainFailureHasNoSideEffects(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	_, err = pg.Pool.Exec(ctx, `CREATE SEQUENCE ids; CREATE TABLE log (id int);
+		CREATE FUNCTION logged() RETURNS int LANGUAGE sql AS 'INSERT INTO log VALUES (1) RETURNING id'`)
+	if err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+
+	if out := pg.explainFailure(ctx, "SELECT count(*) FROM log"); !strings.Contains(out, "EXPLAIN ANALYZE:") {
+		t.Errorf("expected EXPLAIN ANALYZE output, got %s", out)
+	}
+	for _, query := range []string{"SELECT nextval('ids')", "SELECT logged()"} {
+		if out := pg.explainFailure(ctx, query); !strings.Contains(out, "not executed") {
+			t.Errorf("expected %s not to be executed, got %s", query, out)
+		}
+	}
+
+	var id, logged int64
+	if err := pg.Pool.QueryRow(ctx, "SELECT nextval('ids'), (SELECT count(*) FROM log)").Scan(&id, &logged); err != nil {
+		t.Fatalf("failed to query: %v", err)
+	}
+	if id != 1 || logged != 0 {
+		t.Errorf("expected no side effects, got sequence at %d and %d logged rows", id, logged)
+	}
+}
@@ -0,0 +1,280 @@
+package pgxtest
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Last entry of a data directory archive, listing SHA-256 checksums of the
+// files in the format of sha256sum
+const snapshotManifest = "MANIFEST.sha256"
+
+// ExportSnapshot writes the data directory of the server to a gzipped tar
+// archive at path, to be started by ImportSnapshot, e.g. a seeded state kept
+// as a build artifact. The archive includes checksums of the files, verified
+// on import.
+//
+// The server is stopped while the archive is written, so that the data
+// directory is consistent, and started again. Connections of the Pool are
+// broken, and it recovers like any other pool.
+func (p *PG) ExportSnapshot(ctx context.Context, path string) error {
+	if p.cmd == nil {
+		return fmt.Errorf("Exporting snapshots needs a server started by LocalBackend")
+	}
+	if p.tempTablespaceDir != "" {
+		return fmt.Errorf("Exporting snapshots is not supported with Config.TempTablespaceDir")
+	}
+	if err := p.pauseExpiry(); err != nil {
+		return err
+	}
+	defer p.resumeExpiry()
+
+	if err := p.stopServer(); err != nil {
+		return err
+	}
+	err := writeDataArchive(p.dataDir, path)
+	if restartErr := p.restartServer(ctx); restartErr != nil {
+		return errors.Join(err, restartErr)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to export snapshot: %w", err)
+	}
+	return nil
+}
+
+// ImportSnapshot starts a new instance on a data directory exported by
+// ExportSnapshot, after verifying its checksums. The binaries must be of the
+// same major version as those of the exported server.
+//
+// The database is used as is, so the options preparing it (Databases, Roles,
+// Migrate and InitScripts) are ignored, as is Backend.
+func ImportSnapshot(ctx context.Context, path string, config Config) (*PG, error) {
+	config.snapshotFile = path
+	config.Backend = LocalBackend{}
+	config.Databases = nil
+	config.Roles = nil
+	config.Migrate = nil
+	config.InitScripts = nil
+	return Start(ctx, config)
+}
+
+// writeDataArchive writes the files of dataDir to a gzipped tar archive,
+// following the pg_wal symlink of a separate WAL directory
+func writeDataArchive(dataDir string, archive string) (err error) {
+	tmp := archive + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(tmp)
+		}
+	}()
+
+	bw := bufio.NewWriter(f)
+	gw := gzip.NewWriter(bw)
+	tw := tar.NewWriter(gw)
+
+	var manifest []string
+	err = walkData(dataDir, func(name string, file string, info fs.FileInfo) error {
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = name
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		in, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		h := sha256.New()
+		if _, err := io.Copy(io.MultiWriter(tw, h), in); err != nil {
+			return err
+		}
+		manifest = append(manifest, hex.EncodeToString(h.Sum(nil))+"  "+name)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	data := []byte(strings.Join(manifest, "\n") + "\n")
+	if err := tw.WriteHeader(&tar.Header{Name: snapshotManifest, Mode: 0600, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return err
+	}
+
+	for _, c := range []io.Closer{tw, gw} {
+		if err := c.Close(); err != nil {
+			return err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, archive)
+}
+
+// walkData calls fn for the directories and files of dataDir in lexical
+// order, with their slash-separated names relative to dataDir. Symlinks to
+// directories, such as pg_wal, are followed.
+func walkData(dataDir string, fn func(name string, file string, info fs.FileInfo) error) error {
+	var walk func(name string, file string) error
+	walk = func(name string, file string) error {
+		entries, err := os.ReadDir(file)
+		if err != nil {
+			return err
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+		for _, e := range entries {
+			entryName, entryFile := path.Join(name, e.Name()), filepath.Join(file, e.Name())
+			info, err := os.Stat(entryFile)
+			if err != nil {
+				return err
+			}
+			if err := fn(entryName, entryFile, info); err != nil {
+				return err
+			}
+			if info.IsDir() {
+				if err := walk(entryName, entryFile); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return walk("", dataDir)
+}
+
+// extractDataArchive extracts an archive written by writeDataArchive to the
+// empty dataDir, verifying the checksums of the files
+func extractDataArchive(archive string, dataDir string) error {
+	// PostgreSQL insists on these permissions
+	if err := os.Chmod(dataDir, 0700); err != nil {
+		return err
+	}
+
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(bufio.NewReader(f))
+	if err != nil {
+		return fmt.Errorf("Failed to read snapshot %s: %w", archive, err)
+	}
+	tr := tar.NewReader(gr)
+
+	sums := map[string]string{}
+	var manifest []byte
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to read snapshot %s: %w", archive, err)
+		}
+
+		name := strings.TrimSuffix(hdr.Name, "/")
+		if name == snapshotManifest {
+			if manifest, err = io.ReadAll(tr); err != nil {
+				return err
+			}
+			continue
+		}
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("Invalid file %q in snapshot %s", hdr.Name, archive)
+		}
+
+		file := filepath.Join(dataDir, filepath.FromSlash(name))
+		mode := fs.FileMode(hdr.Mode).Perm()
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.Mkdir(file, mode); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			out, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+			if err != nil {
+				return err
+			}
+			h := sha256.New()
+			_, err = io.Copy(io.MultiWriter(out, h), tr)
+			if closeErr := out.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return err
+			}
+			sums[name] = hex.EncodeToString(h.Sum(nil))
+		default:
+			return fmt.Errorf("Unexpected file %q in snapshot %s", hdr.Name, archive)
+		}
+	}
+
+	if manifest == nil {
+		return fmt.Errorf("Snapshot %s has no %s", archive, snapshotManifest)
+	}
+	return verifyManifest(manifest, sums)
+}
+
+// verifyManifest checks that the files have the checksums listed in the
+// manifest, and that there are no files missing from either
+func verifyManifest(manifest []byte, sums map[string]string) error {
+	var problems []string
+	listed := map[string]bool{}
+	for _, line := range strings.Split(strings.TrimSpace(string(manifest)), "\n") {
+		sum, name, ok := strings.Cut(line, "  ")
+		if !ok {
+			return fmt.Errorf("Invalid line in %s: %q", snapshotManifest, line)
+		}
+		listed[name] = true
+		switch got, ok := sums[name]; {
+		case !ok:
+			problems = append(problems, name+": missing")
+		case got != sum:
+			problems = append(problems, name+": checksum mismatch")
+		}
+	}
+	for name := range sums {
+		if !listed[name] {
+			problems = append(problems, name+": not in "+snapshotManifest)
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("Snapshot is corrupted:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package pgxtest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportImportSnapshot(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	if _, err := pg.Pool.Exec(ctx, "CREATE TABLE seeded AS SELECT generate_series(1, 1000) AS n"); err != nil {
+		t.Fatalf("failed to seed: %v", err)
+	}
+	archive := filepath.Join(t.TempDir(), "seeded.tar.gz")
+	if err := pg.ExportSnapshot(ctx, archive); err != nil {
+		t.Fatalf("failed to export snapshot: %v", err)
+	}
+	if err := pg.Pool.Ping(ctx); err != nil {
+		t.Errorf("failed to ping after export: %v", err)
+	}
+
+	imported, err := ImportSnapshot(ctx, archive, Config{})
+	if err != nil {
+		t.Fatalf("failed to import snapshot: %v", err)
+	}
+	defer func() {
+		if err = imported.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	var n int
+	if err := imported.Pool.QueryRow(ctx, "SELECT count(*) FROM seeded").Scan(&n); err != nil {
+		t.Fatalf("failed to count rows: %v", err)
+	}
+	if n != 1000 {
+		t.Errorf("expected 1000 rows, got %d", n)
+	}
+}
+
+func TestDataArchiveChecksums(t *testing.T) {
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "base", "1"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string]string{"PG_VERSION": "16\n", "base/1/1234": "data"} {
+		if err := os.WriteFile(filepath.Join(src, filepath.FromSlash(name)), []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	archive := filepath.Join(t.TempDir(), "data.tar.gz")
+	if err := writeDataArchive(src, archive); err != nil {
+		t.Fatalf("failed to write archive: %v", err)
+	}
+
+	dst := t.TempDir()
+	if err := extractDataArchive(archive, dst); err != nil {
+		t.Fatalf("failed to extract archive: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dst, "base", "1", "1234"))
+	if err != nil || string(data) != "data" {
+		t.Errorf("unexpected extracted file: %q, %v", data, err)
+	}
+
+	err = verifyManifest([]byte("0000  PG_VERSION\n"), map[string]string{"PG_VERSION": "1111", "extra": "2222"})
+	if err == nil || !strings.Contains(err.Error(), "PG_VERSION: checksum mismatch") || !strings.Contains(err.Error(), "extra: not in") {
+		t.Errorf("expected checksum mismatch and unlisted file, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Libraries extensions need in shared_preload_libraries
+var extensionPreloads = map[string]string{
+	"citus":              "citus",
+	"pg_cron":            "pg_cron",
+	"pg_hint_plan":       "pg_hint_plan",
+	"pg_ivm":             "pg_ivm",
+	"pg_stat_statements": "pg_stat_statements",
+	"timescaledb":        "timescaledb",
+}
+
+// extensions returns the extensions to create in the test database
+func extensions(config Config) []string {
+	exts := config.Extensions
+	if config.IVM && !slices.Contains(exts, "pg_ivm") {
+		exts = append(slices.Clip(exts), "pg_ivm")
+	}
+	return exts
+}
+
+// extensionLibraries returns the libraries to preload for the extensions,
+// citus first as it requires
+func extensionLibraries(config Config) []string {
+	var libs []string
+	for _, ext := range extensions(config) {
+		if lib, ok := extensionPreloads[ext]; ok && !slices.Contains(libs, lib) {
+			libs = append(libs, lib)
+		}
+	}
+	if i := slices.Index(libs, "citus"); i > 0 {
+		libs = append([]string{"citus"}, slices.Delete(libs, i, i+1)...)
+	}
+	return libs
+}
+
+// createExtensions creates the extensions in the test database, reporting
+// all the missing ones at once
+func createExtensions(ctx context.Context, pool *pgxpool.Pool, config Config) error {
+	exts := extensions(config)
+	if len(exts) == 0 {
+		return nil
+	}
+
+	rows, err := pool.Query(ctx, `
+		SELECT name FROM unnest($1::text[]) name
+		WHERE name NOT IN (SELECT name FROM pg_available_extensions)`, exts)
+	if err != nil {
+		return err
+	}
+	missing, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		major, err := serverMajorVersion(ctx, pool)
+		if err != nil {
+			return err
+		}
+		return missingExtensionsError(missing, major)
+	}
+
+	for _, ext := range exts {
+		if _, err := pool.Exec(ctx, "CREATE EXTENSION IF NOT EXISTS "+pgx.Identifier{ext}.Sanitize()+" CASCADE"); err != nil {
+			return fmt.Errorf("Failed to create extension %s: %w", ext, err)
+		}
+	}
+	return nil
+}
+
+func missingExtensionsError(missing []string, major int) error {
+	hints := make([]string, len(missing))
+	for i, ext := range missing {
+		hints[i] = "hint: " + installHint(ext, major, packageManager())
+	}
+	return fmt.Errorf("Extensions not installed: %s\n%s", strings.Join(missing, ", "), strings.Join(hints, "\n"))
+}
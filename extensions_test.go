@@ -0,0 +1,46 @@
+package pgxtest
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExtensionLibraries(t *testing.T) {
+	libs := extensionLibraries(Config{
+		Extensions: []string{"pgcrypto", "pg_stat_statements", "citus"},
+		IVM:        true,
+	})
+	expected := []string{"citus", "pg_stat_statements", "pg_ivm"}
+	if !slices.Equal(libs, expected) {
+		t.Errorf("expected %q, got %q", expected, libs)
+	}
+}
+
+func TestMissingExtensionsError(t *testing.T) {
+	err := missingExtensionsError([]string{"postgis", "vector"}, 16)
+	if !strings.Contains(err.Error(), "postgis, vector") || strings.Count(err.Error(), "hint: ") != 2 {
+		t.Errorf("expected missing extensions with hints, got %v", err)
+	}
+}
+
+func TestExtensions(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg := StartT(t, Config{Extensions: []string{"pgcrypto", "pg_stat_statements"}})
+
+	var n int
+	if err := pg.Pool.QueryRow(ctx, "SELECT count(*) FROM pg_stat_statements").Scan(&n); err != nil {
+		t.Errorf("expected pg_stat_statements to be usable: %v", err)
+	}
+	if _, err := pg.Pool.Exec(ctx, "SELECT gen_random_bytes(8)"); err != nil {
+		t.Errorf("expected pgcrypto to be usable: %v", err)
+	}
+
+	_, err := Start(ctx, Config{Extensions: []string{"pgxtest_nonexistent"}})
+	if err == nil || !strings.Contains(err.Error(), "pgxtest_nonexistent") {
+		t.Errorf("expected error naming the missing extension, got %v", err)
+	}
+}
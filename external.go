@@ -0,0 +1,161 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Environment variable with the URL of a server to use instead of starting
+// one, e.g. a PostgreSQL service of the CI
+const externalURLEnv = "PGXTEST_EXTERNAL_URL"
+
+// ExternalBackend uses a running server instead of starting one: every
+// instance is a database with a random name created on the server and dropped
+// on Stop. It is picked by Start if PGXTEST_EXTERNAL_URL is set and
+// Config.Backend is not, so CI with a managed PostgreSQL doesn't need local
+// binaries.
+//
+// The user of the URL needs the CREATEDB privilege. The server is shared with
+// other tests, so options configuring the server or the cluster (Settings,
+// AdditionalArgs, Password, TLS, HBA, Databases, Roles, PITR) are not
+// supported, nor are features that need the files or binaries of the server.
+type ExternalBackend struct {
+	URL string // postgres:// URL of the server, the database in it is used to create databases
+}
+
+func (b ExternalBackend) start(ctx context.Context, config Config) (_ *PG, err error) {
+	if err := validateExternalConfig(config); err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(b.URL)
+	if err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+		return nil, fmt.Errorf("Failed to parse URL of external PostgreSQL: expected postgres://...")
+	}
+	adminConf, err := pgx.ParseConfig(b.URL)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URL of external PostgreSQL: %w", err)
+	}
+
+	name, err := randomDatabaseName()
+	if err != nil {
+		return nil, err
+	}
+	withConn := func(ctx context.Context, fn func(conn *pgx.Conn) error) error {
+		conn, err := pgx.ConnectConfig(ctx, adminConf)
+		if err != nil {
+			return err
+		}
+		defer conn.Close(context.Background())
+		return fn(conn)
+	}
+	if err := withConn(ctx, func(conn *pgx.Conn) error {
+		_, err := conn.Exec(ctx, "CREATE DATABASE "+pgx.Identifier{name}.Sanitize())
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("Failed to create database on external PostgreSQL: %w", err)
+	}
+	release := func() error {
+		return withConn(context.Background(), func(conn *pgx.Conn) error {
+			return dropDatabase(context.Background(), conn, name)
+		})
+	}
+	defer func() {
+		if err != nil {
+			_ = release()
+		}
+	}()
+
+	u.Path = "/" + name
+	poolConf, err := pgxpool.ParseConfig(u.String())
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create pgx pool config: %w", err)
+	}
+	if _, ok := poolConf.ConnConfig.RuntimeParams["application_name"]; !ok {
+		poolConf.ConnConfig.RuntimeParams["application_name"] = applicationName(config.Labels)
+	}
+	poolConf.ConnConfig.Tracer = tracer(config)
+	expired := &atomic.Bool{}
+	poolConf.BeforeConnect = refuseExpired(expired)
+	pool, err := pgxpool.NewWithConfig(ctx, poolConf)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect to test DB: %w", err)
+	}
+
+	if err := prepareDatabase(ctx, pool, config); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("Failed to prepare test DB: %w", err)
+	}
+	if err := checkReady(ctx, pool, config); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	majorVersion, err := serverMajorVersion(ctx, pool)
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	pg := &PG{
+		Pool: pool,
+
+		Host: adminConf.Host,
+		Port: int(adminConf.Port),
+		User: adminConf.User,
+		Name: name,
+
+		MajorVersion: majorVersion,
+
+		initStdout: newRingBuffer(config.OutputLimit),
+		initStderr: newRingBuffer(config.OutputLimit),
+		stdout:     newRingBuffer(config.OutputLimit),
+		stderr:     newRingBuffer(config.OutputLimit),
+
+		config:   config,
+		release:  release,
+		expired:  expired,
+		external: u.String(),
+	}
+	if config.PoolStatsInterval > 0 {
+		pg.poolSampler = startPoolSampler(pool, config.PoolStatsInterval)
+	}
+	return pg, nil
+}
+
+// validateExternalConfig rejects options ExternalBackend can't apply to a
+// shared server
+func validateExternalConfig(config Config) error {
+	options := []struct {
+		name string
+		set  bool
+	}{
+		{"Settings", len(config.Settings) > 0},
+		{"AdditionalArgs", len(config.AdditionalArgs) > 0},
+		{"Password", config.Password != ""},
+		{"TLS", config.TLS},
+		{"HBA", config.HBA != nil},
+		{"Databases", len(config.Databases) > 0},
+		{"Roles", len(config.Roles) > 0},
+		{"PITR", config.PITR},
+	}
+	for _, o := range options {
+		if o.set {
+			return fmt.Errorf("Config.%s is not supported by ExternalBackend", o.name)
+		}
+	}
+	return nil
+}
+
+// externalBackend returns the backend of PGXTEST_EXTERNAL_URL, if it is set
+func externalBackend() (Backend, bool) {
+	if u := os.Getenv(externalURLEnv); u != "" {
+		return ExternalBackend{URL: u}, true
+	}
+	return nil, false
+}
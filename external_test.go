@@ -0,0 +1,48 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+)
+
+func TestExternalBackend(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	server := StartT(t, Config{ListenTCP: true})
+
+	pg, err := Start(ctx, Config{
+		Backend:     ExternalBackend{URL: server.TCPURL()},
+		InitScripts: []string{"CREATE TABLE t (val int)"},
+	})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	if pg.Name == server.Name {
+		t.Errorf("expected a database of its own, got %s", pg.Name)
+	}
+	if _, err := pg.Pool.Exec(ctx, "INSERT INTO t VALUES (1)"); err != nil {
+		t.Errorf("failed to insert: %v", err)
+	}
+	if err := pg.Stop(); err != nil {
+		t.Fatalf("failed to stop pgxtest: %v", err)
+	}
+
+	var exists bool
+	if err := server.Pool.QueryRow(ctx, "SELECT EXISTS (SELECT FROM pg_database WHERE datname = $1)", pg.Name).Scan(&exists); err != nil {
+		t.Fatalf("failed to query databases: %v", err)
+	}
+	if exists {
+		t.Errorf("expected database %s to be dropped on Stop", pg.Name)
+	}
+}
+
+func TestExternalBackendUnsupported(t *testing.T) {
+	_, err := Start(context.Background(), Config{
+		Backend:  ExternalBackend{URL: "postgres://localhost/postgres"},
+		Settings: map[string]string{"work_mem": "64MB"},
+	})
+	if err == nil || err.Error() != "Config.Settings is not supported by ExternalBackend" {
+		t.Errorf("expected an unsupported option error, got %v", err)
+	}
+}
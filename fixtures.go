@@ -0,0 +1,377 @@
+package pgxtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// A fixture file: rows of a table
+type fixture struct {
+	table string // Possibly schema-qualified, as in the file name
+	file  string
+	rows  []map[string]any
+}
+
+// LoadFixtures adds rows from the fixture files in the root of fsys to the
+// test database, in one transaction. Each file holds the rows of the table
+// named by the file (users.json, billing.invoices.yaml) as a list of objects
+// mapping column names to values:
+//
+//	# users.yaml
+//	- id: 1
+//	  email: alice@example.com
+//	  tags: ["admin"]
+//	- id: 2
+//	  email: bob@example.com
+//
+// JSON files (.json) may use any JSON. YAML files (.yaml, .yml) are limited
+// to such lists of flat mappings, with flow-style JSON for nested values.
+// Values are converted to the column types by the server, columns missing in
+// a row get their defaults.
+//
+// Tables are loaded in the order of their foreign keys, referenced tables
+// first; rows referencing rows of the same table must follow them. Sequences
+// of serial and identity columns are moved past the loaded values, so that
+// the application can insert more rows.
+func (p *PG) LoadFixtures(ctx context.Context, fsys fs.FS) error {
+	fixtures, err := readFixtures(fsys)
+	if err != nil {
+		return err
+	}
+	if len(fixtures) == 0 {
+		return nil
+	}
+
+	return pgx.BeginFunc(ctx, p.Pool, func(tx pgx.Tx) error {
+		ordered, err := orderFixtures(ctx, tx, fixtures)
+		if err != nil {
+			return err
+		}
+		for _, f := range ordered {
+			if err := loadFixture(ctx, tx, f); err != nil {
+				return err
+			}
+		}
+		for _, f := range ordered {
+			if err := resetSequences(ctx, tx, f.table); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// readFixtures reads and parses the fixture files in the root of fsys
+func readFixtures(fsys fs.FS) ([]fixture, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	var fixtures []fixture
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		ext := path.Ext(e.Name())
+		var parse func([]byte) ([]map[string]any, error)
+		switch ext {
+		case ".json":
+			parse = parseJSONFixture
+		case ".yaml", ".yml":
+			parse = parseYAMLFixture
+		default:
+			continue
+		}
+		data, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+		rows, err := parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse fixture %s: %w", e.Name(), err)
+		}
+		fixtures = append(fixtures, fixture{table: strings.TrimSuffix(e.Name(), ext), file: e.Name(), rows: rows})
+	}
+	return fixtures, nil
+}
+
+// orderFixtures sorts the fixtures so that tables referenced by foreign keys
+// are loaded before the tables referencing them. Tables in a cycle keep the
+// order of their names.
+func orderFixtures(ctx context.Context, tx pgx.Tx, fixtures []fixture) ([]fixture, error) {
+	// Canonical names of the tables, as regclass prints them
+	names := make([]string, len(fixtures))
+	for i, f := range fixtures {
+		names[i] = pgx.Identifier(strings.Split(f.table, ".")).Sanitize()
+	}
+	rows, err := tx.Query(ctx, "SELECT n::regclass::text FROM unnest($1::text[]) n", names)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find fixture tables: %w", err)
+	}
+	canonical, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find fixture tables: %w", err)
+	}
+	byName := map[string]int{}
+	for i, name := range canonical {
+		if prev, ok := byName[name]; ok {
+			return nil, fmt.Errorf("Fixtures %s and %s are for the same table", fixtures[prev].file, fixtures[i].file)
+		}
+		byName[name] = i
+	}
+
+	rows, err = tx.Query(ctx, `
+		SELECT DISTINCT conrelid::regclass::text, confrelid::regclass::text
+		FROM pg_constraint
+		WHERE contype = 'f' AND conrelid <> confrelid
+		  AND conrelid::regclass::text = ANY($1) AND confrelid::regclass::text = ANY($1)`, canonical)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get foreign keys: %w", err)
+	}
+	type reference struct{ From, To string }
+	refs, err := pgx.CollectRows(rows, pgx.RowToStructByPos[reference])
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get foreign keys: %w", err)
+	}
+	deps := make([][]int, len(fixtures)) // Referenced fixtures of each fixture
+	for _, r := range refs {
+		deps[byName[r.From]] = append(deps[byName[r.From]], byName[r.To])
+	}
+
+	order := make([]int, len(fixtures))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return fixtures[order[i]].table < fixtures[order[j]].table })
+
+	var ordered []fixture
+	state := make([]int, len(fixtures)) // 0: not visited, 1: visiting, 2: done
+	var visit func(i int)
+	visit = func(i int) {
+		if state[i] != 0 {
+			return
+		}
+		state[i] = 1
+		for _, d := range deps[i] {
+			visit(d)
+		}
+		state[i] = 2
+		ordered = append(ordered, fixtures[i])
+	}
+	for _, i := range order {
+		visit(i)
+	}
+	return ordered, nil
+}
+
+// loadFixture inserts the rows of the fixture, letting the server convert the
+// values from JSON to the column types
+func loadFixture(ctx context.Context, tx pgx.Tx, f fixture) error {
+	table := pgx.Identifier(strings.Split(f.table, ".")).Sanitize()
+	batch := &pgx.Batch{}
+	for _, row := range f.rows {
+		if len(row) == 0 {
+			batch.Queue("INSERT INTO " + table + " DEFAULT VALUES")
+			continue
+		}
+		columns := make([]string, 0, len(row))
+		for c := range row {
+			columns = append(columns, c)
+		}
+		sort.Strings(columns)
+		quoted := make([]string, len(columns))
+		for i, c := range columns {
+			quoted[i] = pgx.Identifier{c}.Sanitize()
+		}
+		list := strings.Join(quoted, ", ")
+		data, err := json.Marshal(row)
+		if err != nil {
+			return fmt.Errorf("Failed to encode row of fixture %s: %w", f.file, err)
+		}
+		batch.Queue(fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM json_populate_record(NULL::%s, $1)",
+			table, list, list, table), string(data))
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("Failed to load fixture %s: %w", f.file, err)
+	}
+	return nil
+}
+
+// resetSequences moves sequences of serial and identity columns of the table
+// past the largest values in the columns
+func resetSequences(ctx context.Context, tx pgx.Tx, table string) error {
+	name := pgx.Identifier(strings.Split(table, ".")).Sanitize()
+	rows, err := tx.Query(ctx, `
+		SELECT attname, pg_get_serial_sequence($1::text, attname)
+		FROM pg_attribute
+		WHERE attrelid = $1::text::regclass AND attnum > 0 AND NOT attisdropped
+		  AND pg_get_serial_sequence($1::text, attname) IS NOT NULL`, name)
+	if err != nil {
+		return fmt.Errorf("Failed to get sequences of %s: %w", table, err)
+	}
+	type sequence struct{ Column, Name string }
+	sequences, err := pgx.CollectRows(rows, pgx.RowToStructByPos[sequence])
+	if err != nil {
+		return fmt.Errorf("Failed to get sequences of %s: %w", table, err)
+	}
+	for _, s := range sequences {
+		// setval ignores NULL, the sequence of an empty column is kept
+		sql := fmt.Sprintf("SELECT setval($1::text::regclass, (SELECT max(%s) FROM %s))", pgx.Identifier{s.Column}.Sanitize(), name)
+		if _, err := tx.Exec(ctx, sql, s.Name); err != nil {
+			return fmt.Errorf("Failed to reset sequence %s: %w", s.Name, err)
+		}
+	}
+	return nil
+}
+
+func parseJSONFixture(data []byte) ([]map[string]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	// Keep large integers exact
+	dec.UseNumber()
+	var rows []map[string]any
+	if err := dec.Decode(&rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// parseYAMLFixture parses a YAML list of flat mappings with scalar values or
+// flow-style JSON values. Nested block-style mappings and sequences are
+// rejected rather than misread.
+func parseYAMLFixture(data []byte) ([]map[string]any, error) {
+	rows := []map[string]any{}
+	var row map[string]any
+	indent := -1 // Indentation of the keys of the row, -1 until known
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		trimmed := strings.TrimLeft(line, " ")
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#") || line == "---":
+			continue
+		case line == "[]" && len(rows) == 0:
+			continue
+		case line == "-" || strings.HasPrefix(line, "- "):
+			row = map[string]any{}
+			rows = append(rows, row)
+			trimmed = strings.TrimSpace(strings.TrimPrefix(line, "-"))
+			if trimmed == "{}" || trimmed == "" {
+				indent = -1
+				continue
+			}
+			indent = len(line) - len(trimmed)
+		case row == nil || len(trimmed) == len(line):
+			return nil, fmt.Errorf("line %d: expected a list of mappings", i+1)
+		default:
+			if indent < 0 {
+				indent = len(line) - len(trimmed)
+			}
+			switch {
+			case len(line)-len(trimmed) > indent || trimmed == "-" || strings.HasPrefix(trimmed, "- "):
+				return nil, fmt.Errorf("line %d: unsupported YAML construct, nested values must be flow-style JSON", i+1)
+			case len(line)-len(trimmed) < indent:
+				return nil, fmt.Errorf("line %d: expected a list of mappings", i+1)
+			}
+		}
+
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok || (value != "" && value[0] != ' ') {
+			return nil, fmt.Errorf("line %d: expected key: value", i+1)
+		}
+		key = strings.TrimSpace(key)
+		if _, dup := row[key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %s", i+1, key)
+		}
+		v, err := parseYAMLScalar(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		row[key] = v
+	}
+	return rows, nil
+}
+
+// parseYAMLScalar parses a value of a YAML fixture
+func parseYAMLScalar(s string) (any, error) {
+	switch {
+	case s == "" || s == "~" || s == "null" || s == "Null" || s == "NULL":
+		return nil, nil
+	case s == "true" || s == "True" || s == "TRUE":
+		return true, nil
+	case s == "false" || s == "False" || s == "FALSE":
+		return false, nil
+	case s[0] == '"':
+		end := closingQuote(s)
+		if end < 0 || !isYAMLComment(s[end+1:]) {
+			return nil, fmt.Errorf("invalid double-quoted string %s", s)
+		}
+		v, err := strconv.Unquote(s[:end+1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid double-quoted string %s", s)
+		}
+		return v, nil
+	case s[0] == '\'':
+		end := closingQuote(s)
+		if end < 0 || !isYAMLComment(s[end+1:]) {
+			return nil, fmt.Errorf("invalid single-quoted string %s", s)
+		}
+		return strings.ReplaceAll(s[1:end], "''", "'"), nil
+	case s[0] == '[' || s[0] == '{':
+		var v any
+		dec := json.NewDecoder(strings.NewReader(s))
+		dec.UseNumber()
+		if err := dec.Decode(&v); err != nil {
+			return nil, fmt.Errorf("flow-style values must be JSON: %s", s)
+		}
+		return v, nil
+	case s[0] == '|' || s[0] == '>' || s[0] == '&' || s[0] == '*' || s[0] == '!':
+		return nil, fmt.Errorf("unsupported YAML value %s, use JSON fixtures", s)
+	}
+
+	// Comments end plain scalars
+	if before, _, ok := strings.Cut(s, " #"); ok {
+		s = strings.TrimSpace(before)
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil && strings.IndexFunc(s, isNotNumeric) < 0 {
+		return json.Number(s), nil
+	}
+	return s, nil
+}
+
+// closingQuote returns the index of the quote closing the string s starts
+// with, or -1. Double-quoted strings escape with backslashes, single-quoted
+// ones by doubling the quote.
+func closingQuote(s string) int {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote == '"' && s[i] == '\\':
+			i++
+		case s[i] == quote && quote == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// isYAMLComment reports whether the rest of a value is empty or a comment
+func isYAMLComment(rest string) bool {
+	trimmed := strings.TrimLeft(rest, " \t")
+	return trimmed == "" || (trimmed[0] == '#' && len(trimmed) < len(rest))
+}
+
+// isNotNumeric reports whether the rune can't appear in a decimal number, to
+// keep strings such as Inf or 0x10 strings
+func isNotNumeric(r rune) bool {
+	return !strings.ContainsRune("0123456789+-.eE", r)
+}
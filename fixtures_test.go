@@ -0,0 +1,128 @@
+package pgxtest
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadFixtures(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg := StartT(t, Config{
+		InitScripts: []string{`
+			CREATE SCHEMA billing;
+			CREATE TABLE users (id serial PRIMARY KEY, email text NOT NULL, tags text[], active bool DEFAULT true);
+			CREATE TABLE billing.invoices (id bigint GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY, user_id int NOT NULL REFERENCES users, data jsonb);
+		`},
+	})
+
+	fsys := fstest.MapFS{
+		// Sorts before users, but references them
+		"billing.invoices.json": {Data: []byte(`[{"id": 10, "user_id": 1, "data": {"total": 5}}]`)},
+		"users.yaml": {Data: []byte(`# Users
+- id: 1
+  email: alice@example.com
+  tags: ["admin"]
+- id: 2
+  email: 'bob@example.com'
+  active: false
+`)},
+		"README.md": {Data: []byte("not a fixture")},
+	}
+	if err := pg.LoadFixtures(ctx, fsys); err != nil {
+		t.Fatalf("failed to load fixtures: %v", err)
+	}
+
+	var inactive int
+	if err := pg.Pool.QueryRow(ctx, "SELECT count(*) FROM users WHERE NOT active").Scan(&inactive); err != nil {
+		t.Fatalf("failed to query users: %v", err)
+	}
+	if inactive != 1 {
+		t.Errorf("expected 1 inactive user, got %d", inactive)
+	}
+
+	// Sequences continue after the fixtures
+	var userID, invoiceID int
+	if err := pg.Pool.QueryRow(ctx, "INSERT INTO users (email) VALUES ('carol@example.com') RETURNING id").Scan(&userID); err != nil {
+		t.Fatalf("failed to insert user: %v", err)
+	}
+	if err := pg.Pool.QueryRow(ctx, "INSERT INTO billing.invoices (user_id) VALUES (1) RETURNING id").Scan(&invoiceID); err != nil {
+		t.Fatalf("failed to insert invoice: %v", err)
+	}
+	if userID != 3 || invoiceID != 11 {
+		t.Errorf("expected new ids 3 and 11, got %d and %d", userID, invoiceID)
+	}
+}
+
+func TestParseYAMLFixture(t *testing.T) {
+	rows, err := parseYAMLFixture([]byte(`---
+- id: 1
+  name: "tab\there"
+  note: it's # comment
+  owner: "bob" # admin
+  title: 'it''s "quoted"'	# comment
+  big: 12345678901234567890
+  ratio: 0.5
+  code: 0x10
+  missing:
+  meta: {"a": [1, 2]}
+-
+  id: 2
+- {}
+`))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	expected := []map[string]any{
+		{
+			"id":      json.Number("1"),
+			"name":    "tab\there",
+			"note":    "it's",
+			"owner":   "bob",
+			"title":   "it's \"quoted\"",
+			"big":     json.Number("12345678901234567890"),
+			"ratio":   json.Number("0.5"),
+			"code":    "0x10",
+			"missing": nil,
+			"meta":    map[string]any{"a": []any{json.Number("1"), json.Number("2")}},
+		},
+		{"id": json.Number("2")},
+		{},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("expected %v, got %v", expected, rows)
+	}
+
+	for _, bad := range []string{
+		"id: 1\n",
+		"- id: 1\nname: x\n",
+		"- id: 1\n  id: 2\n",
+		"- text: |\n",
+		"- id: [1,\n",
+		"- name: \"bob\" admin\n",
+		"- name: 'bob'#admin\n",
+		"- id: 1\n id: 2\n",
+	} {
+		if _, err := parseYAMLFixture([]byte(bad)); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+
+	// Block-style nesting is rejected rather than flattened
+	for _, nested := range []string{
+		"- id: 1\n  meta:\n    a: 1\n",
+		"- id: 1\n  tags:\n    - a\n",
+		"- id: 1\n  tags:\n  - a\n",
+		"-\n  id: 1\n  meta:\n    a: 1\n",
+	} {
+		_, err := parseYAMLFixture([]byte(nested))
+		if err == nil || !strings.Contains(err.Error(), "unsupported YAML construct") {
+			t.Errorf("expected an unsupported construct error for %q, got %v", nested, err)
+		}
+	}
+}
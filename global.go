@@ -0,0 +1,105 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+)
+
+// GlobalConfig is the configuration Global starts the server with. Set it in
+// TestMain before the tests run.
+var GlobalConfig Config
+
+var global struct {
+	mu   sync.Mutex
+	pg   *PG
+	refs int
+}
+
+// Global returns the server shared by the whole test binary, starting it on
+// the first call. Every call must be paired with a call to Release, the server
+// is stopped when the last user releases it.
+//
+// Tests sharing the server share the test database too, use CreateDatabase to
+// get an isolated database per test.
+func Global(ctx context.Context) (*PG, error) {
+	global.mu.Lock()
+	defer global.mu.Unlock()
+
+	if global.pg == nil {
+		pg, err := Start(ctx, GlobalConfig)
+		if err != nil {
+			return nil, err
+		}
+		pg.global = true
+		global.pg = pg
+	}
+	global.refs++
+	return global.pg, nil
+}
+
+// Release stops the server of a PG obtained from Global once all users have
+// released it. Releasing it once it is stopped does nothing. For other PGs it
+// is equivalent to Stop.
+func (p *PG) Release() error {
+	if p == nil || !p.global {
+		return p.Stop()
+	}
+
+	global.mu.Lock()
+	if p != global.pg {
+		// Released by all users already
+		global.mu.Unlock()
+		return nil
+	}
+	pg := releaseGlobal()
+	global.mu.Unlock()
+
+	// Global starts a new server meanwhile if it is called
+	return pg.Stop()
+}
+
+// releaseGlobal drops a reference to the server of Global, returning the
+// server if it is to be stopped
+func releaseGlobal() *PG {
+	if global.refs > 0 {
+		global.refs--
+	}
+	if global.refs > 0 || global.pg == nil {
+		return nil
+	}
+
+	pg := global.pg
+	global.pg = nil
+	return pg
+}
+
+// RunGlobal runs the tests keeping the server returned by Global running
+// until all of them finish, so that tests releasing it do not cause it to be
+// restarted by the next test. Use it in TestMain:
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(pgxtest.RunGlobal(m))
+//	}
+//
+// The server is started lazily by the first call to Global.
+func RunGlobal(m *testing.M) int {
+	global.mu.Lock()
+	global.refs++
+	global.mu.Unlock()
+
+	code := m.Run()
+
+	global.mu.Lock()
+	pg := releaseGlobal()
+	global.mu.Unlock()
+	if err := pg.Stop(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to stop global PostgreSQL: %v\n", err)
+		if code == 0 {
+			code = 1
+		}
+	}
+	return code
+}
@@ -0,0 +1,44 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGlobal(t *testing.T) {
+	ctx := context.Background()
+
+	pg1, err := Global(ctx)
+	if err != nil {
+		t.Fatalf("failed to start global pgxtest: %v", err)
+	}
+	pg2, err := Global(ctx)
+	if err != nil {
+		t.Fatalf("failed to get global pgxtest: %v", err)
+	}
+	if pg1 != pg2 {
+		t.Errorf("expected the same instance")
+	}
+
+	if err := pg1.Release(); err != nil {
+		t.Errorf("failed to release global pgxtest: %v", err)
+	}
+	if err := pg2.Pool.Ping(ctx); err != nil {
+		t.Errorf("expected server to keep running while referenced: %v", err)
+	}
+	if err := pg2.Release(); err != nil {
+		t.Errorf("failed to release global pgxtest: %v", err)
+	}
+	if err := pg2.Release(); err != nil {
+		t.Errorf("expected releasing a stopped global pgxtest to do nothing, got %v", err)
+	}
+
+	pg3, err := Global(ctx)
+	if err != nil {
+		t.Fatalf("failed to restart global pgxtest: %v", err)
+	}
+	defer pg3.Release()
+	if pg3 == pg1 {
+		t.Errorf("expected a new instance after the last release")
+	}
+}
@@ -0,0 +1,220 @@
+package pgxtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Environment variable making AssertSchemaGolden write golden files instead
+// of comparing, e.g. PGXTEST_UPDATE_GOLDEN=1 go test ./...
+const updateGoldenEnv = "PGXTEST_UPDATE_GOLDEN"
+
+// Lines of context around changes in diffs
+const diffContext = 3
+
+// Largest number of cells of the table used to diff the changed middle parts
+// of two texts, larger changes are shown as a whole
+const maxDiffCells = 4 << 20
+
+// SchemaDump returns the schema of the test database dumped by pg_dump,
+// normalized to be stable across runs and pg_dump versions of a major
+// release: comments, SET statements, blank lines and ownership are dropped.
+// Compare it to a golden file with AssertSchemaGolden.
+func (p *PG) SchemaDump(ctx context.Context) (string, error) {
+	var dump bytes.Buffer
+	if err := p.Dump(ctx, &dump, DumpOptions{SchemaOnly: true, NoOwner: true}); err != nil {
+		return "", err
+	}
+	return normalizeSchemaDump(dump.String()), nil
+}
+
+// normalizeSchemaDump drops lines of a plain pg_dump output that change
+// between runs or carry no schema
+func normalizeSchemaDump(dump string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(dump, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		switch {
+		case line == "",
+			strings.HasPrefix(line, "--"),
+			strings.HasPrefix(line, "SET "),
+			strings.HasPrefix(line, "SELECT pg_catalog.set_config("),
+			// Random keys of newer pg_dump versions
+			strings.HasPrefix(line, `\restrict `), strings.HasPrefix(line, `\unrestrict `):
+			continue
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// AssertSchemaGolden fails the test with a unified diff if the schema of the
+// test database differs from the golden file written by SchemaDump, e.g. to
+// check that migrations produce the expected schema. With
+// PGXTEST_UPDATE_GOLDEN set, the golden file is written instead.
+func (p *PG) AssertSchemaGolden(ctx context.Context, t testing.TB, path string) {
+	t.Helper()
+
+	actual, err := p.SchemaDump(ctx)
+	if err != nil {
+		t.Fatalf("failed to dump schema: %v", err)
+	}
+
+	if os.Getenv(updateGoldenEnv) != "" {
+		if err := os.WriteFile(path, []byte(actual), 0644); err != nil {
+			t.Fatalf("failed to write golden file: %v", err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("golden file %s does not exist, create it with %s=1", path, updateGoldenEnv)
+	}
+	if err != nil {
+		t.Fatalf("failed to read golden file: %v", err)
+	}
+	if string(expected) != actual {
+		t.Fatalf("schema differs from %s, update it with %s=1:\n%s", path, updateGoldenEnv,
+			unifiedDiff(path, "actual", string(expected), actual))
+	}
+}
+
+// unifiedDiff returns a unified diff of two texts
+func unifiedDiff(fromName string, toName string, from string, to string) string {
+	a, b := splitLines(from), splitLines(to)
+
+	// Lines in the changed middle are found by LCS, common prefix and suffix
+	// are cut first to keep the table small
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []diffOp
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, diffOp{' ', a[i]})
+	}
+	ops = append(ops, diffLines(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for i := len(a) - suffix; i < len(a); i++ {
+		ops = append(ops, diffOp{' ', a[i]})
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+	for start := 0; start < len(ops); {
+		// Find the next change
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+		// Extend the hunk while changes are close enough
+		end := start
+		for i := start; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				end = i + 1
+			} else if i-end >= 2*diffContext {
+				break
+			}
+		}
+		lo, hi := max(start-diffContext, 0), min(end+diffContext, len(ops))
+
+		// Line numbers of the hunk in both texts
+		aLine, bLine := 1, 1
+		for _, op := range ops[:lo] {
+			if op.kind != '+' {
+				aLine++
+			}
+			if op.kind != '-' {
+				bLine++
+			}
+		}
+		aCount, bCount := 0, 0
+		for _, op := range ops[lo:hi] {
+			if op.kind != '+' {
+				aCount++
+			}
+			if op.kind != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", aLine, aCount, bLine, bCount)
+		for _, op := range ops[lo:hi] {
+			fmt.Fprintf(&out, "%c%s\n", op.kind, op.line)
+		}
+		start = hi
+	}
+	return out.String()
+}
+
+// A line of a diff: ' ' for common, '-' for removed and '+' for added lines
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// diffLines returns the edit script turning a into b
+func diffLines(a []string, b []string) []diffOp {
+	var ops []diffOp
+	if len(a)*len(b) > maxDiffCells {
+		for _, l := range a {
+			ops = append(ops, diffOp{'-', l})
+		}
+		for _, l := range b {
+			ops = append(ops, diffOp{'+', l})
+		}
+		return ops
+	}
+
+	// lcs[i][j] is the length of the LCS of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	return ops
+}
+
+// splitLines splits the text into lines without the line terminators
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
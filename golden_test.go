@@ -0,0 +1,82 @@
+package pgxtest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSchemaDump(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	config := Config{InitScripts: []string{"CREATE TABLE users (id int PRIMARY KEY, email text NOT NULL)"}}
+	first := StartT(t, config)
+	second := StartT(t, config)
+
+	dump, err := first.SchemaDump(ctx)
+	if err != nil {
+		t.Fatalf("failed to dump schema: %v", err)
+	}
+	if !strings.Contains(dump, "CREATE TABLE public.users (") {
+		t.Errorf("expected the dump to create the table, got:\n%s", dump)
+	}
+	if strings.Contains(dump, "--") || strings.Contains(dump, "\nSET ") {
+		t.Errorf("expected comments and settings to be dropped, got:\n%s", dump)
+	}
+
+	// Instances with the same schema match the same golden file
+	golden := filepath.Join(t.TempDir(), "schema.sql")
+	if err := os.WriteFile(golden, []byte(dump), 0644); err != nil {
+		t.Fatalf("failed to write golden file: %v", err)
+	}
+	second.AssertSchemaGolden(ctx, t, golden)
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	from := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	to := "a\nb\nc\nD\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\n"
+	expected := `--- golden
++++ actual
+@@ -1,7 +1,7 @@
+ a
+ b
+ c
+-d
++D
+ e
+ f
+ g
+@@ -11,3 +11,4 @@
+ k
+ l
+ m
++n
+`
+	if diff := unifiedDiff("golden", "actual", from, to); diff != expected {
+		t.Errorf("expected diff:\n%s\ngot:\n%s", expected, diff)
+	}
+}
+
+func TestNormalizeSchemaDump(t *testing.T) {
+	dump := `--
+-- PostgreSQL database dump
+--
+\restrict abc123
+
+SET statement_timeout = 0;
+SELECT pg_catalog.set_config('search_path', '', false);
+
+CREATE TABLE public.t (
+    id integer
+);
+
+\unrestrict abc123
+`
+	expected := "CREATE TABLE public.t (\n    id integer\n);\n"
+	if got := normalizeSchemaDump(dump); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,63 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// hbaConf returns the contents of pg_hba.conf with the rules. The test user
+// is always trusted over the UNIX socket, which pgxtest and the Pool rely on.
+// If Config.Password is set, rules requiring it over TCP follow the rules.
+func hbaConf(rules []string, config Config) string {
+	lines := []string{
+		"local all test trust",
+		"local replication test trust",
+	}
+	lines = append(lines, rules...)
+	if config.Password != "" {
+		method := config.Auth
+		if method == "" {
+			method = defaultPasswordAuth
+		}
+		lines = append(lines, "local all all trust", "local replication all trust")
+		for _, addr := range []string{"127.0.0.1/32", "::1/128"} {
+			lines = append(lines,
+				"host all all "+addr+" "+method,
+				"host replication all "+addr+" "+method)
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// writeHBA replaces pg_hba.conf of the cluster
+func writeHBA(dataDir string, rules []string, config Config) error {
+	return os.WriteFile(filepath.Join(dataDir, "pg_hba.conf"), []byte(hbaConf(rules, config)), 0600)
+}
+
+// SetHBA replaces the rules of pg_hba.conf set by Config.HBA and reloads the
+// configuration, to test several authentication setups against one server.
+// New connections are authenticated by the new rules. If the rules are
+// invalid, the server keeps the previous ones and an error is returned.
+func (p *PG) SetHBA(ctx context.Context, rules []string) error {
+	if p.dataDir == "" {
+		return fmt.Errorf("pg_hba.conf can only be changed on a server started by LocalBackend")
+	}
+	if err := writeHBA(p.dataDir, rules, p.config); err != nil {
+		return err
+	}
+	if _, err := p.Pool.Exec(ctx, "SELECT pg_reload_conf()"); err != nil {
+		return err
+	}
+
+	errs, err := p.queryStrings(ctx, "SELECT format('line %s: %s', line_number, error) FROM pg_hba_file_rules WHERE error IS NOT NULL ORDER BY line_number")
+	if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("Invalid pg_hba.conf rules:\n  %s", strings.Join(errs, "\n  "))
+	}
+	return nil
+}
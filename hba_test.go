@@ -0,0 +1,75 @@
+package pgxtest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestHBA(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{
+		ListenTCP: true,
+		Roles:     []Role{{Name: "app"}},
+		HBA:       []string{"host all app 127.0.0.1/32 reject", "host all app ::1/128 reject"},
+	})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	connectApp := func() error {
+		conf, err := pgx.ParseConfig(pg.TCPURL())
+		if err != nil {
+			return err
+		}
+		conf.User = "app"
+		conn, err := pgx.ConnectConfig(ctx, conf)
+		if err == nil {
+			conn.Close(ctx)
+		}
+		return err
+	}
+
+	if err := connectApp(); err == nil {
+		t.Errorf("expected connection of app to be rejected")
+	}
+
+	if err := pg.SetHBA(ctx, []string{"host all app 127.0.0.1/32 trust", "host all app ::1/128 trust"}); err != nil {
+		t.Fatalf("failed to set pg_hba.conf: %v", err)
+	}
+	if err := connectApp(); err != nil {
+		t.Errorf("failed to connect as app: %v", err)
+	}
+
+	if err := pg.SetHBA(ctx, []string{"host all app nowhere"}); err == nil {
+		t.Errorf("expected an error for invalid rules")
+	}
+	if err := pg.Pool.Ping(ctx); err != nil {
+		t.Errorf("failed to ping after invalid rules: %v", err)
+	}
+}
+
+func TestHBAConf(t *testing.T) {
+	conf := hbaConf([]string{"host all app 10.0.0.0/8 md5"}, Config{Password: "secret"})
+	lines := strings.Split(strings.TrimSpace(conf), "\n")
+	if lines[0] != "local all test trust" {
+		t.Errorf("expected the test user to be trusted first, got %q", lines[0])
+	}
+	if lines[2] != "host all app 10.0.0.0/8 md5" {
+		t.Errorf("expected the rules after the test user, got %q", lines[2])
+	}
+	for _, line := range []string{"host all all 127.0.0.1/32 scram-sha-256", "host all all ::1/128 scram-sha-256"} {
+		if !strings.Contains(conf, line+"\n") {
+			t.Errorf("expected %q in pg_hba.conf:\n%s", line, conf)
+		}
+	}
+}
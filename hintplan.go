@@ -0,0 +1,63 @@
+package pgxtest
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Hint prefixes the query with a pg_hint_plan hint comment, e.g.
+//
+//	pgxtest.Hint("SELECT * FROM t WHERE id = $1", "IndexScan(t t_pkey)")
+//
+// Hints take effect if the server runs with Config.HintPlan.
+func Hint(query string, hints ...string) string {
+	if len(hints) == 0 {
+		return query
+	}
+	return "/*+ " + strings.Join(hints, " ") + " */ " + query
+}
+
+// HintPlanAvailable reports whether pg_hint_plan is installed for the
+// PostgreSQL found in binDir (or the default location if empty)
+func HintPlanAvailable(binDir string) bool {
+	binPath, err := findBinPath(binDir)
+	if err != nil {
+		return false
+	}
+	return libraryAvailable(binPath, "pg_hint_plan")
+}
+
+// libraryAvailable reports whether the server library is installed
+func libraryAvailable(binPath string, library string) bool {
+	for _, dir := range pkgLibDirs(binPath) {
+		matches, _ := filepath.Glob(filepath.Join(dir, library+".*"))
+		if len(matches) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// pkgLibDirs returns candidate directories of server libraries
+func pkgLibDirs(binPath string) []string {
+	var dirs []string
+	if out, err := exec.Command(filepath.Join(binPath, "pg_config"), "--pkglibdir").Output(); err == nil {
+		dirs = append(dirs, strings.TrimSpace(string(out)))
+	}
+
+	// Without pg_config: Debian/Ubuntu layout and the usual prefix layouts
+	prefix := filepath.Dir(binPath)
+	for _, dir := range []string{
+		filepath.Join(prefix, "lib"),
+		filepath.Join(prefix, "lib", "postgresql"),
+		filepath.Join(prefix, "lib64", "pgsql"),
+		filepath.Join(prefix, "lib", "pgsql"),
+	} {
+		if f, err := os.Stat(dir); err == nil && f.IsDir() {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
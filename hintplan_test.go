@@ -0,0 +1,56 @@
+package pgxtest
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestHintPlan(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	if !HintPlanAvailable("") {
+		t.Skip("pg_hint_plan is not installed")
+	}
+
+	pg, err := Start(ctx, Config{HintPlan: true})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	if _, err := pg.Pool.Exec(ctx, "CREATE TABLE items AS SELECT generate_series(1, 10000) AS id; CREATE INDEX items_id ON items (id); ANALYZE items"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	rows, err := pg.Pool.Query(ctx, Hint("EXPLAIN SELECT * FROM items WHERE id > 0", "IndexScan(items items_id)"))
+	if err != nil {
+		t.Fatalf("failed to explain: %v", err)
+	}
+	var plan strings.Builder
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			t.Fatalf("failed to scan: %v", err)
+		}
+		plan.WriteString(line + "\n")
+	}
+	if !strings.Contains(plan.String(), "Index Scan using items_id") {
+		t.Errorf("expected hinted index scan, got:\n%s", plan.String())
+	}
+}
+
+func TestHint(t *testing.T) {
+	got := Hint("SELECT 1", "SeqScan(t)", "Leading(t u)")
+	if got != "/*+ SeqScan(t) Leading(t u) */ SELECT 1" {
+		t.Errorf("unexpected hinted query %q", got)
+	}
+	if Hint("SELECT 1") != "SELECT 1" {
+		t.Errorf("query without hints should not change")
+	}
+}
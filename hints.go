@@ -0,0 +1,142 @@
+package pgxtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Messages of CREATE EXTENSION for extensions that are not installed
+var missingExtensionMessages = []*regexp.Regexp{
+	regexp.MustCompile(`extension "([^"]+)" is not available`),                         // PostgreSQL 15+
+	regexp.MustCompile(`could not open extension control file ".*/([^/"]+)\.control"`), // Older
+}
+
+// Extensions shipped with PostgreSQL in the contrib package
+var contribExtensions = map[string]bool{
+	"adminpack": true, "amcheck": true, "autoinc": true, "bloom": true, "btree_gin": true,
+	"btree_gist": true, "citext": true, "cube": true, "dblink": true, "dict_int": true,
+	"dict_xsyn": true, "earthdistance": true, "file_fdw": true, "fuzzystrmatch": true,
+	"hstore": true, "insert_username": true, "intagg": true, "intarray": true, "isn": true,
+	"lo": true, "ltree": true, "moddatetime": true, "pageinspect": true, "pg_buffercache": true,
+	"pg_freespacemap": true, "pg_prewarm": true, "pg_stat_statements": true, "pg_surgery": true,
+	"pg_trgm": true, "pg_visibility": true, "pg_walinspect": true, "pgcrypto": true,
+	"pgrowlocks": true, "pgstattuple": true, "postgres_fdw": true, "refint": true, "seg": true,
+	"sslinfo": true, "tablefunc": true, "tcn": true, "tsm_system_rows": true,
+	"tsm_system_time": true, "unaccent": true, "uuid-ossp": true, "xml2": true,
+}
+
+// Package names of third-party extensions whose packages are not named after
+// them, per package manager. %d is the major version of PostgreSQL.
+var extensionPackages = map[string]map[string]string{
+	"postgis":     {"apt": "postgresql-%d-postgis-3", "dnf": "postgis34_%d", "apk": "postgis", "brew": "postgis"},
+	"vector":      {"apt": "postgresql-%d-pgvector", "dnf": "pgvector_%d", "apk": "postgresql-pgvector", "brew": "pgvector"},
+	"timescaledb": {"apt": "timescaledb-2-postgresql-%d", "dnf": "timescaledb-2-postgresql-%d", "brew": "timescaledb"},
+	"pg_partman":  {"apt": "postgresql-%d-partman", "dnf": "pg_partman_%d"},
+	"pgtap":       {"apt": "postgresql-%d-pgtap", "dnf": "pgtap_%d", "apk": "postgresql-pgtap", "brew": "pgtap"},
+}
+
+// addInstallHint adds the command installing the missing extension to the
+// error of CREATE EXTENSION, if err is one
+func addInstallHint(ctx context.Context, pool *pgxpool.Pool, err error) error {
+	ext := missingExtension(err)
+	if ext == "" {
+		return err
+	}
+	major, versionErr := serverMajorVersion(ctx, pool)
+	if versionErr != nil {
+		return err
+	}
+	return fmt.Errorf("%w\nhint: %s", err, installHint(ext, major, packageManager()))
+}
+
+// missingExtension returns the name of the extension err reports as not
+// installed
+func missingExtension(err error) string {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return ""
+	}
+	for _, re := range missingExtensionMessages {
+		if m := re.FindStringSubmatch(pgErr.Message); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// installHint returns the command installing the extension for the major
+// version of PostgreSQL with the package manager
+func installHint(ext string, major int, manager string) string {
+	var pkg string
+	switch {
+	case contribExtensions[ext]:
+		pkg = map[string]string{
+			"apt":  "postgresql-%d", // Includes contrib since PostgreSQL 10
+			"dnf":  "postgresql%d-contrib",
+			"apk":  "postgresql%d-contrib",
+			"brew": "postgresql@%d",
+		}[manager]
+	case extensionPackages[ext] != nil:
+		pkg = extensionPackages[ext][manager]
+	default:
+		// The usual naming of extension packages
+		pkg = map[string]string{
+			"apt": "postgresql-%d-" + strings.ReplaceAll(ext, "_", "-"),
+			"dnf": ext + "_%d",
+		}[manager]
+	}
+	if pkg == "" {
+		return fmt.Sprintf("install the package providing extension %s for PostgreSQL %d", ext, major)
+	}
+	if strings.Contains(pkg, "%d") {
+		pkg = fmt.Sprintf(pkg, major)
+	}
+
+	switch manager {
+	case "apt":
+		return "sudo apt-get install " + pkg
+	case "dnf":
+		return "sudo dnf install " + pkg
+	case "apk":
+		return "apk add " + pkg
+	default:
+		return "brew install " + pkg
+	}
+}
+
+// packageManager returns the package manager of the machine: apt, dnf, apk,
+// brew, or empty if unknown
+func packageManager() string {
+	if runtime.GOOS == "darwin" {
+		return "brew"
+	}
+	data, err := os.ReadFile("/etc/os-release")
+	if err != nil {
+		return ""
+	}
+	var ids []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if k, v, ok := strings.Cut(line, "="); ok && (k == "ID" || k == "ID_LIKE") {
+			ids = append(ids, strings.Fields(strings.Trim(v, `"`))...)
+		}
+	}
+	for _, id := range ids {
+		switch id {
+		case "debian", "ubuntu":
+			return "apt"
+		case "rhel", "fedora", "centos":
+			return "dnf"
+		case "alpine":
+			return "apk"
+		}
+	}
+	return ""
+}
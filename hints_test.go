@@ -0,0 +1,61 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestMissingExtension(t *testing.T) {
+	for _, c := range []struct {
+		err      error
+		expected string
+	}{
+		{&pgconn.PgError{Code: "0A000", Message: `extension "postgis" is not available`}, "postgis"},
+		{fmt.Errorf("migration 3: %w", &pgconn.PgError{
+			Code:    "58P01",
+			Message: `could not open extension control file "/usr/share/postgresql/14/extension/uuid-ossp.control": No such file or directory`,
+		}), "uuid-ossp"},
+		{&pgconn.PgError{Code: "42601", Message: "syntax error"}, ""},
+		{fmt.Errorf(`extension "postgis" is not available`), ""},
+	} {
+		if got := missingExtension(c.err); got != c.expected {
+			t.Errorf("missingExtension(%v): expected %q, got %q", c.err, c.expected, got)
+		}
+	}
+}
+
+func TestInstallHint(t *testing.T) {
+	for _, c := range []struct {
+		ext, manager, expected string
+	}{
+		{"postgis", "apt", "sudo apt-get install postgresql-16-postgis-3"},
+		{"pg_trgm", "dnf", "sudo dnf install postgresql16-contrib"},
+		{"vector", "brew", "brew install pgvector"},
+		{"pg_cron", "apt", "sudo apt-get install postgresql-16-pg-cron"},
+		{"pg_cron", "", "install the package providing extension pg_cron for PostgreSQL 16"},
+	} {
+		if got := installHint(c.ext, 16, c.manager); got != c.expected {
+			t.Errorf("installHint(%s, %s): expected %q, got %q", c.ext, c.manager, c.expected, got)
+		}
+	}
+}
+
+func TestInstallHintOnStart(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	_, err := Start(ctx, Config{
+		Migrate: func(ctx context.Context, pool *pgxpool.Pool) error {
+			_, err := pool.Exec(ctx, "CREATE EXTENSION pgxtest_nonexistent")
+			return err
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "hint: ") {
+		t.Errorf("expected install hint in error, got %v", err)
+	}
+}
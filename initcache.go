@@ -0,0 +1,173 @@
+package pgxtest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// initCluster creates a cluster in the empty dataDir, by running initdb, by
+// copying a cluster cached in Config.InitDBCache, by extracting a snapshot
+// exported by ExportSnapshot, by taking a base backup of a primary or by
+// restoring a base backup for point-in-time recovery
+func initCluster(binPath string, dataDir string, walDir string, config Config) (*ringBuffer, *ringBuffer, error) {
+	if config.primary != nil {
+		return replicaBaseBackup(binPath, dataDir, walDir, config)
+	}
+	if config.recovery != nil {
+		stdout, stderr := newRingBuffer(config.OutputLimit), newRingBuffer(config.OutputLimit)
+		return stdout, stderr, restoreBaseBackup(dataDir, walDir, config.recovery)
+	}
+	if config.snapshotFile != "" {
+		stdout, stderr := newRingBuffer(config.OutputLimit), newRingBuffer(config.OutputLimit)
+		if err := extractDataArchive(config.snapshotFile, dataDir); err != nil {
+			return stdout, stderr, err
+		}
+		if walDir != "" {
+			if err := moveWAL(dataDir, walDir); err != nil {
+				return stdout, stderr, fmt.Errorf("Failed to move WAL: %w", err)
+			}
+		}
+		return stdout, stderr, nil
+	}
+
+	if config.InitDBCache == "" {
+		return initDB(binPath, dataDir, initDBArgs(walDir, config), config.OutputLimit)
+	}
+
+	cached, err := cachedCluster(binPath, config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stdout := newRingBuffer(config.OutputLimit)
+	stderr := newRingBuffer(config.OutputLimit)
+	for name, buf := range map[string]*ringBuffer{"initdb.out": stdout, "initdb.err": stderr} {
+		if data, err := os.ReadFile(filepath.Join(cached, name)); err == nil {
+			_, _ = buf.Write(data)
+		}
+	}
+
+	// cp creates the directory with the permissions of the cached one, which
+	// postgres insists on
+	if err := os.Remove(dataDir); err != nil {
+		return stdout, stderr, err
+	}
+	if err := copyTree(filepath.Join(cached, "data"), dataDir); err != nil {
+		return stdout, stderr, fmt.Errorf("Failed to copy cached cluster: %w", err)
+	}
+	if walDir != "" {
+		if err := moveWAL(dataDir, walDir); err != nil {
+			return stdout, stderr, fmt.Errorf("Failed to move WAL: %w", err)
+		}
+	}
+	return stdout, stderr, nil
+}
+
+// cachedCluster returns the cache entry holding a cluster initialized by the
+// binaries in binPath, running initdb if there is none yet
+func cachedCluster(binPath string, config Config) (string, error) {
+	args := initDBArgs("", config)
+	key, err := initCacheKey(binPath, args)
+	if err != nil {
+		return "", err
+	}
+	entry := filepath.Join(config.InitDBCache, key)
+	if _, err := os.Stat(filepath.Join(entry, "data")); err == nil {
+		return entry, nil
+	}
+
+	if err := os.MkdirAll(config.InitDBCache, 0755); err != nil {
+		return "", err
+	}
+	// Concurrent Starts may populate the entry at the same time, the first
+	// one to rename its copy into place wins
+	tmp, err := os.MkdirTemp(config.InitDBCache, key+".tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+
+	stdout, stderr, err := initDB(binPath, filepath.Join(tmp, "data"), args, config.OutputLimit)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "initdb.out"), stdout.Bytes(), 0644); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "initdb.err"), stderr.Bytes(), 0644); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(tmp, entry); err != nil {
+		if _, statErr := os.Stat(filepath.Join(entry, "data")); statErr != nil {
+			return "", fmt.Errorf("Failed to populate initdb cache: %w", err)
+		}
+	}
+	return entry, nil
+}
+
+// initCacheKey identifies the clusters made by the initdb in binPath with the
+// arguments. The binary is identified by its size and modification time, so
+// that upgraded binaries don't reuse stale clusters.
+func initCacheKey(binPath string, args []string) (string, error) {
+	initdb := filepath.Join(binPath, "initdb")
+	fi, err := os.Stat(initdb)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%d\x00%d\x00%s", initdb, fi.Size(), fi.ModTime().UnixNano(), strings.Join(args, "\x00"))
+	return hex.EncodeToString(h.Sum(nil))[:16], nil
+}
+
+// copyTree copies the directory src to the non-existent dst, cloning the
+// files where the filesystem supports it
+func copyTree(src, dst string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "linux":
+		cmd = exec.Command("cp", "-a", "--reflink=auto", src, dst)
+	case "darwin":
+		cmd = exec.Command("cp", "-c", "-pR", src, dst)
+	default:
+		cmd = exec.Command("cp", "-pR", src, dst)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, out)
+	}
+	return nil
+}
+
+// moveWAL moves pg_wal of the cluster in dataDir to walDir and links it, as
+// initdb --waldir does
+func moveWAL(dataDir, walDir string) error {
+	pgWAL := filepath.Join(dataDir, "pg_wal")
+	entries, err := os.ReadDir(pgWAL)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		src, dst := filepath.Join(pgWAL, e.Name()), filepath.Join(walDir, e.Name())
+		if !e.IsDir() {
+			err = moveFile(src, dst)
+		} else if err = os.Rename(src, dst); err != nil {
+			// Subdirectories of a fresh pg_wal are empty
+			err = errors.Join(os.MkdirAll(dst, 0700), os.RemoveAll(src))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := os.Remove(pgWAL); err != nil {
+		return err
+	}
+	return os.Symlink(walDir, pgWAL)
+}
@@ -0,0 +1,101 @@
+package pgxtest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInitDBCache(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	cache := t.TempDir()
+	for i := 0; i < 2; i++ {
+		pg, err := Start(ctx, Config{InitDBCache: cache, WALDir: t.TempDir()})
+		if err != nil {
+			t.Fatalf("failed to start pgxtest: %v", err)
+		}
+		if _, err := pg.Pool.Exec(ctx, "CREATE TABLE t (id int)"); err != nil {
+			t.Errorf("failed to create table: %v", err)
+		}
+		if err := pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}
+
+	entries, err := os.ReadDir(cache)
+	if err != nil {
+		t.Fatalf("failed to read cache: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected 1 cache entry, got %d", len(entries))
+	}
+}
+
+func TestInitCacheKey(t *testing.T) {
+	binPath := t.TempDir()
+	initdb := filepath.Join(binPath, "initdb")
+	if err := os.WriteFile(initdb, []byte("v1"), 0755); err != nil {
+		t.Fatalf("failed to write initdb: %v", err)
+	}
+
+	key1, err := initCacheKey(binPath, []string{"--no-sync"})
+	if err != nil {
+		t.Fatalf("failed to compute key: %v", err)
+	}
+	key2, err := initCacheKey(binPath, []string{"--no-sync", "--locale=C"})
+	if err != nil {
+		t.Fatalf("failed to compute key: %v", err)
+	}
+	if key1 == key2 {
+		t.Errorf("expected different keys for different arguments")
+	}
+
+	if err := os.WriteFile(initdb, []byte("v2 upgraded"), 0755); err != nil {
+		t.Fatalf("failed to write initdb: %v", err)
+	}
+	key3, err := initCacheKey(binPath, []string{"--no-sync"})
+	if err != nil {
+		t.Fatalf("failed to compute key: %v", err)
+	}
+	if key1 == key3 {
+		t.Errorf("expected different keys for a replaced binary")
+	}
+}
+
+func TestMoveWAL(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "cached")
+	if err := os.MkdirAll(filepath.Join(src, "pg_wal", "archive_status"), 0700); err != nil {
+		t.Fatalf("failed to create pg_wal: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "pg_wal", "000000010000000000000001"), []byte("wal"), 0600); err != nil {
+		t.Fatalf("failed to write segment: %v", err)
+	}
+
+	dataDir := filepath.Join(dir, "data")
+	if err := copyTree(src, dataDir); err != nil {
+		t.Fatalf("failed to copy cluster: %v", err)
+	}
+	walDir := filepath.Join(dir, "wal")
+	if err := os.Mkdir(walDir, 0700); err != nil {
+		t.Fatalf("failed to create WAL directory: %v", err)
+	}
+	if err := moveWAL(dataDir, walDir); err != nil {
+		t.Fatalf("failed to move WAL: %v", err)
+	}
+
+	if target, err := os.Readlink(filepath.Join(dataDir, "pg_wal")); err != nil || target != walDir {
+		t.Errorf("expected pg_wal to link to %s, got %q, %v", walDir, target, err)
+	}
+	for _, name := range []string{"archive_status", "000000010000000000000001"} {
+		if _, err := os.Stat(filepath.Join(walDir, name)); err != nil {
+			t.Errorf("expected %s in WAL directory: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(src, "pg_wal", "000000010000000000000001")); err != nil {
+		t.Errorf("expected cached cluster to be intact: %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// prepareDatabase creates the extensions, runs the migrations and init scripts
+// of config on a new test database
+func prepareDatabase(ctx context.Context, pool *pgxpool.Pool, config Config) error {
+	if err := createExtensions(ctx, pool, config); err != nil {
+		return err
+	}
+	if config.Migrate != nil {
+		if err := config.Migrate(ctx, pool); err != nil {
+			return fmt.Errorf("Failed to migrate: %w", addInstallHint(ctx, pool, err))
+		}
+	}
+	return runInitScripts(ctx, pool, config)
+}
+
+// runInitScripts executes Config.InitScripts in order
+func runInitScripts(ctx context.Context, pool *pgxpool.Pool, config Config) error {
+	for _, pattern := range config.InitScripts {
+		var (
+			files []string
+			err   error
+		)
+		if config.InitScriptsFS != nil {
+			files, err = fs.Glob(config.InitScriptsFS, pattern)
+		} else {
+			files, err = filepath.Glob(pattern)
+		}
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("Init script %s not found", pattern)
+		}
+		sort.Strings(files)
+
+		for _, file := range files {
+			var sql []byte
+			if config.InitScriptsFS != nil {
+				sql, err = fs.ReadFile(config.InitScriptsFS, file)
+			} else {
+				sql, err = os.ReadFile(file)
+			}
+			if err != nil {
+				return err
+			}
+			// Without arguments the simple protocol is used, which allows
+			// several statements per script
+			if _, err := pool.Exec(ctx, string(sql)); err != nil {
+				return fmt.Errorf("Failed to run init script %s: %w", file, addInstallHint(ctx, pool, err))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package pgxtest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestInitScripts(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "schema.sql"), []byte("CREATE TABLE test (val text)"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	seeds := filepath.Join(dir, "seeds")
+	if err := os.Mkdir(seeds, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, sql := range map[string]string{
+		"02_b.sql": "INSERT INTO test VALUES ('b')",
+		"01_a.sql": "INSERT INTO test VALUES ('a'); UPDATE test SET val = val || '1'",
+	} {
+		if err := os.WriteFile(filepath.Join(seeds, name), []byte(sql), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pg, err := Start(ctx, Config{InitScripts: []string{
+		filepath.Join(dir, "schema.sql"),
+		filepath.Join(seeds, "*.sql"),
+	}})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	var vals string
+	if err := pg.Pool.QueryRow(ctx, "SELECT string_agg(val, ',' ORDER BY val) FROM test").Scan(&vals); err != nil {
+		t.Fatalf("failed to query: %v", err)
+	}
+	if vals != "a1,b" {
+		t.Errorf("expected scripts to run in order, got %q", vals)
+	}
+}
+
+func TestInitScriptsFS(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{
+		InitScripts:   []string{"schema/*.sql"},
+		InitScriptsFS: fstest.MapFS{"schema/test.sql": {Data: []byte("CREATE TABLE test (val text)")}},
+	})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	if _, err := pg.Pool.Exec(ctx, "SELECT * FROM test"); err != nil {
+		t.Errorf("expected init script to create the table: %v", err)
+	}
+}
+
+func TestInitScriptsMissing(t *testing.T) {
+	err := runInitScripts(context.Background(), nil, Config{
+		InitScripts:   []string{"missing/*.sql"},
+		InitScriptsFS: fstest.MapFS{},
+	})
+	if err == nil {
+		t.Errorf("expected missing init scripts to fail")
+	}
+}
@@ -0,0 +1,124 @@
+package pgxtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// StatIO is a row of pg_stat_io (PostgreSQL 16+). Operations not applicable
+// to the combination of backend type, object and context are reported as zero.
+type StatIO struct {
+	BackendType string
+	Object      string
+	Context     string
+
+	Reads     int64
+	Writes    int64
+	Extends   int64
+	Hits      int64
+	Evictions int64
+	Reuses    int64
+	Fsyncs    int64
+}
+
+// StatIO returns the cluster-wide I/O statistics from pg_stat_io
+func (p *PG) StatIO(ctx context.Context) ([]StatIO, error) {
+	rows, err := p.Pool.Query(ctx, `
+		SELECT backend_type, object, context,
+		       coalesce(reads, 0), coalesce(writes, 0), coalesce(extends, 0),
+		       coalesce(hits, 0), coalesce(evictions, 0), coalesce(reuses, 0),
+		       coalesce(fsyncs, 0)
+		FROM pg_stat_io
+		ORDER BY 1, 2, 3`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []StatIO
+	for rows.Next() {
+		var s StatIO
+		err := rows.Scan(&s.BackendType, &s.Object, &s.Context,
+			&s.Reads, &s.Writes, &s.Extends, &s.Hits, &s.Evictions, &s.Reuses, &s.Fsyncs)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+// ResetStatIO resets the pg_stat_io counters
+func (p *PG) ResetStatIO(ctx context.Context) error {
+	_, err := p.Pool.Exec(ctx, "SELECT pg_stat_reset_shared('io')")
+	return err
+}
+
+// RelationBuffers is the number of shared buffers a relation of the test
+// database occupies
+type RelationBuffers struct {
+	Relation string
+	Buffers  int64
+	Dirty    int64
+}
+
+// BufferCache returns the contents of the shared buffer cache for relations
+// of the test database, largest first. It creates the pg_buffercache
+// extension if necessary.
+func (p *PG) BufferCache(ctx context.Context) ([]RelationBuffers, error) {
+	if _, err := p.Pool.Exec(ctx, "CREATE EXTENSION IF NOT EXISTS pg_buffercache"); err != nil {
+		return nil, err
+	}
+
+	rows, err := p.Pool.Query(ctx, `
+		SELECT c.oid::regclass::text, count(*), count(*) FILTER (WHERE b.isdirty)
+		FROM pg_buffercache b
+		JOIN pg_class c ON b.relfilenode = pg_relation_filenode(c.oid)
+		WHERE b.reldatabase = (SELECT oid FROM pg_database WHERE datname = current_database())
+		GROUP BY c.oid
+		ORDER BY 2 DESC, 1`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var buffers []RelationBuffers
+	for rows.Next() {
+		var b RelationBuffers
+		if err := rows.Scan(&b.Relation, &b.Buffers, &b.Dirty); err != nil {
+			return nil, err
+		}
+		buffers = append(buffers, b)
+	}
+	return buffers, rows.Err()
+}
+
+// QueryBuffers executes the query under EXPLAIN (ANALYZE, BUFFERS) and returns
+// the number of shared blocks found in the cache and read from disk.
+//
+// A query is fully cached if read is zero.
+func (p *PG) QueryBuffers(ctx context.Context, query string, args ...any) (hit, read int64, err error) {
+	var out []byte
+	err = p.Pool.QueryRow(ctx, "EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON) "+query, args...).Scan(&out)
+	if err != nil {
+		return 0, 0, err
+	}
+	return parseBufferUsage(out)
+}
+
+func parseBufferUsage(plan []byte) (hit, read int64, err error) {
+	var explain []struct {
+		Plan struct {
+			SharedHitBlocks  int64 `json:"Shared Hit Blocks"`
+			SharedReadBlocks int64 `json:"Shared Read Blocks"`
+		}
+	}
+	if err := json.Unmarshal(plan, &explain); err != nil {
+		return 0, 0, err
+	}
+	if len(explain) == 0 {
+		return 0, 0, fmt.Errorf("empty plan")
+	}
+	return explain[0].Plan.SharedHitBlocks, explain[0].Plan.SharedReadBlocks, nil
+}
@@ -0,0 +1,60 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+)
+
+func TestQueryBuffers(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	if _, err := pg.Pool.Exec(ctx, "CREATE TABLE items AS SELECT generate_series(1, 10000) AS id"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	if _, _, err := pg.QueryBuffers(ctx, "SELECT count(*) FROM items"); err != nil {
+		t.Fatalf("failed to run query: %v", err)
+	}
+	hit, read, err := pg.QueryBuffers(ctx, "SELECT count(*) FROM items")
+	if err != nil {
+		t.Fatalf("failed to run query: %v", err)
+	}
+	if read != 0 || hit == 0 {
+		t.Errorf("expected second run to be fully cached, hit=%d read=%d", hit, read)
+	}
+
+	buffers, err := pg.BufferCache(ctx)
+	if err != nil {
+		t.Skipf("pg_buffercache is not available: %v", err)
+	}
+	found := false
+	for _, b := range buffers {
+		if b.Relation == "items" && b.Buffers > 0 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected items in buffer cache, got %+v", buffers)
+	}
+}
+
+func TestParseBufferUsage(t *testing.T) {
+	hit, read, err := parseBufferUsage([]byte(`[{"Plan": {"Node Type": "Aggregate", "Shared Hit Blocks": 45, "Shared Read Blocks": 3}}]`))
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	if hit != 45 || read != 3 {
+		t.Errorf("expected hit=45 read=3, got hit=%d read=%d", hit, read)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package pgxtest
+
+import (
+	"errors"
+)
+
+func withFileLock(path string, fn func() error) error {
+	return errors.New("shared instances are not supported on this platform")
+}
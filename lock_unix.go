@@ -0,0 +1,25 @@
+//go:build unix
+
+package pgxtest
+
+import (
+	"os"
+	"syscall"
+)
+
+// withFileLock runs fn holding an exclusive lock on the file, coordinating
+// with other processes
+func withFileLock(path string, fn func() error) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		return err
+	}
+	defer syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+
+	return fn()
+}
@@ -0,0 +1,136 @@
+package pgxtest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// Logical decoding requires the server to run with wal_level=logical, e.g.
+//
+//	pgxtest.Start(ctx, pgxtest.Config{Settings: map[string]string{"wal_level": "logical"}})
+
+// LogicalMessage is a message emitted with pg_logical_emit_message and read
+// back from a logical replication slot.
+type LogicalMessage struct {
+	LSN           string
+	Transactional bool
+	Prefix        string
+	Content       []byte
+}
+
+// EmitLogicalMessage writes a logical decoding message into WAL and returns
+// its LSN.
+//
+// Transactional messages are decoded only if the surrounding transaction
+// commits. Applications usually emit messages inside their own transactions,
+// this is a shortcut for tests.
+func (p *PG) EmitLogicalMessage(ctx context.Context, transactional bool, prefix string, content []byte) (string, error) {
+	var lsn string
+	err := p.Pool.QueryRow(ctx,
+		"SELECT pg_logical_emit_message($1, $2, $3::bytea)::text",
+		transactional, prefix, content,
+	).Scan(&lsn)
+	if err != nil {
+		return "", err
+	}
+	return lsn, nil
+}
+
+// CreateMessageSlot creates a logical replication slot suitable for
+// ReadLogicalMessages. It uses the test_decoding output plugin.
+func (p *PG) CreateMessageSlot(ctx context.Context, slot string) error {
+	_, err := p.Pool.Exec(ctx,
+		"SELECT pg_create_logical_replication_slot($1, 'test_decoding')",
+		slot,
+	)
+	return err
+}
+
+// ReadLogicalMessages consumes pending changes from the slot and returns the
+// logical messages with the given prefix. Empty prefix returns all messages.
+//
+// Other changes (row modifications, transaction boundaries) are consumed and
+// discarded.
+func (p *PG) ReadLogicalMessages(ctx context.Context, slot string, prefix string) ([]LogicalMessage, error) {
+	rows, err := p.Pool.Query(ctx,
+		"SELECT lsn::text, data FROM pg_logical_slot_get_binary_changes($1, NULL, NULL, 'include-xids', '0')",
+		slot,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []LogicalMessage
+	for rows.Next() {
+		var lsn string
+		var data []byte
+		if err := rows.Scan(&lsn, &data); err != nil {
+			return nil, err
+		}
+
+		msg, ok, err := parseTestDecodingMessage(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse logical message at %s: %w", lsn, err)
+		}
+		if !ok || (prefix != "" && msg.Prefix != prefix) {
+			continue
+		}
+		msg.LSN = lsn
+		messages = append(messages, msg)
+	}
+	return messages, rows.Err()
+}
+
+// Parses test_decoding representation of a message:
+//
+//	message: transactional: 1 prefix: outbox, sz: 5 content:hello
+func parseTestDecodingMessage(data []byte) (LogicalMessage, bool, error) {
+	const (
+		header        = "message: transactional: "
+		prefixMarker  = " prefix: "
+		sizeMarker    = ", sz: "
+		contentMarker = " content:"
+	)
+
+	if !bytes.HasPrefix(data, []byte(header)) {
+		return LogicalMessage{}, false, nil
+	}
+	rest := data[len(header):]
+	if len(rest) == 0 {
+		return LogicalMessage{}, false, fmt.Errorf("truncated message")
+	}
+
+	msg := LogicalMessage{Transactional: rest[0] == '1'}
+	rest = rest[1:]
+
+	if !bytes.HasPrefix(rest, []byte(prefixMarker)) {
+		return LogicalMessage{}, false, fmt.Errorf("missing prefix")
+	}
+	rest = rest[len(prefixMarker):]
+
+	i := bytes.Index(rest, []byte(sizeMarker))
+	if i < 0 {
+		return LogicalMessage{}, false, fmt.Errorf("missing size")
+	}
+	msg.Prefix = string(rest[:i])
+	rest = rest[i+len(sizeMarker):]
+
+	i = bytes.Index(rest, []byte(contentMarker))
+	if i < 0 {
+		return LogicalMessage{}, false, fmt.Errorf("missing content")
+	}
+	size, err := strconv.Atoi(string(rest[:i]))
+	if err != nil {
+		return LogicalMessage{}, false, fmt.Errorf("invalid size: %w", err)
+	}
+	rest = rest[i+len(contentMarker):]
+	if len(rest) < size {
+		return LogicalMessage{}, false, fmt.Errorf("content shorter than declared size %d", size)
+	}
+	msg.Content = rest[:size]
+
+	return msg, true, nil
+}
@@ -0,0 +1,54 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLogicalMessages(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{Settings: map[string]string{"wal_level": "logical"}})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	if err := pg.CreateMessageSlot(ctx, "outbox"); err != nil {
+		t.Fatalf("failed to create slot: %v", err)
+	}
+
+	if _, err := pg.EmitLogicalMessage(ctx, true, "outbox", []byte("hello")); err != nil {
+		t.Fatalf("failed to emit message: %v", err)
+	}
+	if _, err := pg.EmitLogicalMessage(ctx, false, "other", []byte("ignored")); err != nil {
+		t.Fatalf("failed to emit message: %v", err)
+	}
+
+	messages, err := pg.ReadLogicalMessages(ctx, "outbox", "outbox")
+	if err != nil {
+		t.Fatalf("failed to read messages: %v", err)
+	}
+	if len(messages) != 1 || string(messages[0].Content) != "hello" || !messages[0].Transactional {
+		t.Errorf("unexpected messages: %+v", messages)
+	}
+}
+
+func TestParseTestDecodingMessage(t *testing.T) {
+	msg, ok, err := parseTestDecodingMessage([]byte("message: transactional: 0 prefix: outbox, sz: 8 content:a, sz: b"))
+	if err != nil || !ok {
+		t.Fatalf("failed to parse message: ok=%v err=%v", ok, err)
+	}
+	if msg.Transactional || msg.Prefix != "outbox" || string(msg.Content) != "a, sz: b" {
+		t.Errorf("unexpected message: %+v", msg)
+	}
+
+	if _, ok, _ := parseTestDecodingMessage([]byte("BEGIN")); ok {
+		t.Errorf("BEGIN parsed as a message")
+	}
+}
@@ -0,0 +1,131 @@
+package pgxtest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Default number of workers of a Load
+const defaultLoadWorkers = 4
+
+// Load is application traffic run against the test database while
+// maintenance operates on it, see UnderLoad
+type Load struct {
+	Workers int // Concurrent writers, default 4
+
+	// Performs one write, e.g. an INSERT or UPDATE of the seeded table.
+	// Called repeatedly by every worker until the maintenance is done
+	Write func(ctx context.Context, pool *pgxpool.Pool) error
+}
+
+// LoadStats describes how the load fared during the maintenance
+type LoadStats struct {
+	Writes     int           // Successful writes
+	Failures   int           // Failed writes
+	FirstError error         // Error of the first failed write
+	MaxLatency time.Duration // Longest write, e.g. blocked by a lock taken by the maintenance
+}
+
+// UnderLoad runs the maintenance (e.g. Reindex, Cluster or Repack) while the
+// load writes to the test database, and reports how the writes fared. The
+// error is that of the maintenance, failures of the load are in the stats.
+func (p *PG) UnderLoad(ctx context.Context, load Load, maintenance func(ctx context.Context) error) (LoadStats, error) {
+	workers := load.Workers
+	if workers <= 0 {
+		workers = defaultLoadWorkers
+	}
+
+	loadCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu    sync.Mutex
+		stats LoadStats
+		wg    sync.WaitGroup
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for loadCtx.Err() == nil {
+				start := time.Now()
+				err := load.Write(loadCtx, p.Pool)
+				latency := time.Since(start)
+
+				mu.Lock()
+				// Writes interrupted by the end of the maintenance don't count
+				switch {
+				case err == nil:
+					stats.Writes++
+				case loadCtx.Err() == nil:
+					stats.Failures++
+					if stats.FirstError == nil {
+						stats.FirstError = err
+					}
+				}
+				stats.MaxLatency = max(stats.MaxLatency, latency)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	err := maintenance(ctx)
+	cancel()
+	wg.Wait()
+	return stats, err
+}
+
+// Reindex rebuilds the indexes of the table with REINDEX CONCURRENTLY, which
+// does not block writes
+func (p *PG) Reindex(ctx context.Context, table string) error {
+	if _, err := p.Pool.Exec(ctx, "REINDEX TABLE CONCURRENTLY "+pgx.Identifier(strings.Split(table, ".")).Sanitize()); err != nil {
+		return fmt.Errorf("Failed to reindex %s: %w", table, err)
+	}
+	return nil
+}
+
+// Cluster rewrites the table in the order of the index with CLUSTER, which
+// blocks reads and writes of the table while it runs
+func (p *PG) Cluster(ctx context.Context, table string, index string) error {
+	sql := "CLUSTER " + pgx.Identifier(strings.Split(table, ".")).Sanitize() + " USING " + pgx.Identifier{index}.Sanitize()
+	if _, err := p.Pool.Exec(ctx, sql); err != nil {
+		return fmt.Errorf("Failed to cluster %s: %w", table, err)
+	}
+	return nil
+}
+
+// Repack rewrites the table online with pg_repack, which must be installed
+// next to the server binaries. The pg_repack extension is created in the test
+// database if needed.
+func (p *PG) Repack(ctx context.Context, table string) error {
+	if p.binPath == "" {
+		return fmt.Errorf("pg_repack needs a server started by LocalBackend")
+	}
+	repack := filepath.Join(p.binPath, "pg_repack")
+	if _, err := os.Stat(repack); err != nil {
+		return fmt.Errorf("pg_repack is not installed: %w", err)
+	}
+	if _, err := p.Pool.Exec(ctx, "CREATE EXTENSION IF NOT EXISTS pg_repack"); err != nil {
+		return fmt.Errorf("Failed to create pg_repack extension: %w", addInstallHint(ctx, p.Pool, err))
+	}
+
+	cmd := exec.CommandContext(ctx, repack, "--no-order", "--table", table)
+	cmd.Env = append(os.Environ(), p.clientEnv()...)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("Failed to repack %s: %w\n%s", table, err, output.Bytes())
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestReindexUnderLoad(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	if _, err := pg.Pool.Exec(ctx, "CREATE TABLE events (id serial PRIMARY KEY, val int); CREATE INDEX ON events (val); INSERT INTO events (val) SELECT g FROM generate_series(1, 10000) g"); err != nil {
+		t.Fatalf("failed to seed table: %v", err)
+	}
+
+	stats, err := pg.UnderLoad(ctx, Load{
+		Write: func(ctx context.Context, pool *pgxpool.Pool) error {
+			_, err := pool.Exec(ctx, "INSERT INTO events (val) VALUES (1)")
+			return err
+		},
+	}, func(ctx context.Context) error {
+		return pg.Reindex(ctx, "public.events")
+	})
+	if err != nil {
+		t.Fatalf("failed to reindex: %v", err)
+	}
+	if stats.Failures != 0 {
+		t.Errorf("expected no failed writes, got %d: %v", stats.Failures, stats.FirstError)
+	}
+	if stats.Writes == 0 {
+		t.Errorf("expected writes during the reindex")
+	}
+}
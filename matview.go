@@ -0,0 +1,138 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateMaterializedView creates the materialized view name (optionally
+// schema-qualified) over query. Without withData it is left unpopulated until
+// refreshed.
+func (p *PG) CreateMaterializedView(ctx context.Context, name, query string, withData bool) error {
+	sql := fmt.Sprintf("CREATE MATERIALIZED VIEW %s AS %s", pgx.Identifier(strings.Split(name, ".")).Sanitize(), query)
+	if !withData {
+		sql += " WITH NO DATA"
+	}
+	if _, err := p.Pool.Exec(ctx, sql); err != nil {
+		return fmt.Errorf("Failed to create materialized view %s: %w", name, err)
+	}
+	return nil
+}
+
+// RefreshMaterializedView refreshes the materialized view. Refreshing
+// concurrently requires a unique index on the view, and the view to be
+// populated.
+func (p *PG) RefreshMaterializedView(ctx context.Context, name string, concurrently bool) error {
+	view, _, err := p.materializedView(ctx, name)
+	if err != nil {
+		return err
+	}
+	sql := "REFRESH MATERIALIZED VIEW "
+	if concurrently {
+		sql += "CONCURRENTLY "
+	}
+	if _, err := p.Pool.Exec(ctx, sql+view); err != nil {
+		return fmt.Errorf("Failed to refresh materialized view %s: %w", name, err)
+	}
+	return nil
+}
+
+// MaterializedViewStale reports whether the materialized view is unpopulated
+// or its contents differ from the current result of its query. The view must
+// not have columns of types without equality, such as json.
+func (p *PG) MaterializedViewStale(ctx context.Context, name string) (bool, error) {
+	view, definition, err := p.materializedView(ctx, name)
+	if err != nil {
+		return false, err
+	}
+	if definition == "" {
+		return true, nil
+	}
+
+	var stale bool
+	err = p.Pool.QueryRow(ctx, fmt.Sprintf(`
+		SELECT EXISTS (
+			SELECT FROM ((TABLE %[1]s EXCEPT ALL (%[2]s)) UNION ALL ((%[2]s) EXCEPT ALL TABLE %[1]s)) diff
+		)`, view, definition)).Scan(&stale)
+	if err != nil {
+		return false, fmt.Errorf("Failed to compare materialized view %s: %w", name, err)
+	}
+	return stale, nil
+}
+
+// WaitMaterializedViewFresh waits until the materialized view is not stale,
+// e.g. refreshed by a background job of the code under test, or fails the
+// test once timeout expires
+func (p *PG) WaitMaterializedViewFresh(ctx context.Context, t testing.TB, timeout time.Duration, name string) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		stale, err := p.MaterializedViewStale(ctx, name)
+		if err == nil && !stale {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			if err != nil {
+				t.Fatalf("materialized view %s not fresh within %s: %v", name, timeout, err)
+			}
+			t.Fatalf("materialized view %s not fresh within %s", name, timeout)
+			return
+		case <-time.After(eventuallyInterval):
+		}
+	}
+}
+
+// materializedView returns the quoted name of the materialized view and its
+// query, or an empty query if the view is not populated
+func (p *PG) materializedView(ctx context.Context, name string) (string, string, error) {
+	var (
+		view, definition string
+		populated        bool
+	)
+	err := p.Pool.QueryRow(ctx, `
+		SELECT c.oid::regclass::text, pg_get_viewdef(c.oid), c.relispopulated
+		FROM pg_class c
+		WHERE c.oid = to_regclass($1) AND c.relkind = 'm'`, name).Scan(&view, &definition, &populated)
+	if err == pgx.ErrNoRows {
+		return "", "", fmt.Errorf("Materialized view %s not found", name)
+	}
+	if err != nil {
+		return "", "", err
+	}
+	if !populated {
+		definition = ""
+	}
+	return view, strings.TrimSuffix(strings.TrimSpace(definition), ";"), nil
+}
+
+// CreateIMMV creates an incrementally maintained materialized view with
+// pg_ivm, see Config.IVM. Unlike materialized views, it is kept up to date by
+// triggers as the underlying tables change.
+func (p *PG) CreateIMMV(ctx context.Context, name, query string) error {
+	var schema string
+	err := p.Pool.QueryRow(ctx, `
+		SELECT n.nspname FROM pg_extension e JOIN pg_namespace n ON n.oid = e.extnamespace
+		WHERE e.extname = 'pg_ivm'`).Scan(&schema)
+	if err == pgx.ErrNoRows {
+		return fmt.Errorf("pg_ivm is not installed in the test database, see Config.IVM")
+	}
+	if err != nil {
+		return err
+	}
+
+	createIMMV := pgx.Identifier{schema, "create_immv"}.Sanitize()
+	if _, err := p.Pool.Exec(ctx, "SELECT "+createIMMV+"($1, $2)", name, query); err != nil {
+		return fmt.Errorf("Failed to create IMMV %s: %w", name, err)
+	}
+	return nil
+}
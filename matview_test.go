@@ -0,0 +1,58 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMaterializedView(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg := StartT(t, Config{})
+
+	if _, err := pg.Pool.Exec(ctx, "CREATE TABLE orders (id int PRIMARY KEY, total int)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	if err := pg.CreateMaterializedView(ctx, "order_totals", "SELECT count(*) AS n, sum(total) AS total FROM orders", false); err != nil {
+		t.Fatalf("failed to create materialized view: %v", err)
+	}
+
+	stale, err := pg.MaterializedViewStale(ctx, "order_totals")
+	if err != nil {
+		t.Fatalf("failed to check staleness: %v", err)
+	}
+	if !stale {
+		t.Errorf("expected unpopulated view to be stale")
+	}
+
+	if err := pg.RefreshMaterializedView(ctx, "order_totals", false); err != nil {
+		t.Fatalf("failed to refresh: %v", err)
+	}
+	pg.WaitMaterializedViewFresh(ctx, t, time.Second, "order_totals")
+
+	if _, err := pg.Pool.Exec(ctx, "INSERT INTO orders VALUES (1, 10)"); err != nil {
+		t.Fatalf("failed to insert order: %v", err)
+	}
+	if stale, err := pg.MaterializedViewStale(ctx, "order_totals"); err != nil || !stale {
+		t.Errorf("expected view to be stale after insert, got %v, %v", stale, err)
+	}
+
+	if err := pg.RefreshMaterializedView(ctx, "order_totals", true); err == nil {
+		t.Errorf("expected concurrent refresh without unique index to fail")
+	}
+	if _, err := pg.Pool.Exec(ctx, "CREATE UNIQUE INDEX ON order_totals (n)"); err != nil {
+		t.Fatalf("failed to create index: %v", err)
+	}
+	if err := pg.RefreshMaterializedView(ctx, "order_totals", true); err != nil {
+		t.Fatalf("failed to refresh concurrently: %v", err)
+	}
+	if stale, err := pg.MaterializedViewStale(ctx, "order_totals"); err != nil || stale {
+		t.Errorf("expected view to be fresh after refresh, got %v, %v", stale, err)
+	}
+
+	if _, err := pg.MaterializedViewStale(ctx, "orders"); err == nil {
+		t.Errorf("expected error for a table")
+	}
+}
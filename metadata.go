@@ -0,0 +1,75 @@
+package pgxtest
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Name of the file in the instance directory describing the instance
+const metadataFile = "pgxtest.json"
+
+// Longest application_name PostgreSQL keeps (NAMEDATALEN - 1)
+const maxApplicationName = 63
+
+// instanceMetadata is stored in the instance directory so that leftover
+// instances can be attributed to the tests that started them.
+type instanceMetadata struct {
+	PID               int               `json:"pid"`
+	OwnerPID          int               `json:"owner_pid"` // Process that has started the instance, see CleanupOrphans
+	Created           time.Time         `json:"created"`
+	DataDir           string            `json:"data_dir"`
+	SocketDir         string            `json:"socket_dir"`
+	TempTablespaceDir string            `json:"temp_tablespace_dir,omitempty"`
+	WALDir            string            `json:"wal_dir,omitempty"`
+	Labels            map[string]string `json:"labels,omitempty"`
+}
+
+func writeMetadata(dir string, md instanceMetadata) error {
+	data, err := json.MarshalIndent(md, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, metadataFile), data, 0644)
+}
+
+func readMetadata(dir string) (instanceMetadata, error) {
+	var md instanceMetadata
+	data, err := os.ReadFile(filepath.Join(dir, metadataFile))
+	if err != nil {
+		return md, err
+	}
+	err = json.Unmarshal(data, &md)
+	return md, err
+}
+
+// applicationName builds application_name from the labels, so that sessions
+// are attributable in pg_stat_activity and server logs.
+func applicationName(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	name := "pgxtest"
+	for _, k := range keys {
+		name += " " + k + "=" + labels[k]
+	}
+
+	// application_name only allows printable ASCII
+	name = strings.Map(func(r rune) rune {
+		if r < 32 || r > 126 {
+			return '?'
+		}
+		return r
+	}, name)
+
+	if len(name) > maxApplicationName {
+		name = name[:maxApplicationName]
+	}
+	return name
+}
@@ -0,0 +1,48 @@
+package pgxtest
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestLabels(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{Labels: map[string]string{"test": t.Name()}})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	md, err := readMetadata(pg.dir)
+	if err != nil {
+		t.Fatalf("failed to read metadata: %v", err)
+	}
+	if md.Labels["test"] != t.Name() || md.PID == 0 {
+		t.Errorf("unexpected metadata: %+v", md)
+	}
+
+	var name string
+	if err := pg.Pool.QueryRow(ctx, "SHOW application_name").Scan(&name); err != nil {
+		t.Fatalf("failed to get application_name: %v", err)
+	}
+	if name != "pgxtest test=TestLabels" {
+		t.Errorf("unexpected application_name %q", name)
+	}
+}
+
+func TestApplicationName(t *testing.T) {
+	name := applicationName(map[string]string{"b": "2", "a": "ünï", "long": strings.Repeat("x", 100)})
+	if !strings.HasPrefix(name, "pgxtest a=?n? b=2 long=xxx") {
+		t.Errorf("unexpected application_name %q", name)
+	}
+	if len(name) != maxApplicationName {
+		t.Errorf("expected application_name to be truncated, got %d bytes", len(name))
+	}
+}
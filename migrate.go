@@ -0,0 +1,124 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// A migration file of golang-migrate or goose
+type migrationFile struct {
+	version uint64
+	name    string
+}
+
+// GolangMigrateFS returns a Config.Migrate function applying the up migrations
+// of golang-migrate in the root of fsys (VERSION_TITLE.up.sql files), in the
+// order of versions.
+//
+// The migrations are executed directly and the schema_migrations table is not
+// maintained.
+func GolangMigrateFS(fsys fs.FS) func(ctx context.Context, pool *pgxpool.Pool) error {
+	return func(ctx context.Context, pool *pgxpool.Pool) error {
+		files, err := migrationFiles(fsys, ".up.sql")
+		if err != nil {
+			return err
+		}
+		return applyMigrations(ctx, pool, fsys, files, func(sql string) string { return sql })
+	}
+}
+
+// GooseFS returns a Config.Migrate function applying the Up sections of goose
+// SQL migrations in the root of fsys (VERSION_NAME.sql files), in the order of
+// versions.
+//
+// Each Up section is executed as a whole, so statements that can't run in a
+// transaction block such as CREATE INDEX CONCURRENTLY are not supported. The
+// goose_db_version table is not maintained.
+func GooseFS(fsys fs.FS) func(ctx context.Context, pool *pgxpool.Pool) error {
+	return func(ctx context.Context, pool *pgxpool.Pool) error {
+		files, err := migrationFiles(fsys, ".sql")
+		if err != nil {
+			return err
+		}
+		return applyMigrations(ctx, pool, fsys, files, gooseUpSection)
+	}
+}
+
+// migrationFiles returns the files with the suffix named VERSION_..., ordered
+// by version
+func migrationFiles(fsys fs.FS, suffix string) ([]migrationFile, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []migrationFile
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, suffix) || strings.HasSuffix(name, ".down.sql") {
+			continue
+		}
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse version of migration %s: %w", name, err)
+		}
+		files = append(files, migrationFile{version: version, name: name})
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].version < files[j].version })
+	for i := 1; i < len(files); i++ {
+		if files[i].version == files[i-1].version {
+			return nil, fmt.Errorf("Duplicate migration version %d: %s and %s", files[i].version, files[i-1].name, files[i].name)
+		}
+	}
+	return files, nil
+}
+
+func applyMigrations(ctx context.Context, pool *pgxpool.Pool, fsys fs.FS, files []migrationFile, extract func(string) string) error {
+	for _, f := range files {
+		data, err := fs.ReadFile(fsys, f.name)
+		if err != nil {
+			return err
+		}
+		sql := extract(string(data))
+		if strings.TrimSpace(sql) == "" {
+			continue
+		}
+		// Without arguments the simple protocol is used, which allows several
+		// statements per migration
+		if _, err := pool.Exec(ctx, sql); err != nil {
+			return fmt.Errorf("Failed to apply migration %s: %w", f.name, err)
+		}
+	}
+	return nil
+}
+
+// gooseUpSection returns the part of a goose migration between the
+// "-- +goose Up" and "-- +goose Down" annotations
+func gooseUpSection(sql string) string {
+	var b strings.Builder
+	up := false
+	for _, line := range strings.SplitAfter(sql, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "-- +goose") {
+			switch strings.TrimSpace(strings.TrimPrefix(trimmed, "-- +goose")) {
+			case "Up":
+				up = true
+			case "Down":
+				up = false
+			}
+			continue
+		}
+		if up {
+			b.WriteString(line)
+		}
+	}
+	return b.String()
+}
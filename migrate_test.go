@@ -0,0 +1,91 @@
+package pgxtest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMigrateGolangMigrate(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"2_add_val.up.sql":       {Data: []byte("ALTER TABLE test ADD val text; INSERT INTO test VALUES (1, 'migrated')")},
+		"2_add_val.down.sql":     {Data: []byte("ALTER TABLE test DROP val")},
+		"1_create_test.up.sql":   {Data: []byte("CREATE TABLE test (id int)")},
+		"1_create_test.down.sql": {Data: []byte("DROP TABLE test")},
+	}
+
+	pg, err := Start(ctx, Config{Migrate: GolangMigrateFS(fsys)})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	var val string
+	if err := pg.Pool.QueryRow(ctx, "SELECT val FROM test").Scan(&val); err != nil {
+		t.Fatalf("failed to query migrated table: %v", err)
+	}
+	if val != "migrated" {
+		t.Errorf("expected %q, got %q", "migrated", val)
+	}
+}
+
+func TestMigrateFailure(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"1_broken.sql": {Data: []byte("-- +goose Up\nCREATE TABLE (\n")},
+	}
+
+	pg, err := Start(ctx, Config{Migrate: GooseFS(fsys)})
+	if err == nil {
+		pg.Stop()
+		t.Fatalf("expected start to fail")
+	}
+	if !strings.Contains(err.Error(), "1_broken.sql") {
+		t.Errorf("expected error to name the migration, got %v", err)
+	}
+}
+
+func TestMigrationFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"20240102_b.sql": {},
+		"20240101_a.sql": {},
+		"README.md":      {},
+	}
+	files, err := migrationFiles(fsys, ".sql")
+	if err != nil {
+		t.Fatalf("failed to list migrations: %v", err)
+	}
+	if len(files) != 2 || files[0].name != "20240101_a.sql" || files[1].name != "20240102_b.sql" {
+		t.Errorf("unexpected migrations %+v", files)
+	}
+
+	fsys["20240101_c.sql"] = &fstest.MapFile{}
+	if _, err := migrationFiles(fsys, ".sql"); err == nil {
+		t.Errorf("expected duplicate versions to fail")
+	}
+}
+
+func TestGooseUpSection(t *testing.T) {
+	sql := `-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE test (id int);
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE test;
+`
+	up := gooseUpSection(sql)
+	if strings.TrimSpace(up) != "CREATE TABLE test (id int);" {
+		t.Errorf("unexpected Up section %q", up)
+	}
+}
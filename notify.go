@@ -0,0 +1,204 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Notifications received by a Listener and not yet consumed by Expect
+const listenerBuffer = 1024
+
+// Notify sends a notification to the channel, as done by code that
+// invalidates caches of other processes after a change
+func (p *PG) Notify(ctx context.Context, channel, payload string) error {
+	if _, err := p.Pool.Exec(ctx, "SELECT pg_notify($1, $2)", channel, payload); err != nil {
+		return fmt.Errorf("Failed to notify %s: %w", channel, err)
+	}
+	return nil
+}
+
+// TerminateListeners terminates the sessions listening on the channel,
+// simulating a dropped connection of a cache invalidation subscriber under
+// test. It returns the number of terminated sessions.
+//
+// PostgreSQL does not expose the channels of other sessions, so listeners are
+// recognized by their last statement being LISTEN channel. Sessions that have
+// run other statements since are not found.
+func (p *PG) TerminateListeners(ctx context.Context, channel string) (int, error) {
+	listen := "LISTEN " + pgx.Identifier{channel}.Sanitize()
+	rows, err := p.Pool.Query(ctx, `
+		SELECT pg_terminate_backend(pid) FROM pg_stat_activity
+		WHERE datname = current_database() AND pid <> pg_backend_pid()
+		  AND state = 'idle' AND (lower(query) = lower($1) OR lower(query) = lower($2))`,
+		listen, "LISTEN "+channel)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to terminate listeners of %s: %w", channel, err)
+	}
+	terminated, err := pgx.CollectRows(rows, pgx.RowTo[bool])
+	if err != nil {
+		return 0, fmt.Errorf("Failed to terminate listeners of %s: %w", channel, err)
+	}
+	return len(terminated), nil
+}
+
+// Listener is a subscriber harness: it listens on a channel of the test
+// database on its own connection and records the notifications, to assert
+// what the code under test publishes.
+type Listener struct {
+	Channel string
+
+	connConfig    *pgx.ConnConfig
+	notifications chan *pgconn.Notification
+
+	mu     sync.Mutex
+	conn   *pgx.Conn
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Listen subscribes to the channel. The Listener is closed in t.Cleanup.
+func (p *PG) Listen(ctx context.Context, t testing.TB, channel string) *Listener {
+	t.Helper()
+
+	conf, err := testPoolConfig(p.Host, p.Port, p.Name, p.config)
+	if err != nil {
+		t.Fatalf("failed to configure listener: %v", err)
+	}
+
+	l := &Listener{
+		Channel:       channel,
+		connConfig:    conf.ConnConfig,
+		notifications: make(chan *pgconn.Notification, listenerBuffer),
+	}
+	if err := l.Reconnect(ctx); err != nil {
+		t.Fatalf("failed to listen on %s: %v", channel, err)
+	}
+	t.Cleanup(l.Close)
+	return l
+}
+
+// Reconnect connects the Listener again after Drop. Notifications sent while
+// it was disconnected are lost, as they are for a real subscriber.
+func (l *Listener) Reconnect(ctx context.Context) error {
+	l.Close()
+
+	conn, err := pgx.ConnectConfig(ctx, l.connConfig)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{l.Channel}.Sanitize()); err != nil {
+		conn.Close(ctx)
+		return err
+	}
+
+	waitCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go l.receive(waitCtx, conn, done)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.conn, l.cancel, l.done = conn, cancel, done
+	return nil
+}
+
+func (l *Listener) receive(ctx context.Context, conn *pgx.Conn, done chan struct{}) {
+	defer close(done)
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return
+		}
+		select {
+		case l.notifications <- n:
+		default:
+			// Nobody is consuming notifications, drop them like an overloaded
+			// subscriber would
+		}
+	}
+}
+
+// Drop terminates the connection of the Listener on the server side.
+// Notifications are not received until Reconnect.
+func (l *Listener) Drop(ctx context.Context) error {
+	l.mu.Lock()
+	conn, done := l.conn, l.done
+	l.mu.Unlock()
+	if conn == nil {
+		return nil
+	}
+
+	killer, err := pgx.ConnectConfig(ctx, l.connConfig)
+	if err != nil {
+		return err
+	}
+	defer killer.Close(ctx)
+	if _, err := killer.Exec(ctx, "SELECT pg_terminate_backend($1)", conn.PgConn().PID()); err != nil {
+		return fmt.Errorf("Failed to terminate listener: %w", err)
+	}
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	l.Close()
+	return nil
+}
+
+// Expect waits for a notification with the payload, failing the test if none
+// arrives within timeout. Notifications received before it are consumed.
+func (l *Listener) Expect(t testing.TB, timeout time.Duration, payload string) {
+	t.Helper()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var seen []string
+	for {
+		select {
+		case n := <-l.notifications:
+			if n.Payload == payload {
+				return
+			}
+			seen = append(seen, n.Payload)
+		case <-timer.C:
+			t.Fatalf("no notification %q on %s within %v, received: [%s]", payload, l.Channel, timeout, strings.Join(seen, ", "))
+		}
+	}
+}
+
+// ExpectNone fails the test if a notification arrives within wait
+func (l *Listener) ExpectNone(t testing.TB, wait time.Duration) {
+	t.Helper()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case n := <-l.notifications:
+		t.Fatalf("unexpected notification %q on %s", n.Payload, l.Channel)
+	case <-timer.C:
+	}
+}
+
+// Close stops listening
+func (l *Listener) Close() {
+	l.mu.Lock()
+	conn, cancel, done := l.conn, l.cancel, l.done
+	l.conn, l.cancel, l.done = nil, nil, nil
+	l.mu.Unlock()
+
+	if conn == nil {
+		return
+	}
+	cancel()
+	<-done
+	conn.Close(context.Background())
+}
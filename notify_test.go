@@ -0,0 +1,60 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestListener(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg := StartT(t, Config{})
+
+	l := pg.Listen(ctx, t, "cache_invalidation")
+	if err := pg.Notify(ctx, "cache_invalidation", "users:1"); err != nil {
+		t.Fatalf("failed to notify: %v", err)
+	}
+	l.Expect(t, 5*time.Second, "users:1")
+
+	if err := l.Drop(ctx); err != nil {
+		t.Fatalf("failed to drop listener: %v", err)
+	}
+	if err := pg.Notify(ctx, "cache_invalidation", "users:2"); err != nil {
+		t.Fatalf("failed to notify: %v", err)
+	}
+	if err := l.Reconnect(ctx); err != nil {
+		t.Fatalf("failed to reconnect listener: %v", err)
+	}
+	l.ExpectNone(t, 100*time.Millisecond)
+}
+
+func TestTerminateListeners(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg := StartT(t, Config{})
+
+	conn, err := pgx.Connect(ctx, pg.URL())
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close(ctx)
+	if _, err := conn.Exec(ctx, "LISTEN cache_invalidation"); err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	n, err := pg.TerminateListeners(ctx, "cache_invalidation")
+	if err != nil {
+		t.Fatalf("failed to terminate listeners: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 terminated listener, got %d", n)
+	}
+	if err := conn.Ping(ctx); err == nil {
+		t.Errorf("expected listener connection to be terminated")
+	}
+}
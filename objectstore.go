@@ -0,0 +1,269 @@
+package pgxtest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ObjectStore is a local stand-in for S3-compatible object storage, for
+// testing backup tooling offline. Objects are files in Dir/BUCKET/KEY, served
+// over the basic S3 API at URL with path-style addressing: buckets are
+// created and listed, objects are put, got, listed and deleted. Requests are
+// not authenticated, any credentials work.
+//
+// Set it as Config.WALArchive to archive WAL of an instance to it.
+type ObjectStore struct {
+	Dir string // Directory holding the buckets
+	URL string // Endpoint of the S3 API, e.g. for AWS_ENDPOINT_URL
+
+	server *httptest.Server
+	temp   bool
+}
+
+// NewObjectStore starts an object store on dir, or on a temporary directory
+// removed by Close if dir is empty
+func NewObjectStore(dir string) (*ObjectStore, error) {
+	s := &ObjectStore{Dir: dir}
+	if dir == "" {
+		d, err := os.MkdirTemp("", "objects-pgxtest")
+		if err != nil {
+			return nil, err
+		}
+		s.Dir, s.temp = d, true
+	}
+
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.server.URL
+	return s, nil
+}
+
+// Close stops the server
+func (s *ObjectStore) Close() {
+	s.server.Close()
+	if s.temp {
+		os.RemoveAll(s.Dir)
+	}
+}
+
+// Keys returns the keys of the objects in the bucket starting with prefix,
+// sorted
+func (s *ObjectStore) Keys(bucket, prefix string) ([]string, error) {
+	root := filepath.Join(s.Dir, bucket)
+	var keys []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload") {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	sort.Strings(keys)
+	return keys, err
+}
+
+func (s *ObjectStore) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket == "" || !filepath.IsLocal(bucket) || (key != "" && !filepath.IsLocal(key)) {
+		s3Error(w, http.StatusBadRequest, "InvalidRequest")
+		return
+	}
+	dir := filepath.Join(s.Dir, bucket)
+	file := filepath.Join(dir, filepath.FromSlash(key))
+
+	switch {
+	case key == "" && r.Method == http.MethodPut:
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			s3Error(w, http.StatusInternalServerError, "InternalError")
+		}
+	case key == "" && r.Method == http.MethodHead:
+		if _, err := os.Stat(dir); err != nil {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case key == "" && r.Method == http.MethodGet:
+		s.list(w, r, bucket)
+	case key == "" && r.Method == http.MethodDelete:
+		if err := os.Remove(dir); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			s3Error(w, http.StatusConflict, "BucketNotEmpty")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		if err := putObject(file, r); err != nil {
+			s3Error(w, http.StatusInternalServerError, "InternalError")
+		}
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		f, err := os.Open(file)
+		if err != nil {
+			s3Error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		defer f.Close()
+		fi, err := f.Stat()
+		if err != nil || fi.IsDir() {
+			s3Error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		http.ServeContent(w, r, "", fi.ModTime(), f)
+	case r.Method == http.MethodDelete:
+		if err := os.Remove(file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			s3Error(w, http.StatusInternalServerError, "InternalError")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		s3Error(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+// putObject stores the body of the request to file, atomically
+func putObject(file string, r *http.Request) error {
+	body := io.Reader(r.Body)
+	// SDKs sign streamed uploads chunk by chunk
+	if strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		data, err := decodeAWSChunked(r.Body)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".upload")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// decodeAWSChunked decodes the aws-chunked encoding: chunks of
+// "SIZE;chunk-signature=...\r\nDATA\r\n" ending with a zero-sized one
+func decodeAWSChunked(r io.Reader) ([]byte, error) {
+	br := bufio.NewReader(r)
+	var out bytes.Buffer
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		n, err := strconv.ParseInt(size, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return out.Bytes(), nil
+		}
+		if _, err := io.CopyN(&out, br, n); err != nil {
+			return nil, err
+		}
+		if _, err := br.ReadString('\n'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+type s3Object struct {
+	Key          string
+	Size         int64
+	LastModified string
+}
+
+type s3Prefix struct {
+	Prefix string
+}
+
+type s3ListResult struct {
+	XMLName        xml.Name   `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListBucketResult"`
+	Name           string     `xml:"Name"`
+	Prefix         string     `xml:"Prefix"`
+	Delimiter      string     `xml:"Delimiter,omitempty"`
+	KeyCount       int        `xml:"KeyCount"`
+	IsTruncated    bool       `xml:"IsTruncated"`
+	Contents       []s3Object `xml:"Contents"`
+	CommonPrefixes []s3Prefix `xml:"CommonPrefixes"`
+}
+
+// list answers ListObjects and ListObjectsV2, without pagination
+func (s *ObjectStore) list(w http.ResponseWriter, r *http.Request, bucket string) {
+	if _, err := os.Stat(filepath.Join(s.Dir, bucket)); err != nil {
+		s3Error(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	prefix, delimiter := r.URL.Query().Get("prefix"), r.URL.Query().Get("delimiter")
+	keys, err := s.Keys(bucket, prefix)
+	if err != nil {
+		s3Error(w, http.StatusInternalServerError, "InternalError")
+		return
+	}
+
+	result := s3ListResult{Name: bucket, Prefix: prefix, Delimiter: delimiter}
+	seen := map[string]bool{}
+	for _, key := range keys {
+		if delimiter != "" {
+			if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+				common := key[:len(prefix)+i+len(delimiter)]
+				if !seen[common] {
+					seen[common] = true
+					result.CommonPrefixes = append(result.CommonPrefixes, s3Prefix{Prefix: common})
+				}
+				continue
+			}
+		}
+		fi, err := os.Stat(filepath.Join(s.Dir, bucket, filepath.FromSlash(key)))
+		if err != nil {
+			continue
+		}
+		result.Contents = append(result.Contents, s3Object{
+			Key:          path.Clean(key),
+			Size:         fi.Size(),
+			LastModified: fi.ModTime().UTC().Format(time.RFC3339),
+		})
+	}
+	result.KeyCount = len(result.Contents) + len(result.CommonPrefixes)
+
+	w.Header().Set("Content-Type", "application/xml")
+	_, _ = w.Write([]byte(xml.Header))
+	_ = xml.NewEncoder(w).Encode(result)
+}
+
+func s3Error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, xml.Header+"<Error><Code>"+code+"</Code></Error>")
+}
@@ -0,0 +1,74 @@
+package pgxtest
+
+import (
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestObjectStore(t *testing.T) {
+	store, err := NewObjectStore("")
+	if err != nil {
+		t.Fatalf("failed to start object store: %v", err)
+	}
+	defer store.Close()
+
+	do := func(method, path, body string, header http.Header) (int, string) {
+		t.Helper()
+		req, err := http.NewRequest(method, store.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to %s %s: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response: %v", err)
+		}
+		return resp.StatusCode, string(data)
+	}
+
+	if status, _ := do("PUT", "/backups", "", nil); status != http.StatusOK {
+		t.Fatalf("expected bucket to be created, got status %d", status)
+	}
+	do("PUT", "/backups/base/1/data.tar", "tarball", nil)
+	do("PUT", "/backups/wal/000000010000000000000001", "wal", nil)
+	do("PUT", "/backups/wal/000000010000000000000002", "3;chunk-signature=ab\r\nwal\r\n0;chunk-signature=cd\r\n\r\n",
+		http.Header{"X-Amz-Content-Sha256": {"STREAMING-AWS4-HMAC-SHA256-PAYLOAD"}})
+
+	if status, body := do("GET", "/backups/base/1/data.tar", "", nil); status != http.StatusOK || body != "tarball" {
+		t.Errorf("expected tarball, got %d %q", status, body)
+	}
+	if status, body := do("GET", "/backups/wal/000000010000000000000002", "", nil); status != http.StatusOK || body != "wal" {
+		t.Errorf("expected decoded chunked upload, got %d %q", status, body)
+	}
+	if status, _ := do("GET", "/backups/missing", "", nil); status != http.StatusNotFound {
+		t.Errorf("expected missing object to be not found, got %d", status)
+	}
+	if status, _ := do("GET", "/backups/../escape", "", nil); status == http.StatusOK {
+		t.Errorf("expected escaping key to be refused")
+	}
+
+	_, list := do("GET", "/backups?list-type=2&delimiter=/", "", nil)
+	if !strings.Contains(list, "<Prefix>base/</Prefix>") || !strings.Contains(list, "<Prefix>wal/</Prefix>") {
+		t.Errorf("expected common prefixes in listing, got %s", list)
+	}
+
+	do("DELETE", "/backups/base/1/data.tar", "", nil)
+	keys, err := store.Keys("backups", "")
+	if err != nil {
+		t.Fatalf("failed to list keys: %v", err)
+	}
+	expected := []string{"wal/000000010000000000000001", "wal/000000010000000000000002"}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expected keys %v, got %v", expected, keys)
+	}
+}
@@ -0,0 +1,105 @@
+package pgxtest
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Time the server of an orphaned instance is given to exit after an
+// immediate shutdown request, before it is killed
+const orphanKillTimeout = 10 * time.Second
+
+// CleanupOrphans kills servers and removes directories of instances left
+// behind by test processes that have exited without calling Stop, e.g. after
+// a crash, os.Exit or a timeout. It returns the removed instance directories.
+//
+// Only temporary instances (those without Config.Dir) are cleaned up, shared
+// instances are kept. Directories are recognized as instances by their
+// metadata, written when the instance directory is set up, others are never
+// removed. Call it at the start of a CI job or in TestMain.
+func CleanupOrphans() ([]string, error) {
+	dirs, err := filepath.Glob(filepath.Join(os.TempDir(), "pgxtest*"))
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	var errs []error
+	for _, dir := range dirs {
+		orphan, err := cleanupOrphan(dir)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if orphan {
+			removed = append(removed, dir)
+		}
+	}
+	return removed, errors.Join(errs...)
+}
+
+// cleanupOrphan removes the instance directory if the process owning it has
+// exited, killing its server, and reports whether it was removed
+func cleanupOrphan(dir string) (bool, error) {
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return false, nil
+	}
+
+	md, err := readMetadata(dir)
+	if err != nil {
+		// Not an instance directory, e.g. a temporary tablespace or socket
+		// directory of an instance, or one of another program
+		return false, nil
+	}
+	// Instances written before owners were recorded have no OwnerPID
+	if md.OwnerPID == 0 || processAlive(md.OwnerPID) {
+		return false, nil
+	}
+
+	if err := killOrphanServer(md); err != nil {
+		return false, err
+	}
+	removeDirs(dir, md.TempTablespaceDir, md.WALDir, md.SocketDir)
+	return true, nil
+}
+
+// killOrphanServer kills the server of the instance if it is still running.
+// The PID is checked against postmaster.pid, so that an unrelated process
+// that got the PID is left alone.
+func killOrphanServer(md instanceMetadata) error {
+	data, err := os.ReadFile(filepath.Join(md.DataDir, "postmaster.pid"))
+	if err != nil {
+		// The server has exited
+		return nil
+	}
+	line, _, _ := bytes.Cut(data, []byte("\n"))
+	if pid, err := strconv.Atoi(strings.TrimSpace(string(line))); err != nil || pid != md.PID {
+		return nil
+	}
+	if !processAlive(md.PID) {
+		return nil
+	}
+
+	proc, err := os.FindProcess(md.PID)
+	if err != nil {
+		return err
+	}
+	// Unlike SIGKILL, immediate shutdown stops the backends as well
+	if err := proc.Signal(shutdownSignal(ShutdownImmediate)); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return err
+	}
+	// The server is not a child of this process, so it can't be waited for
+	deadline := time.Now().Add(orphanKillTimeout)
+	for processAlive(md.PID) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if processAlive(md.PID) {
+		return proc.Kill()
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package pgxtest
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCleanupOrphans(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	exited := exec.Command("true")
+	if err := exited.Run(); err != nil {
+		t.Skipf("failed to run a process: %v", err)
+	}
+
+	instance := func(name string, md *instanceMetadata, age time.Duration) string {
+		dir := filepath.Join(tmp, name)
+		if err := os.MkdirAll(filepath.Join(dir, "data"), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if md != nil {
+			md.DataDir = filepath.Join(dir, "data")
+			if err := writeMetadata(dir, *md); err != nil {
+				t.Fatal(err)
+			}
+		}
+		mtime := time.Now().Add(-age)
+		if err := os.Chtimes(dir, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+		return dir
+	}
+
+	orphan := instance("pgxtest1", &instanceMetadata{PID: exited.Process.Pid, OwnerPID: exited.Process.Pid}, 0)
+	running := instance("pgxtest2", &instanceMetadata{PID: exited.Process.Pid, OwnerPID: os.Getpid()}, 0)
+	// Interrupted before the server was started
+	abandoned := instance("pgxtest3", &instanceMetadata{OwnerPID: exited.Process.Pid}, 0)
+	// Temporary tablespace and object store directories have no metadata
+	tablespace := instance("pgxtest4", nil, 48*time.Hour)
+	objects := instance("objects-pgxtest5", nil, 48*time.Hour)
+	unrelated := instance("other", nil, 48*time.Hour)
+
+	removed, err := CleanupOrphans()
+	if err != nil {
+		t.Fatalf("failed to clean up orphans: %v", err)
+	}
+	slices.Sort(removed)
+	if want := []string{orphan, abandoned}; !slices.Equal(removed, want) {
+		t.Errorf("expected %q to be removed, got %q", want, removed)
+	}
+	for _, dir := range []string{running, tablespace, objects, unrelated} {
+		if _, err := os.Stat(dir); err != nil {
+			t.Errorf("expected %s to be kept: %v", dir, err)
+		}
+	}
+	for _, dir := range []string{orphan, abandoned} {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed: %v", dir, err)
+		}
+	}
+}
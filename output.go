@@ -0,0 +1,143 @@
+package pgxtest
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Default limit of captured output per stream
+const defaultOutputLimit = 1 << 20
+
+// Output is the captured output of a process. If the process has written more
+// than Config.OutputLimit bytes to a stream, only the last bytes are kept.
+type Output struct {
+	Stdout []byte
+	Stderr []byte
+}
+
+// ringBuffer is an io.Writer keeping the last limit bytes written to it
+type ringBuffer struct {
+	mu    sync.Mutex
+	buf   []byte
+	limit int
+	start int  // Position of the oldest byte once the buffer is full
+	full  bool // The buffer has wrapped around at least once
+}
+
+func newRingBuffer(limit int) *ringBuffer {
+	if limit <= 0 {
+		limit = defaultOutputLimit
+	}
+	return &ringBuffer{limit: limit}
+}
+
+func (r *ringBuffer) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := len(p)
+	if len(p) > r.limit {
+		p = p[len(p)-r.limit:]
+	}
+
+	for len(p) > 0 {
+		if !r.full {
+			free := r.limit - len(r.buf)
+			chunk := min(free, len(p))
+			r.buf = append(r.buf, p[:chunk]...)
+			p = p[chunk:]
+			if len(r.buf) == r.limit {
+				r.full = true
+				r.start = 0
+			}
+			continue
+		}
+
+		chunk := copy(r.buf[r.start:], p)
+		p = p[chunk:]
+		r.start = (r.start + chunk) % r.limit
+	}
+	return n, nil
+}
+
+// Bytes returns a copy of the retained output
+func (r *ringBuffer) Bytes() []byte {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]byte, 0, len(r.buf))
+	if !r.full {
+		return append(out, r.buf...)
+	}
+	out = append(out, r.buf[r.start:]...)
+	return append(out, r.buf[:r.start]...)
+}
+
+func (r *ringBuffer) String() string {
+	return string(r.Bytes())
+}
+
+// InitDBOutput returns the output of initdb
+func (p *PG) InitDBOutput() Output {
+	return Output{Stdout: p.initStdout.Bytes(), Stderr: p.initStderr.Bytes()}
+}
+
+// ServerOutput returns the output of the postgres server process. The server
+// writes its log to Stderr.
+func (p *PG) ServerOutput() Output {
+	return Output{Stdout: p.stdout.Bytes(), Stderr: p.stderr.Bytes()}
+}
+
+// Logs returns the lines of the server log kept in memory, the last
+// Config.OutputLimit bytes of it. The first line may be cut.
+func (p *PG) Logs() []string {
+	return splitLines(p.stderr.String())
+}
+
+// serverWriters returns the writers for the stdout and stderr of the server:
+// the ring buffers, copying to serverLog and Config.LogFile if set
+func serverWriters(config Config, serverLog *serverLogWriter, stdout *ringBuffer, stderr *ringBuffer) (io.Writer, io.Writer, error) {
+	var copies []io.Writer
+	if serverLog != nil {
+		copies = append(copies, serverLog)
+	}
+	if config.LogFile != "" {
+		f, err := os.OpenFile(config.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Failed to open log file: %w", err)
+		}
+		f.Close()
+		copies = append(copies, logFile(config.LogFile))
+	}
+	if len(copies) == 0 {
+		return stdout, stderr, nil
+	}
+	return io.MultiWriter(append([]io.Writer{stdout}, copies...)...),
+		io.MultiWriter(append([]io.Writer{stderr}, copies...)...), nil
+}
+
+// logFile is an io.Writer appending to the file. The file is opened for
+// each write, so that it needs no closing when the server exits, and errors
+// are ignored, so that the output of the server is never stuck.
+type logFile string
+
+// Serializes writes of stdout and stderr
+var logFileMu sync.Mutex
+
+func (f logFile) Write(p []byte) (int, error) {
+	logFileMu.Lock()
+	defer logFileMu.Unlock()
+
+	file, err := os.OpenFile(string(f), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err == nil {
+		_, _ = file.Write(p)
+		file.Close()
+	}
+	return len(p), nil
+}
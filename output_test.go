@@ -0,0 +1,86 @@
+package pgxtest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRingBuffer(t *testing.T) {
+	r := newRingBuffer(8)
+	for _, s := range []string{"abc", "defgh", "ij", "klmnopqrstu"} {
+		if _, err := r.Write([]byte(s)); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+		all := "abcdefghijklmnopqrstu"[:strings.Index("abcdefghijklmnopqrstu", s)+len(s)]
+		if len(all) > 8 {
+			all = all[len(all)-8:]
+		}
+		if got := r.String(); got != all {
+			t.Errorf("after writing %q expected %q, got %q", s, all, got)
+		}
+	}
+}
+
+func TestServerOutput(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	if out := pg.InitDBOutput(); !strings.Contains(string(out.Stdout), "initdb") && !strings.Contains(string(out.Stdout), "database cluster") {
+		t.Errorf("unexpected initdb output: %q", out.Stdout)
+	}
+	if out := pg.ServerOutput(); !strings.Contains(string(out.Stderr), "ready to accept connections") {
+		t.Errorf("unexpected server output: %q", out.Stderr)
+	}
+}
+
+func TestLogs(t *testing.T) {
+	stderr := newRingBuffer(0)
+	pg := &PG{stderr: stderr}
+	if logs := pg.Logs(); len(logs) != 0 {
+		t.Errorf("expected no logs, got %q", logs)
+	}
+
+	stderr.Write([]byte("LOG:  first\nLOG:  second\n"))
+	if logs := pg.Logs(); len(logs) != 2 || logs[0] != "LOG:  first" || logs[1] != "LOG:  second" {
+		t.Errorf("unexpected logs: %q", logs)
+	}
+}
+
+func TestLogFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.log")
+	stdout, stderr := newRingBuffer(0), newRingBuffer(0)
+	outW, errW, err := serverWriters(Config{LogFile: path}, nil, stdout, stderr)
+	if err != nil {
+		t.Fatalf("failed to create writers: %v", err)
+	}
+	outW.Write([]byte("out\n"))
+	errW.Write([]byte("err\n"))
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+	if string(data) != "out\nerr\n" {
+		t.Errorf("unexpected log file: %q", data)
+	}
+	if stdout.String() != "out\n" || stderr.String() != "err\n" {
+		t.Errorf("unexpected captured output: %q, %q", stdout, stderr)
+	}
+
+	if _, _, err := serverWriters(Config{LogFile: filepath.Join(path, "nested")}, nil, stdout, stderr); err == nil {
+		t.Errorf("expected an error for an unwritable log file")
+	}
+}
@@ -0,0 +1,76 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// NormalizeOwnership hands the contents of the test database over to the role
+// (the test user if empty): objects owned by other roles are reassigned to it,
+// and it is granted all privileges on the user schemas and their tables,
+// sequences and functions.
+//
+// Useful after restoring a dump made by a different role, where objects keep
+// the original owner and privileges.
+func (p *PG) NormalizeOwnership(ctx context.Context, role string) error {
+	if role == "" {
+		role = p.User
+	}
+	to := pgx.Identifier{role}.Sanitize()
+
+	owners, err := p.queryStrings(ctx, `
+		SELECT DISTINCT r.rolname
+		FROM (
+			SELECT relowner AS owner FROM pg_class WHERE relnamespace IN (SELECT oid FROM user_schemas)
+			UNION SELECT proowner FROM pg_proc WHERE pronamespace IN (SELECT oid FROM user_schemas)
+			UNION SELECT typowner FROM pg_type WHERE typnamespace IN (SELECT oid FROM user_schemas)
+			UNION SELECT nspowner FROM pg_namespace WHERE oid IN (SELECT oid FROM user_schemas)
+		) o
+		JOIN pg_roles r ON r.oid = o.owner
+		WHERE r.rolname <> $1 AND r.rolname NOT LIKE 'pg\_%'
+		ORDER BY 1`, role)
+	if err != nil {
+		return err
+	}
+	for _, owner := range owners {
+		if _, err := p.Pool.Exec(ctx, fmt.Sprintf("REASSIGN OWNED BY %s TO %s", pgx.Identifier{owner}.Sanitize(), to)); err != nil {
+			return fmt.Errorf("failed to reassign objects of %s: %w", owner, err)
+		}
+	}
+
+	schemas, err := p.queryStrings(ctx, "SELECT nspname FROM user_schemas ORDER BY 1")
+	if err != nil {
+		return err
+	}
+	for _, schema := range schemas {
+		s := pgx.Identifier{schema}.Sanitize()
+		for _, grant := range []string{
+			"GRANT ALL ON SCHEMA %[1]s TO %[2]s",
+			"GRANT ALL ON ALL TABLES IN SCHEMA %[1]s TO %[2]s",
+			"GRANT ALL ON ALL SEQUENCES IN SCHEMA %[1]s TO %[2]s",
+			"GRANT ALL ON ALL FUNCTIONS IN SCHEMA %[1]s TO %[2]s",
+		} {
+			if _, err := p.Pool.Exec(ctx, fmt.Sprintf(grant, s, to)); err != nil {
+				return fmt.Errorf("failed to grant privileges on schema %s: %w", schema, err)
+			}
+		}
+	}
+	return nil
+}
+
+// queryStrings runs a query returning a single text column. The query can
+// refer to user_schemas: the namespaces that are neither system nor temporary.
+func (p *PG) queryStrings(ctx context.Context, query string, args ...any) ([]string, error) {
+	rows, err := p.Pool.Query(ctx, `
+		WITH user_schemas AS (
+			SELECT oid, nspname FROM pg_namespace
+			WHERE nspname NOT IN ('pg_catalog', 'information_schema')
+			  AND nspname NOT LIKE 'pg\_toast%' AND nspname NOT LIKE 'pg\_temp\_%'
+		)`+query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
@@ -0,0 +1,42 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNormalizeOwnership(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	_, err = pg.Pool.Exec(ctx, `
+		CREATE ROLE legacy;
+		CREATE TABLE accounts (id int);
+		ALTER TABLE accounts OWNER TO legacy;
+	`)
+	if err != nil {
+		t.Fatalf("failed to prepare objects: %v", err)
+	}
+
+	if err := pg.NormalizeOwnership(ctx, ""); err != nil {
+		t.Fatalf("failed to normalize ownership: %v", err)
+	}
+
+	var owner string
+	if err := pg.Pool.QueryRow(ctx, "SELECT tableowner FROM pg_tables WHERE tablename = 'accounts'").Scan(&owner); err != nil {
+		t.Fatalf("failed to get owner: %v", err)
+	}
+	if owner != pg.User {
+		t.Errorf("expected owner %q, got %q", pg.User, owner)
+	}
+}
@@ -0,0 +1,44 @@
+package pgxtest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Environment variable making configs with Dir persistent, for a local
+// development loop where schema and data survive between test runs
+const persistentEnv = "PGXTEST_PERSISTENT"
+
+// persistentFromEnv reports whether PGXTEST_PERSISTENT makes the config
+// persistent
+func persistentFromEnv(config Config) bool {
+	return os.Getenv(persistentEnv) != "" && config.Dir != "" &&
+		config.TempTablespaceDir == "" && config.WALDir == ""
+}
+
+// clusterExists reports whether dataDir contains a cluster created by initdb
+func clusterExists(dataDir string) bool {
+	_, err := os.Stat(filepath.Join(dataDir, "PG_VERSION"))
+	return err == nil
+}
+
+// stopPreviousServer kills the server left running in a persistent instance
+// directory by a process that has exited without calling Stop
+func stopPreviousServer(dir string) error {
+	md, err := readMetadata(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to read instance metadata: %w", err)
+	}
+	if md.OwnerPID != 0 && md.OwnerPID != os.Getpid() && processAlive(md.OwnerPID) {
+		return fmt.Errorf("Failed to start PostgreSQL: %s is in use by process %d", dir, md.OwnerPID)
+	}
+	if err := killOrphanServer(md); err != nil {
+		return fmt.Errorf("Failed to stop previous server: %w", err)
+	}
+	return nil
+}
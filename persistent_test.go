@@ -0,0 +1,48 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPersistent(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	config := Config{Dir: t.TempDir(), Persistent: true}
+
+	pg, err := Start(ctx, config)
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	if _, err := pg.Pool.Exec(ctx, "CREATE TABLE kept (val int); INSERT INTO kept VALUES (1)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	if err := pg.Stop(); err != nil {
+		t.Fatalf("failed to stop pgxtest: %v", err)
+	}
+
+	pg, err = Start(ctx, config)
+	if err != nil {
+		t.Fatalf("failed to start pgxtest again: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	var n int
+	if err := pg.Pool.QueryRow(ctx, "SELECT count(*) FROM kept").Scan(&n); err != nil {
+		t.Fatalf("failed to count rows: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 row after restart, got %d", n)
+	}
+}
+
+func TestPersistentNeedsDir(t *testing.T) {
+	if _, err := Start(context.Background(), Config{Persistent: true}); err == nil {
+		t.Errorf("expected an error without Config.Dir")
+	}
+}
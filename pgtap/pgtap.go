@@ -0,0 +1,221 @@
+// Runs pgTAP test files against a pgxtest instance and reports every TAP test
+// point as a Go subtest, so SQL-level and Go-level tests share one runner.
+//
+// Requires pgTAP to be installed on your system. Tests are skipped if it is
+// not available.
+package pgtap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dottedmag/pgxtest"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Result is a single TAP test point
+type Result struct {
+	Number      int
+	OK          bool
+	Description string
+	Directive   string // TODO or SKIP, empty if absent
+	Reason      string // Explanation following the directive
+	Diagnostics []string
+}
+
+// Install creates the pgtap extension in the test database. It skips the test
+// if pgTAP is not installed on the system.
+func Install(ctx context.Context, t testing.TB, pg *pgxtest.PG) {
+	t.Helper()
+
+	_, err := pg.Pool.Exec(ctx, "CREATE EXTENSION IF NOT EXISTS pgtap")
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "58P01" { // undefined_file
+		t.Skipf("pgTAP is not installed: %v", err)
+	}
+	if err != nil {
+		t.Fatalf("failed to install pgTAP: %v", err)
+	}
+}
+
+// Run installs pgTAP and runs every file matching the glob patterns as a
+// subtest named after the file. Every TAP test point becomes a nested subtest.
+//
+// Like pg_prove, files are executed as-is: wrap them in BEGIN/ROLLBACK to
+// discard their changes. psql meta-commands (lines starting with a backslash)
+// are ignored.
+func Run(ctx context.Context, t *testing.T, pg *pgxtest.PG, patterns ...string) {
+	t.Helper()
+
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			t.Fatalf("invalid pattern %q: %v", pattern, err)
+		}
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		t.Fatalf("no pgTAP files match %q", patterns)
+	}
+
+	Install(ctx, t, pg)
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			script, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatalf("failed to read %s: %v", file, err)
+			}
+			results, plan, err := Exec(ctx, pg, string(script))
+			report(t, results, plan)
+			if err != nil {
+				t.Errorf("failed to execute %s: %v", file, err)
+			}
+		})
+	}
+}
+
+// Exec executes a pgTAP script and returns the parsed test points and the
+// planned number of tests (-1 if the script has no plan).
+//
+// Test points produced before an SQL error are returned along with the error.
+func Exec(ctx context.Context, pg *pgxtest.PG, script string) ([]Result, int, error) {
+	conn, err := pg.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, -1, err
+	}
+	defer conn.Release()
+
+	results, execErr := conn.Conn().PgConn().Exec(ctx, stripMetaCommands(script)).ReadAll()
+
+	var tap []string
+	for _, result := range results {
+		for _, row := range result.Rows {
+			if len(row) > 0 {
+				tap = append(tap, strings.Split(string(row[0]), "\n")...)
+			}
+		}
+	}
+
+	points, plan, err := Parse(tap)
+	if execErr != nil {
+		return points, plan, execErr
+	}
+	return points, plan, err
+}
+
+func report(t *testing.T, results []Result, plan int) {
+	t.Helper()
+
+	for _, r := range results {
+		name := strconv.Itoa(r.Number)
+		if r.Description != "" {
+			name += " " + r.Description
+		}
+		t.Run(name, func(t *testing.T) {
+			for _, d := range r.Diagnostics {
+				t.Log(d)
+			}
+			switch {
+			case r.Directive == "SKIP":
+				t.Skip(r.Reason)
+			case r.Directive == "TODO":
+				if !r.OK {
+					t.Logf("TODO: %s", r.Reason)
+				}
+			case !r.OK:
+				t.Fail()
+			}
+		})
+	}
+
+	if plan >= 0 && plan != len(results) {
+		t.Errorf("planned %d tests but ran %d", plan, len(results))
+	}
+}
+
+func stripMetaCommands(script string) string {
+	lines := strings.Split(script, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), `\`) {
+			lines[i] = ""
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Parse parses TAP output lines into test points. It returns the planned
+// number of tests, or -1 if there is no plan line.
+func Parse(lines []string) ([]Result, int, error) {
+	var results []Result
+	plan := -1
+
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "#"):
+			if len(results) > 0 {
+				last := &results[len(results)-1]
+				last.Diagnostics = append(last.Diagnostics, strings.TrimSpace(strings.TrimPrefix(line, "#")))
+			}
+		case strings.HasPrefix(line, "1.."):
+			n, err := strconv.Atoi(strings.Fields(line[3:] + " ")[0])
+			if err != nil {
+				return results, plan, fmt.Errorf("invalid plan %q: %w", line, err)
+			}
+			plan = n
+		case strings.HasPrefix(line, "ok"), strings.HasPrefix(line, "not ok"):
+			r, err := parseTestPoint(line, len(results)+1)
+			if err != nil {
+				return results, plan, err
+			}
+			results = append(results, r)
+		}
+	}
+	return results, plan, nil
+}
+
+// A TODO or SKIP directive ending a test point, other # are part of the
+// description
+var directive = regexp.MustCompile(`(?i)#\s*(TODO|SKIP)(?:\s+(.*))?$`)
+
+func parseTestPoint(line string, next int) (Result, error) {
+	r := Result{Number: next}
+
+	rest := line
+	if strings.HasPrefix(rest, "not ok") {
+		rest = rest[len("not ok"):]
+	} else {
+		r.OK = true
+		rest = rest[len("ok"):]
+	}
+	rest = strings.TrimSpace(rest)
+
+	if fields := strings.Fields(rest); len(fields) > 0 {
+		if n, err := strconv.Atoi(fields[0]); err == nil {
+			r.Number = n
+			rest = strings.TrimSpace(rest[len(fields[0]):])
+		}
+	}
+
+	if m := directive.FindStringSubmatchIndex(rest); m != nil {
+		r.Directive = strings.ToUpper(rest[m[2]:m[3]])
+		if m[4] >= 0 {
+			r.Reason = strings.TrimSpace(rest[m[4]:m[5]])
+		}
+		rest = rest[:m[0]]
+	}
+
+	r.Description = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(rest), "-"))
+	if r.Number <= 0 {
+		return r, fmt.Errorf("invalid test point %q", line)
+	}
+	return r, nil
+}
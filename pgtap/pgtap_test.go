@@ -0,0 +1,80 @@
+package pgtap
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dottedmag/pgxtest"
+)
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+
+	pg, err := pgxtest.Start(ctx, pgxtest.Config{})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	file := filepath.Join(t.TempDir(), "basic.sql")
+	script := `\set QUIET 1
+BEGIN;
+SELECT plan(2);
+SELECT ok(true, 'truth');
+SELECT is(1 + 1, 2, 'arithmetic');
+SELECT * FROM finish();
+ROLLBACK;
+`
+	if err := os.WriteFile(file, []byte(script), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	Run(ctx, t, pg, file)
+}
+
+func TestParse(t *testing.T) {
+	results, plan, err := Parse([]string{
+		"1..6",
+		"ok 1 - first",
+		"not ok 2 - second",
+		"# Failed test 2: \"second\"",
+		"ok 3 # SKIP no network",
+		"not ok 4 - later # TODO not implemented",
+		"ok 5 - issue #42 fixed",
+		"ok 6 - tagged #skipped # skip",
+	})
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	if plan != 6 {
+		t.Errorf("expected plan 6, got %d", plan)
+	}
+	if len(results) != 6 {
+		t.Fatalf("expected 6 results, got %d", len(results))
+	}
+
+	if !results[0].OK || results[0].Description != "first" {
+		t.Errorf("unexpected first result: %+v", results[0])
+	}
+	if results[1].OK || len(results[1].Diagnostics) != 1 {
+		t.Errorf("unexpected second result: %+v", results[1])
+	}
+	if results[2].Directive != "SKIP" || results[2].Reason != "no network" {
+		t.Errorf("unexpected third result: %+v", results[2])
+	}
+	if results[3].Directive != "TODO" || results[3].Description != "later" {
+		t.Errorf("unexpected fourth result: %+v", results[3])
+	}
+	if results[4].Directive != "" || results[4].Description != "issue #42 fixed" {
+		t.Errorf("unexpected fifth result: %+v", results[4])
+	}
+	if results[5].Directive != "SKIP" || results[5].Reason != "" || results[5].Description != "tagged #skipped" {
+		t.Errorf("unexpected sixth result: %+v", results[5])
+	}
+}
@@ -6,24 +6,216 @@ package pgxtest
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"io"
+	"io/fs"
 	"log/slog"
+	"net"
 	"os"
 	"os/exec"
 	"path"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/tracelog"
 	pgxslog "github.com/mcosta74/pgx-slog"
 )
 
 type Config struct {
+	// Backend running the server, default is local binaries if they are found
+	// and DockerBackend if they are not but docker is installed
+	Backend Backend
+
+	// PostgreSQL version to run: a version prefix (16, 16.2) or comma-separated
+	// comparisons (>=15,<17). The newest matching installation is picked, see
+	// Installations. DownloadBackend downloads the newest matching release,
+	// DockerBackend uses a plain version as the image tag
+	Version string
+
 	BinDir         string   // Directory to look for postgresql binaries including initdb, postgres
-	Dir            string   // Directory for storing database files, removed for non-persistent configs
-	AdditionalArgs []string // Additional arguments to pass to the postgres command
+	Dir            string   // Directory for storing database files, removed for non-persistent configs, see Persistent
+	AdditionalArgs []string // Additional arguments to pass to the postgres command, see also Settings
+	TrackFunctions bool     // Collect call statistics for procedural language functions, see FunctionCoverage
+	HintPlan       bool     // Preload pg_hint_plan to allow forcing plans with Hint
+	IVM            bool     // Preload pg_ivm and create the extension in the test database, see CreateIMMV
+
+	// Extensions to create in the test database, e.g. pgcrypto or postgis.
+	// Libraries of the extensions that need it, such as pg_stat_statements,
+	// are preloaded. Missing extensions fail Start with install hints
+	Extensions []string
+
+	// Login roles created at startup, see PoolAs. Ignored by StartShared
+	Roles []Role
+
+	// Additional empty databases to create next to the test database, e.g.
+	// audit or analytics, see PoolFor. Migrate, InitScripts and Extensions
+	// apply only to the test database. Ignored by StartShared
+	Databases []string
+
+	// Options of initdb. The test user has no password unless Password is
+	// set, otherwise password authentication methods need a password set with
+	// InitDBArgs (--pwfile)
+	DataChecksums bool     // Enable data checksums, to mirror production clusters
+	Encoding      string   // Encoding of the databases, e.g. UTF8
+	Locale        string   // Locale of the databases, e.g. C or en_US.UTF-8
+	Auth          string   // Authentication method of the cluster, or of TCP connections if Password is set
+	InitDBArgs    []string // Additional arguments to pass to initdb
+
+	// Password of the test user. If set, TCP connections must authenticate
+	// with it using Auth, default scram-sha-256, while the UNIX socket used by
+	// the Pool stays trusted. Implies ListenTCP, see TCPURL. Not supported by
+	// DockerBackend, ignored by StartShared
+	Password string
+
+	// Rules of pg_hba.conf replacing the ones of initdb, e.g.
+	// "host all app 127.0.0.1/32 scram-sha-256", see also PG.SetHBA. The test
+	// user is always trusted over the UNIX socket, other connections need
+	// rules. Not supported by DockerBackend, ignored by StartShared
+	HBA []string
+
+	// Enable TLS with a server certificate for localhost signed by a
+	// generated CA, see PG.CAFile and PG.TLSConfig. Implies ListenTCP. Not supported
+	// by DockerBackend, ignored by StartShared
+	TLS bool
+
+	// Server configuration parameters, e.g. {"work_mem": "64MB"}. Values are
+	// passed as is, without quoting. Applied before AdditionalArgs
+	Settings map[string]string
+
+	PoolStatsInterval time.Duration // Sample Pool statistics with this interval, see PoolStats. Disabled if zero
+
+	StartAttempts int // Retry startup failing due to transient conditions up to this many attempts in total, default 1
+
+	// Labels describing the instance, e.g. test name or package. They are
+	// recorded in the instance directory and used for the default
+	// application_name, to find out which test has left an instance behind
+	Labels map[string]string
+
+	SharedInstances int // Number of instances StartShared spreads databases over, default 2
+
+	// Migrations run on the test database before Start returns, see also
+	// GolangMigrateFS and GooseFS
+	Migrate func(ctx context.Context, pool *pgxpool.Pool) error
+
+	// SQL scripts executed on the test database after the migrations, in
+	// order. Entries are glob patterns, matches of a pattern run in lexical
+	// order. psql meta-commands and COPY FROM stdin are not supported, dump
+	// data with pg_dump --inserts or load dumps with RestoreDump
+	InitScripts   []string
+	InitScriptsFS fs.FS // Filesystem of InitScripts, default is the OS filesystem
+
+	// Additional readiness check polled after the test database is created,
+	// Start returns once it succeeds. Use it to wait for conditions specific to
+	// your setup, e.g. tables created by an extension's background worker
+	ReadyWhen func(ctx context.Context, pool *pgxpool.Pool) error
+
+	// Directory caching clusters created by initdb, keyed by the binaries.
+	// Instances copy a cached cluster instead of running initdb, which takes
+	// most of the startup time. Entries of replaced binaries are not removed
+	InitDBCache string
+
+	OutputLimit int // Bytes of output to keep per stream of initdb and postgres processes, default 1MiB
+
+	// File to append the server output to, in addition to keeping the last
+	// OutputLimit bytes of it for Logs, e.g. to keep the whole log of a CI run
+	LogFile string
+
+	// Directory (e.g. on tmpfs) for a tablespace used for temporary files and
+	// tables of the test database. A subdirectory is created for the instance
+	// and removed on Stop
+	TempTablespaceDir string
+
+	// Directory (e.g. on tmpfs, or on another disk) for the WAL of the
+	// instance. A subdirectory is created for the instance and removed on Stop
+	WALDir string
+
+	// Keep the cluster in Dir on Stop and start it again instead of running
+	// initdb if Dir has one, so that schema and data survive between runs.
+	// Databases, Roles, Migrate and InitScripts are applied only when the
+	// cluster is created, remove Dir to start over. Needs Dir, not
+	// supported with TempTablespaceDir and WALDir. Ignored by StartShared.
+	//
+	// Set PGXTEST_PERSISTENT to a non-empty value to make every config with
+	// Dir persistent without changing the code.
+	Persistent bool
+
+	// Directory for the UNIX socket of the server. A subdirectory is created
+	// for the instance and removed on Stop. By default the socket is in Dir,
+	// or in a short hashed directory under /tmp if the socket path would
+	// exceed the limit of 103 bytes of macOS. Ignored by StartShared
+	SocketDir string
+
+	// Archive WAL of the instance to the "wal" bucket of the object store,
+	// see ArchiveWAL. Ignored by StartShared
+	WALArchive *ObjectStore
+
+	// Archive WAL (to WALArchive, or to the instance directory if it is not
+	// set) and take a base backup once the instance is prepared, so that
+	// RestoreToTime can recover any moment after Start. Not supported with
+	// TempTablespaceDir. Ignored by StartShared
+	PITR bool
+
+	// Directory to collect core files of crashed server processes to on
+	// Stop, together with the binary path and version of the server. Core
+	// dumps are enabled for the server, kernel.core_pattern must write them
+	// to the working directory (the default "core" or "core.%p") rather than
+	// to a crash handler
+	CoreDumpDir string
+
+	// Listen on a localhost TCP port in addition to the UNIX socket, for
+	// clients that can't use UNIX sockets. The port is allocated automatically
+	// unless Port is set; combine with StartAttempts in case another process
+	// grabs it first. Ignored by StartShared
+	ListenTCP bool
+	Port      int // Port to listen on, implies ListenTCP
+
+	// Stop the server after this time even if Stop is not called, to protect
+	// shared machines from forgotten instances. Connecting afterwards fails
+	// with ErrInstanceExpired, Stop removes the files. Ignored by StartShared
+	TTL time.Duration
+
+	// Stop the server once the context passed to Start is done, so that
+	// cancelled tests don't leave servers behind. Connecting afterwards fails
+	// with ErrInstanceExpired, Stop removes the files. Don't set it if the
+	// context only bounds the startup. Ignored by StartShared
+	BindToContext bool
+
+	ShutdownMode ShutdownMode // How Stop shuts down the server, default ShutdownFast
+
+	Logger *slog.Logger // Logger for pgx trace logs of the Pool, default slog.Default()
+
+	// Logger for the server log, disabled by default. Each entry is logged
+	// with the level of its severity and attributes for the SQLSTATE and the
+	// DETAIL, HINT, CONTEXT and STATEMENT lines of the entry. Sets
+	// log_line_prefix unless Settings do; with other prefixes the SQLSTATE
+	// is not logged
+	ServerLogger *slog.Logger
+
+	// Level of pgx trace logs, default tracelog.LogLevelTrace. Set to
+	// tracelog.LogLevelNone to disable tracing of the Pool altogether
+	TraceLogLevel tracelog.LogLevel
+
+	Redact *Redaction // Redacts bind parameters of statements in pgx trace logs
+
+	Tracer pgx.QueryTracer // Tracer of the Pool, replaces trace logging if set
+
+	Recorder *Recorder // Records statements executed through the Pool, see Replay
+	PlanGate *PlanGate // Collects plans of tagged queries executed through the Pool
+
+	// Record statements executed through the Pool in memory, see Queries. A
+	// Recorder is created unless set
+	RecordQueries bool
+
+	snapshotFile string    // Data directory archive to start from, set by ImportSnapshot
+	primary      *PG       // Server to start a streaming replica of, set by StartWithReplica
+	recovery     *recovery // Point-in-time recovery to start from, set by RestoreToTime
 }
 
 type PG struct {
@@ -32,18 +224,127 @@ type PG struct {
 	Pool *pgxpool.Pool
 
 	Host string
+	Port int // Port of the server, also determines the name of the UNIX socket in Host
 	User string
 	Name string
 
-	stderr io.ReadCloser
-	stdout io.ReadCloser
+	MajorVersion int // Major version of the server, e.g. 16, to skip tests of features it lacks
+
+	CAFile string // PEM file of the CA certificate if Config.TLS is set, e.g. for sslrootcert
+
+	initStdout *ringBuffer
+	initStderr *ringBuffer
+	stdout     *ringBuffer
+	stderr     *ringBuffer
+	serverLog  *serverLogWriter // Config.ServerLogger of the running server
+
+	config     Config
+	binPath    string
+	dataDir    string
+	serverArgs []string  // Arguments of postgres, except for the data directory
+	extraArgs  []string  // Arguments added by Restart
+	crashed    bool      // Server killed by Crash, or failed to start again, and not running
+	pitrBackup string    // Base backup taken for Config.PITR
+	pitrSince  time.Time // Earliest time RestoreToTime can recover
+	external   string    // URL of the test database on a server of ExternalBackend
+	recorder   *Recorder // Config.Recorder, see Queries
+	global     bool      // Started by Global
+
+	poolSampler *poolSampler
+
+	// Releases the database instead of stopping the server if the server is
+	// not owned by this PG
+	release func() error
+
+	tempTablespaceDir string
+	walDir            string
+
+	snapshots []string // Databases keeping snapshots, see Snapshot
+
+	poolsMu sync.Mutex
+	pools   map[poolKey]*pgxpool.Pool // Pools of other databases and roles, see PoolFor and PoolAs
+
+	expired        *atomic.Bool
+	expiryDeadline time.Time       // End of Config.TTL, zero if none
+	expiryCtx      context.Context // Context of Config.BindToContext
+	ttlTimer       *time.Timer
+	cancelExpiry   chan struct{}
+	expiredDone    chan struct{}
 }
 
-func postgresqlDBConf(sockDir string, dbName string) (*pgxpool.Config, error) {
-	url := "postgres://test@localhost/" + dbName + "?host=" + sockDir
+func postgresqlDBConf(sockDir string, port int, dbName string) (*pgxpool.Config, error) {
+	host := "localhost"
+	if port != 0 {
+		host += ":" + strconv.Itoa(port)
+	}
+	url := "postgres://test@" + host + "/" + dbName + "?host=" + sockDir
 	return pgxpool.ParseConfig(url)
 }
 
+// testPoolConfig returns configuration of the pool handed over to the user
+func testPoolConfig(sockDir string, port int, dbName string, config Config) (*pgxpool.Config, error) {
+	conf, err := postgresqlDBConf(sockDir, port, dbName)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := conf.ConnConfig.RuntimeParams["application_name"]; !ok {
+		conf.ConnConfig.RuntimeParams["application_name"] = applicationName(config.Labels)
+	}
+	conf.ConnConfig.Tracer = tracer(config)
+	return conf, nil
+}
+
+// tracer returns the tracer of the pool handed over to the user
+func tracer(config Config) pgx.QueryTracer {
+	var tracers multiTracer
+	if t := logTracer(config); t != nil {
+		tracers = append(tracers, t)
+	}
+	if config.Recorder != nil {
+		tracers = append(tracers, config.Recorder)
+	}
+	if config.PlanGate != nil {
+		tracers = append(tracers, config.PlanGate)
+	}
+
+	switch len(tracers) {
+	case 0:
+		return nil
+	case 1:
+		return tracers[0]
+	default:
+		return tracers
+	}
+}
+
+// logTracer returns the tracer configured by Tracer or TraceLogLevel
+func logTracer(config Config) pgx.QueryTracer {
+	if config.Tracer != nil {
+		return config.Tracer
+	}
+
+	level := config.TraceLogLevel
+	if level == 0 {
+		level = tracelog.LogLevelTrace
+	}
+	if level == tracelog.LogLevelNone {
+		return nil
+	}
+
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	var traceLogger tracelog.Logger = pgxslog.NewLogger(logger)
+	if config.Redact != nil {
+		traceLogger = redactingLogger{next: traceLogger, redaction: config.Redact}
+	}
+	return &tracelog.TraceLog{
+		Logger:   traceLogger,
+		LogLevel: level,
+	}
+}
+
 func createTestDB(ctx context.Context, pool *pgxpool.Pool) error {
 	var conn *pgxpool.Conn
 	// Prepare test database
@@ -65,6 +366,18 @@ func createTestDB(ctx context.Context, pool *pgxpool.Pool) error {
 	return nil
 }
 
+func createTempTablespace(ctx context.Context, pool *pgxpool.Pool, location string) error {
+	if _, err := pool.Exec(ctx, "CREATE TABLESPACE pgxtest_temp LOCATION "+quoteLiteral(location)); err != nil {
+		return err
+	}
+	_, err := pool.Exec(ctx, "ALTER DATABASE test SET temp_tablespaces = pgxtest_temp")
+	return err
+}
+
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
 // Start a new PostgreSQL database, on temporary storage.
 //
 // This database has fsync disabled for performance, so it might run faster
@@ -73,12 +386,105 @@ func createTestDB(ctx context.Context, pool *pgxpool.Pool) error {
 //
 // Use the Pool field to access the database pool
 func Start(ctx context.Context, config Config) (*PG, error) {
+	attempts := config.StartAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	backend := config.Backend
+	if backend == nil {
+		backend = autoBackend(config)
+	}
+
+	if config.RecordQueries && config.Recorder == nil {
+		config.Recorder = &Recorder{}
+	}
+
+	for {
+		pg, err := backend.start(ctx, config)
+		if err == nil {
+			pg.recorder = config.Recorder
+			return pg, nil
+		}
+		attempts--
+		if attempts == 0 || ctx.Err() != nil || !isTransientStartError(err) {
+			return pg, err
+		}
+	}
+}
+
+// Messages of startup errors that are likely to go away if the provisioning is
+// retried from scratch: races for ports or sockets with other instances and
+// servers that are slow to start on loaded machines
+var transientStartErrors = []string{
+	"the database system is starting up",
+	"could not bind",
+	"Address already in use",
+	"could not create lock file",
+	"could not create any Unix-domain sockets",
+	"could not create listen socket",
+}
+
+// Port PostgreSQL listens on unless configured otherwise
+const defaultPort = 5432
+
+func serverPort(port int) int {
+	if port == 0 {
+		return defaultPort
+	}
+	return port
+}
+
+// freePort returns a TCP port that is currently free on localhost
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+func isTransientStartError(err error) bool {
+	for _, msg := range transientStartErrors {
+		if strings.Contains(err.Error(), msg) {
+			return true
+		}
+	}
+	return false
+}
+
+func startLocal(ctx context.Context, config Config) (_ *PG, err error) {
 	// Find executables root path
-	binPath, err := findBinPath(config.BinDir)
+	if err := validateSettings(config.Settings); err != nil {
+		return nil, err
+	}
+
+	binPath, err := findBinaries(config)
+	if err != nil {
+		return nil, err
+	}
+
+	preload, err := preloadLibraries(binPath, config)
 	if err != nil {
 		return nil, err
 	}
 
+	if persistentFromEnv(config) {
+		config.Persistent = true
+	}
+	if config.PITR && config.TempTablespaceDir != "" {
+		return nil, fmt.Errorf("Config.PITR is not supported with Config.TempTablespaceDir")
+	}
+	if config.Persistent {
+		if config.Dir == "" {
+			return nil, fmt.Errorf("Config.Persistent needs Config.Dir")
+		}
+		if config.TempTablespaceDir != "" || config.WALDir != "" {
+			return nil, fmt.Errorf("Config.Persistent is not supported with Config.TempTablespaceDir and Config.WALDir")
+		}
+	}
+
 	// Prepare data directory
 	dir := config.Dir
 	if config.Dir == "" {
@@ -89,60 +495,146 @@ func Start(ctx context.Context, config Config) (*PG, error) {
 		dir = d
 	}
 
+	if config.PITR && config.WALArchive == nil {
+		config.WALArchive = &ObjectStore{Dir: filepath.Join(dir, "archive")}
+	}
+
+	var tempTablespaceDir, walDir, sockDir string
+
 	dataDir := filepath.Join(dir, "data")
-	sockDir := filepath.Join(dir, "sock")
+	reuse := config.Persistent && clusterExists(dataDir)
+	// Whether the test DB needs to be created and prepared
+	// Replicas and recovered instances get everything from their source
+	standby := config.primary != nil || config.recovery != nil
+	fresh := !reuse && config.snapshotFile == "" && !standby
+
+	// Start from a clean slate if startup is retried
+	defer func() {
+		if err != nil {
+			if reuse {
+				removeDirs(sockDir)
+			} else {
+				removeDirs(dir, tempTablespaceDir, walDir, sockDir)
+			}
+		}
+	}()
+
+	if reuse {
+		if err := stopPreviousServer(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.TempTablespaceDir != "" {
+		tempTablespaceDir, err = os.MkdirTemp(config.TempTablespaceDir, "pgxtest")
+		if err != nil {
+			return nil, err
+		}
+	}
+	if config.WALDir != "" {
+		walDir, err = os.MkdirTemp(config.WALDir, "pgxtest")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if config.WALArchive != nil {
+		if err := os.MkdirAll(filepath.Join(config.WALArchive.Dir, walArchiveBucket), 0755); err != nil {
+			return nil, err
+		}
+	}
 
 	err = os.MkdirAll(dataDir, 0711)
 	if err != nil {
 		return nil, err
 	}
 
-	err = os.MkdirAll(sockDir, 0711)
+	if config.SocketDir != "" {
+		sockDir, err = os.MkdirTemp(config.SocketDir, "pgxtest")
+	} else {
+		sockDir = socketDir(dir)
+		err = os.MkdirAll(sockDir, 0711)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	init := prepareCommand(filepath.Join(binPath, "initdb"),
-		"-D", dataDir,
-		"--no-sync",
-		"--username=test",
-	)
-	out, err := init.CombinedOutput()
+	// Mark the directory as an instance owned by this process, so that
+	// CleanupOrphans can tell it from other directories if the process
+	// exits before the server is started
+	err = writeMetadata(dir, instanceMetadata{
+		OwnerPID:          os.Getpid(),
+		Created:           time.Now(),
+		DataDir:           dataDir,
+		SocketDir:         sockDir,
+		TempTablespaceDir: tempTablespaceDir,
+		WALDir:            walDir,
+		Labels:            config.Labels,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("Failed to initialize DB: %w -> %s", err, string(out))
+		return nil, err
 	}
 
-	// Start PostgreSQL
-	args := []string{
-		"-D", dataDir, // Data directory
-		"-k", sockDir, // Location for the UNIX socket
-		"-h", "", // Disable TCP listening
-		"-F", // No fsync, just go fast
+	initStdout, initStderr := newRingBuffer(config.OutputLimit), newRingBuffer(config.OutputLimit)
+	if !reuse {
+		initStdout, initStderr, err = initCluster(binPath, dataDir, walDir, config)
 	}
-	if len(config.AdditionalArgs) > 0 {
-		args = append(args, config.AdditionalArgs...)
+	if err == nil && (config.Password != "" || config.HBA != nil) {
+		err = writeHBA(dataDir, config.HBA, config)
+	}
+	var caFile string
+	if err == nil && config.TLS {
+		caFile, err = generateCertificates(dir, dataDir)
 	}
-	cmd := prepareCommand(filepath.Join(binPath, "postgres"),
-		args...,
-	)
-	stderr, err := cmd.StderrPipe()
 	if err != nil {
 		return nil, err
 	}
 
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		stderr.Close()
-		return nil, err
+	port := config.Port
+	if port == 0 && listensTCP(config) {
+		if port, err = freePort(); err != nil {
+			return nil, err
+		}
 	}
 
-	err = cmd.Start()
+	// Start PostgreSQL
+	args := serverArgs(sockDir, port, preload, config)
+	serverLog := newServerLogWriter(config.ServerLogger)
+	defer func() {
+		// abort has waited for the server
+		if err != nil {
+			serverLog.Close()
+		}
+	}()
+	cmd, stdout, stderr, err := launch(binPath, dataDir, args, config, serverLog)
 	if err != nil {
 		return nil, abort("Failed to start PostgreSQL", cmd, stderr, stdout, err)
 	}
 
+	err = writeMetadata(dir, instanceMetadata{
+		PID:               cmd.Process.Pid,
+		OwnerPID:          os.Getpid(),
+		Created:           time.Now(),
+		DataDir:           dataDir,
+		SocketDir:         sockDir,
+		TempTablespaceDir: tempTablespaceDir,
+		WALDir:            walDir,
+		Labels:            config.Labels,
+	})
+	if err != nil {
+		return nil, abort("Failed to write instance metadata", cmd, stderr, stdout, err)
+	}
+
+	// Servers that are not fresh don't go through createTestDB, which waits
+	// for the server to accept connections
+	if !fresh {
+		if err := waitReady(ctx, sockDir, port); err != nil {
+			return nil, abort("PostgreSQL did not become ready", cmd, stderr, stdout, err)
+		}
+	}
+
 	// Connect to postgres DB
-	postgresConf, err := postgresqlDBConf(sockDir, "postgres")
+	postgresConf, err := postgresqlDBConf(sockDir, port, "postgres")
 	if err != nil {
 		return nil, abort("Failed to create pgx pool config", cmd, stderr, stdout, err)
 	}
@@ -151,87 +643,300 @@ func Start(ctx context.Context, config Config) (*PG, error) {
 		return nil, abort("Failed to connect to postgres DB", cmd, stderr, stdout, err)
 	}
 
-	if err := createTestDB(ctx, pool); err != nil {
-		return nil, abort("Failed to create test DB", cmd, stderr, stdout, err)
+	// Imported snapshots and reused clusters have the test DB already
+	if fresh {
+		if err := createTestDB(ctx, pool); err != nil {
+			return nil, abort("Failed to create test DB", cmd, stderr, stdout, err)
+		}
+	}
+
+	if config.Password != "" && !standby {
+		if _, err := pool.Exec(ctx, "ALTER ROLE test PASSWORD "+quoteLiteral(config.Password)); err != nil {
+			return nil, abort("Failed to set password", cmd, stderr, stdout, err)
+		}
+	}
+
+	if err := checkCollationVersions(ctx, pool); err != nil {
+		return nil, abort("Failed to check collation versions", cmd, stderr, stdout, err)
+	}
+
+	if tempTablespaceDir != "" {
+		if err := createTempTablespace(ctx, pool, tempTablespaceDir); err != nil {
+			return nil, abort("Failed to create temporary tablespace", cmd, stderr, stdout, err)
+		}
 	}
 
 	pool.Close()
 
 	// Connect to it properly
-	testConf, err := postgresqlDBConf(sockDir, "test")
+	pool, expired, err := openTestPool(ctx, sockDir, port, config, fresh)
 	if err != nil {
-		return nil, abort("Failed to create pgx pool config", cmd, stderr, stdout, err)
+		return nil, abort("Failed to set up test DB", cmd, stderr, stdout, err)
 	}
-	testConf.ConnConfig.Tracer = &tracelog.TraceLog{
-		Logger: pgxslog.NewLogger(
-			// TODO (misha): change to a proper test logger
-			slog.Default(),
-		),
-		LogLevel: tracelog.LogLevelTrace,
-	}
-	pool, err = pgxpool.NewWithConfig(ctx, testConf)
+	majorVersion, err := serverMajorVersion(ctx, pool)
 	if err != nil {
-		return nil, abort("Failed to connect to test DB", cmd, stderr, stdout, err)
+		pool.Close()
+		return nil, abort("Failed to set up test DB", cmd, stderr, stdout, err)
 	}
 
 	pg := &PG{
 		cmd: cmd,
 		dir: dir,
 
+		config:     config,
+		binPath:    binPath,
+		dataDir:    dataDir,
+		serverArgs: args,
+
+		tempTablespaceDir: tempTablespaceDir,
+		walDir:            walDir,
+
 		Pool: pool,
 
 		Host: sockDir,
+		Port: serverPort(port),
 		User: "test",
 		Name: "test",
 
-		stderr: stderr,
-		stdout: stdout,
+		MajorVersion: majorVersion,
+		CAFile:       caFile,
+
+		initStdout: initStdout,
+		initStderr: initStderr,
+		stdout:     stdout,
+		stderr:     stderr,
+		serverLog:  serverLog,
+
+		expired: expired,
+	}
+
+	if config.PITR {
+		if err := pg.takePITRBackup(); err != nil {
+			return nil, errors.Join(err, pg.Stop())
+		}
+	}
+
+	pg.startExpiry(ctx, config.TTL, config.BindToContext)
+
+	if config.PoolStatsInterval > 0 {
+		pg.poolSampler = startPoolSampler(pool, config.PoolStatsInterval)
 	}
 
 	return pg, nil
 }
 
-// Stop the database and remove storage files.
-func (p *PG) Stop() error {
-	if p == nil {
-		return nil
+// openTestPool connects to the test database of a started server and, if the
+// cluster is fresh, prepares it for use
+func openTestPool(ctx context.Context, host string, port int, config Config, fresh bool) (*pgxpool.Pool, *atomic.Bool, error) {
+	if err := validateDatabases(config.Databases); err != nil {
+		return nil, nil, err
+	}
+	if err := validateRoles(config.Roles); err != nil {
+		return nil, nil, err
+	}
+	if fresh {
+		if err := createDatabases(ctx, host, port, config.Databases); err != nil {
+			return nil, nil, err
+		}
 	}
 
-	p.Pool.Close()
+	testConf, err := testPoolConfig(host, port, "test", config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to create pgx pool config: %w", err)
+	}
+	expired := &atomic.Bool{}
+	testConf.BeforeConnect = refuseExpired(expired)
+	pool, err := pgxpool.NewWithConfig(ctx, testConf)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to connect to test DB: %w", err)
+	}
 
-	defer func() {
-		// Always try to remove it
-		os.RemoveAll(p.dir)
-	}()
+	if fresh {
+		if err := createRoles(ctx, pool, config.Roles); err != nil {
+			pool.Close()
+			return nil, nil, err
+		}
+		if err := prepareDatabase(ctx, pool, config); err != nil {
+			pool.Close()
+			return nil, nil, fmt.Errorf("Failed to prepare test DB: %w", err)
+		}
+		if err := grantRoles(ctx, pool, config.Roles); err != nil {
+			pool.Close()
+			return nil, nil, err
+		}
+	}
 
-	err := p.cmd.Process.Signal(os.Interrupt)
-	if err != nil {
-		return err
+	if err := checkReady(ctx, pool, config); err != nil {
+		pool.Close()
+		return nil, nil, err
 	}
+	return pool, expired, nil
+}
 
-	// Doesn't matter if the server exists with an error
-	err = p.cmd.Wait()
+// checkReady waits for Config.ReadyWhen to succeed, if it is set
+func checkReady(ctx context.Context, pool *pgxpool.Pool, config Config) error {
+	if config.ReadyWhen == nil {
+		return nil
+	}
+	err := retry(func() error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return config.ReadyWhen(ctx, pool)
+	}, 1000, 10*time.Millisecond)
 	if err != nil {
-		_ = p.cmd.Process.Signal(os.Kill)
+		return fmt.Errorf("Readiness check failed: %w", err)
+	}
+	return nil
+}
 
-		// Remove UNIX sockets
-		files, err := os.ReadDir(p.Host)
-		if err == nil {
-			for _, file := range files {
-				_ = os.Remove(filepath.Join(p.Host, file.Name()))
-			}
+// preloadLibraries returns the libraries to preload for the options in config
+func preloadLibraries(binPath string, config Config) ([]string, error) {
+	var preload []string
+	if config.HintPlan {
+		if !libraryAvailable(binPath, "pg_hint_plan") {
+			return nil, fmt.Errorf("pg_hint_plan is not installed")
+		}
+		preload = append(preload, "pg_hint_plan")
+	}
+	var missing []string
+	for _, lib := range extensionLibraries(config) {
+		if slices.Contains(preload, lib) {
+			continue
+		}
+		if !libraryAvailable(binPath, lib) {
+			missing = append(missing, lib)
+			continue
+		}
+		preload = append(preload, lib)
+	}
+	if len(missing) > 0 {
+		version, _ := installedVersion(binPath)
+		return nil, missingExtensionsError(missing, majorVersion(version))
+	}
+	return preload, nil
+}
+
+// initDBArgs returns arguments of initdb, except for the data directory
+func initDBArgs(walDir string, config Config) []string {
+	args := []string{
+		"--no-sync",
+		"--username=test",
+	}
+	if walDir != "" {
+		args = append(args, "--waldir="+walDir)
+	}
+	if config.DataChecksums {
+		args = append(args, "--data-checksums")
+	}
+	if config.Encoding != "" {
+		args = append(args, "--encoding="+config.Encoding)
+	}
+	if config.Locale != "" {
+		args = append(args, "--locale="+config.Locale)
+	}
+	// pg_hba.conf is written by writeHBA instead
+	if config.Auth != "" && config.Password == "" {
+		args = append(args, "--auth="+config.Auth)
+	}
+	return append(args, config.InitDBArgs...)
+}
+
+// listensTCP reports whether the options in config need the server to listen
+// on TCP in addition to the UNIX socket
+func listensTCP(config Config) bool {
+	return config.ListenTCP || config.Password != "" || config.TLS
+}
+
+// serverArgs returns arguments of postgres, except for the data directory
+func serverArgs(sockDir string, port int, preload []string, config Config) []string {
+	host := "" // Disable TCP listening
+	if listensTCP(config) || config.Port != 0 {
+		host = "localhost"
+	}
+	args := []string{
+		"-k", sockDir, // Location for the UNIX socket
+		"-h", host,
+		"-F", // No fsync, just go fast
+	}
+	if port != 0 {
+		args = append(args, "-p", strconv.Itoa(port))
+	}
+	if config.TrackFunctions {
+		args = append(args, "-c", "track_functions=pl")
+	}
+	if len(preload) > 0 {
+		args = append(args, "-c", "shared_preload_libraries="+strings.Join(preload, ","))
+	}
+	if config.TLS {
+		// server.crt and server.key are in the data directory
+		args = append(args, "-c", "ssl=on")
+	}
+	if config.WALArchive != nil {
+		args = append(args, "-c", "archive_mode=on", "-c", "archive_command="+archiveCommand(config.WALArchive))
+	}
+	if config.ServerLogger != nil {
+		args = append(args, "-c", "log_line_prefix="+serverLogPrefix)
+	}
+	args = append(args, settingArgs(config.Settings)...)
+	if len(config.AdditionalArgs) > 0 {
+		args = append(args, config.AdditionalArgs...)
+	}
+	return args
+}
+
+// Stop the database and remove storage files. The server is killed if it does
+// not shut down within a minute, see StopCtx.
+func (p *PG) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultShutdownTimeout)
+	defer cancel()
+	return p.StopCtx(ctx)
+}
+
+// StopCtx stops the database in Config.ShutdownMode and removes storage files.
+// The server is killed once ctx is done, and an error is returned.
+func (p *PG) StopCtx(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+
+	if p.poolSampler != nil {
+		p.poolSampler.Close()
+	}
+	if p.Pool != nil {
+		p.Pool.Close()
+	}
+	p.closePools()
+
+	if p.release != nil {
+		if err := p.dropSnapshots(); err != nil {
+			return err
 		}
+		return p.release()
 	}
 
-	if p.stderr != nil {
-		p.stderr.Close()
+	// Always try to remove it
+	if p.config.Persistent {
+		defer removeDirs(p.Host)
+	} else {
+		defer removeDirs(p.dir, p.tempTablespaceDir, p.walDir, p.Host)
 	}
 
-	if p.stdout != nil {
-		p.stdout.Close()
+	// The server is already stopped if the instance has expired or crashed
+	if p.stopExpiry() || p.crashed {
+		return p.collectCoreDumps()
 	}
+	// Collect core dumps even if the server has died
+	return errors.Join(p.shutdownServer(ctx, p.config.ShutdownMode), p.collectCoreDumps())
+}
 
-	return nil
+// removeDirs removes the directories, skipping empty paths
+func removeDirs(dirs ...string) {
+	for _, dir := range dirs {
+		if dir != "" {
+			os.RemoveAll(dir)
+		}
+	}
 }
 
 // Needed because Ubuntu doesn't put initdb in $PATH
@@ -310,13 +1015,11 @@ func prepareCommand(command string, args ...string) *exec.Cmd {
 	return cmd
 }
 
-func abort(msg string, cmd *exec.Cmd, stderr, stdout io.ReadCloser, err error) error {
-	_ = cmd.Process.Signal(os.Interrupt)
-	_ = cmd.Wait()
+func abort(msg string, cmd *exec.Cmd, stderr, stdout *ringBuffer, err error) error {
+	if cmd.Process != nil {
+		_ = cmd.Process.Signal(os.Interrupt)
+		_ = cmd.Wait()
+	}
 
-	serr, _ := io.ReadAll(stderr)
-	sout, _ := io.ReadAll(stdout)
-	_ = stderr.Close()
-	_ = stdout.Close()
-	return fmt.Errorf("%s: %s\nOUT: %s\nERR: %s", msg, err, string(sout), string(serr))
+	return fmt.Errorf("%s: %w\nOUT: %s\nERR: %s", msg, err, stdout, stderr)
 }
@@ -1,10 +1,19 @@
 package pgxtest
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/tracelog"
 )
 
 func TestPostgreSQL(t *testing.T) {
@@ -124,3 +133,192 @@ func TestAdditionalArgs(t *testing.T) {
 		t.Errorf("expected walLevel 'logical', got %q", walLevel)
 	}
 }
+
+func TestIsTransientStartError(t *testing.T) {
+	transient := fmt.Errorf("Failed to connect to postgres DB: %w", errors.New("FATAL: the database system is starting up (SQLSTATE 57P03)"))
+	if !isTransientStartError(transient) {
+		t.Errorf("expected %q to be transient", transient)
+	}
+
+	if isTransientStartError(errors.New("Did not find PostgreSQL executables installed")) {
+		t.Errorf("missing executables should not be transient")
+	}
+}
+
+func TestTempTablespaceDir(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	tempDir := t.TempDir()
+	pg, err := Start(ctx, Config{TempTablespaceDir: tempDir})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	var tablespaces string
+	if err := pg.Pool.QueryRow(ctx, "SHOW temp_tablespaces").Scan(&tablespaces); err != nil {
+		t.Fatalf("failed to SHOW temp_tablespaces: %v", err)
+	}
+	if tablespaces != "pgxtest_temp" {
+		t.Errorf("expected temp_tablespaces 'pgxtest_temp', got %q", tablespaces)
+	}
+
+	if _, err := pg.Pool.Exec(ctx, "CREATE TEMP TABLE spill AS SELECT generate_series(1, 1000) AS id"); err != nil {
+		t.Fatalf("failed to create temporary table: %v", err)
+	}
+	entries, err := os.ReadDir(tempDir)
+	if err != nil || len(entries) != 1 {
+		t.Errorf("expected instance directory in %s, got %v (%v)", tempDir, entries, err)
+	}
+}
+
+func TestReadyWhen(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	calls := 0
+	pg, err := Start(ctx, Config{ReadyWhen: func(ctx context.Context, pool *pgxpool.Pool) error {
+		calls++
+		if calls < 3 {
+			return errors.New("not ready yet")
+		}
+		_, err := pool.Exec(ctx, "CREATE TABLE ready (val text)")
+		return err
+	}})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	if calls != 3 {
+		t.Errorf("expected 3 readiness checks, got %d", calls)
+	}
+	if _, err := pg.Pool.Exec(ctx, "SELECT * FROM ready"); err != nil {
+		t.Errorf("readiness check did not run against the test database: %v", err)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug - 1}))
+
+	pg, err := Start(ctx, Config{Logger: logger})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	if _, err := pg.Pool.Exec(ctx, "SELECT 'logged'"); err != nil {
+		t.Fatalf("failed to query: %v", err)
+	}
+	if !strings.Contains(buf.String(), "logged") {
+		t.Errorf("expected the query to be logged, got %q", buf.String())
+	}
+}
+
+func TestTracer(t *testing.T) {
+	if tr, ok := tracer(Config{}).(*tracelog.TraceLog); !ok || tr.LogLevel != tracelog.LogLevelTrace {
+		t.Errorf("expected trace logging by default, got %#v", tracer(Config{}))
+	}
+	if tr, ok := tracer(Config{TraceLogLevel: tracelog.LogLevelWarn}).(*tracelog.TraceLog); !ok || tr.LogLevel != tracelog.LogLevelWarn {
+		t.Errorf("expected warning level, got %#v", tr)
+	}
+	if tr := tracer(Config{TraceLogLevel: tracelog.LogLevelNone}); tr != nil {
+		t.Errorf("expected no tracer, got %#v", tr)
+	}
+
+	custom := &tracelog.TraceLog{}
+	if tr := tracer(Config{Tracer: custom}); tr != custom {
+		t.Errorf("expected custom tracer, got %#v", tr)
+	}
+}
+
+func TestListenTCP(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{ListenTCP: true, StartAttempts: 3})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	if pg.Port == defaultPort {
+		t.Errorf("expected an allocated port, got %d", pg.Port)
+	}
+
+	conn, err := pgx.Connect(ctx, fmt.Sprintf("postgres://test@localhost:%d/test", pg.Port))
+	if err != nil {
+		t.Fatalf("failed to connect over TCP: %v", err)
+	}
+	defer conn.Close(ctx)
+
+	if err := conn.Ping(ctx); err != nil {
+		t.Errorf("failed to ping over TCP: %v", err)
+	}
+}
+
+func TestWALDir(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	walDir := t.TempDir()
+	pg, err := Start(ctx, Config{WALDir: walDir})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+
+	if _, err := pg.Pool.Exec(ctx, "CREATE TABLE test AS SELECT generate_series(1, 1000) AS id"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	segments, err := filepath.Glob(filepath.Join(walDir, "*", "0*"))
+	if err != nil || len(segments) == 0 {
+		t.Errorf("expected WAL segments in %s, got %v (%v)", walDir, segments, err)
+	}
+
+	if err = pg.Stop(); err != nil {
+		t.Errorf("failed to stop pgxtest: %v", err)
+	}
+	entries, err := os.ReadDir(walDir)
+	if err != nil || len(entries) != 0 {
+		t.Errorf("expected WAL directory of the instance to be removed, got %v (%v)", entries, err)
+	}
+}
+
+func TestInitDBOptions(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg := StartT(t, Config{DataChecksums: true, Encoding: "UTF8", Locale: "C"})
+
+	var checksums, encoding, collate string
+	err := pg.Pool.QueryRow(ctx, `
+		SELECT current_setting('data_checksums'), pg_encoding_to_char(encoding), datcollate
+		FROM pg_database WHERE datname = current_database()`).Scan(&checksums, &encoding, &collate)
+	if err != nil {
+		t.Fatalf("failed to query settings: %v", err)
+	}
+	if checksums != "on" || encoding != "UTF8" || collate != "C" {
+		t.Errorf("expected checksums on, UTF8 and C, got %s, %s and %s", checksums, encoding, collate)
+	}
+}
@@ -0,0 +1,124 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// recovery describes point-in-time recovery of a new instance from the base
+// backup and the WAL archive of another
+type recovery struct {
+	backup  string       // Base backup directory
+	archive *ObjectStore // WAL archive
+	target  time.Time
+}
+
+// takePITRBackup takes the base backup RestoreToTime starts from
+func (p *PG) takePITRBackup() error {
+	backup := filepath.Join(p.dir, "base")
+	if _, _, err := baseBackup(p.binPath, p, backup, []string{"--wal-method=stream"}, p.config.OutputLimit); err != nil {
+		return err
+	}
+	p.pitrBackup = backup
+	p.pitrSince = time.Now()
+	return nil
+}
+
+// RestoreToTime starts a new instance recovered to the state of this one at
+// the time, from the base backup and the WAL archived since, as a backup tool
+// would. The instance needs Config.PITR. The new instance has the same config,
+// except for Dir, Port, WALArchive and PITR, and is stopped independently.
+//
+// The time must be between Start and now. Transactions committed at the time
+// are included.
+func (p *PG) RestoreToTime(ctx context.Context, t time.Time) (_ *PG, err error) {
+	if p.pitrBackup == "" {
+		return nil, fmt.Errorf("RestoreToTime needs Config.PITR")
+	}
+	if t.Before(p.pitrSince) {
+		return nil, fmt.Errorf("Failed to restore to %s: it is before the base backup taken at %s", t, p.pitrSince)
+	}
+	if t.After(time.Now()) {
+		return nil, fmt.Errorf("Failed to restore to %s: it is in the future", t)
+	}
+
+	// Recovery stops at the first commit after the target, make sure there
+	// is one in the archive
+	if _, err := p.Pool.Exec(ctx, "SELECT txid_current()"); err != nil {
+		return nil, err
+	}
+	if _, err := p.ArchiveWAL(ctx); err != nil {
+		return nil, err
+	}
+
+	config := p.config
+	config.recovery = &recovery{backup: p.pitrBackup, archive: p.config.WALArchive, target: t}
+	config.Dir = ""
+	config.Port = 0
+	config.WALArchive = nil
+	config.PITR = false
+	config.Persistent = false
+	config.Backend = LocalBackend{}
+	restored, err := Start(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to restore to %s: %w", t, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = restored.Stop()
+		}
+	}()
+
+	// The server accepts connections before reaching the target
+	for {
+		var inRecovery bool
+		if err := restored.Pool.QueryRow(ctx, "SELECT pg_is_in_recovery()").Scan(&inRecovery); err != nil {
+			return nil, fmt.Errorf("Failed to check recovery: %w", err)
+		}
+		if !inRecovery {
+			return restored, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("Recovery to %s has not finished: %w", t, ctx.Err())
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// restoreBaseBackup copies the base backup to dataDir and configures recovery
+// from the WAL archive up to the target
+func restoreBaseBackup(dataDir string, walDir string, r *recovery) error {
+	// cp creates the directory with the permissions of the backup, which
+	// postgres insists on
+	if err := os.Remove(dataDir); err != nil {
+		return err
+	}
+	if err := copyTree(r.backup, dataDir); err != nil {
+		return fmt.Errorf("Failed to copy base backup: %w", err)
+	}
+	if walDir != "" {
+		if err := moveWAL(dataDir, walDir); err != nil {
+			return fmt.Errorf("Failed to move WAL: %w", err)
+		}
+	}
+
+	settings := fmt.Sprintf("restore_command = %s\nrecovery_target_time = %s\nrecovery_target_action = 'promote'\n",
+		quoteLiteral(restoreCommand(r.archive)),
+		quoteLiteral(r.target.UTC().Format("2006-01-02 15:04:05.999999")+"+00"))
+	f, err := os.OpenFile(filepath.Join(dataDir, "postgresql.auto.conf"), os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(settings); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dataDir, "recovery.signal"), nil, 0600)
+}
@@ -0,0 +1,63 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRestoreToTime(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg := StartT(t, Config{
+		PITR:        true,
+		InitScripts: []string{"CREATE TABLE t (val int)"},
+	})
+
+	if _, err := pg.Pool.Exec(ctx, "INSERT INTO t VALUES (1)"); err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	target := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	if _, err := pg.Pool.Exec(ctx, "INSERT INTO t VALUES (2)"); err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+
+	restored, err := pg.RestoreToTime(ctx, target)
+	if err != nil {
+		t.Fatalf("failed to restore: %v", err)
+	}
+	defer func() {
+		if err := restored.Stop(); err != nil {
+			t.Errorf("failed to stop restored instance: %v", err)
+		}
+	}()
+
+	var vals []int32
+	rows, err := restored.Pool.Query(ctx, "SELECT val FROM t ORDER BY val")
+	if err != nil {
+		t.Fatalf("failed to query: %v", err)
+	}
+	for rows.Next() {
+		var v int32
+		if err := rows.Scan(&v); err != nil {
+			t.Fatalf("failed to scan: %v", err)
+		}
+		vals = append(vals, v)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("failed to query: %v", err)
+	}
+	if len(vals) != 1 || vals[0] != 1 {
+		t.Errorf("expected only the row inserted before the target, got %v", vals)
+	}
+}
+
+func TestRestoreToTimeNeedsPITR(t *testing.T) {
+	pg := &PG{}
+	if _, err := pg.RestoreToTime(context.Background(), time.Now()); err == nil {
+		t.Errorf("expected an error without Config.PITR")
+	}
+}
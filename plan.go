@@ -0,0 +1,92 @@
+package pgxtest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// StartPlan describes what Start would do for a configuration
+type StartPlan struct {
+	BinPath string // Directory of the PostgreSQL executables
+
+	// Directory of the instance. If Config.Dir is empty, Start creates a
+	// temporary directory matching this pattern
+	Dir       string
+	DataDir   string
+	SocketDir string
+
+	InitDB []string // Command line of initdb
+
+	// Entry of Config.InitDBCache copied to DataDir instead of running
+	// initdb. InitDB populates it if it does not exist yet
+	InitDBCache string
+	Server      []string // Command line of postgres, without the port if it is allocated by Start
+
+	Labels map[string]string
+}
+
+// Plan resolves the executables and renders the commands Start would run for
+// config without running anything. Use it to debug configuration, e.g. in CI
+// setups where it is not obvious which PostgreSQL installation is picked up.
+//
+// Only LocalBackend is described: Plan fails if Start would use another
+// backend, set explicitly or picked because there are no local binaries.
+func Plan(config Config) (StartPlan, error) {
+	backend := config.Backend
+	if backend == nil {
+		backend = autoBackend(config)
+	}
+	if _, ok := backend.(LocalBackend); !ok {
+		return StartPlan{}, fmt.Errorf("Plan only describes LocalBackend, Start would use %T", backend)
+	}
+
+	if err := validateSettings(config.Settings); err != nil {
+		return StartPlan{}, err
+	}
+	binPath, err := findBinaries(config)
+	if err != nil {
+		return StartPlan{}, err
+	}
+	preload, err := preloadLibraries(binPath, config)
+	if err != nil {
+		return StartPlan{}, err
+	}
+
+	dir := config.Dir
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "pgxtest*")
+	}
+	var walDir string
+	if config.WALDir != "" {
+		walDir = filepath.Join(config.WALDir, "pgxtest*")
+	}
+	dataDir := filepath.Join(dir, "data")
+	sockDir := socketDir(dir)
+	if config.SocketDir != "" {
+		sockDir = filepath.Join(config.SocketDir, "pgxtest*")
+	}
+
+	var cacheEntry string
+	if config.InitDBCache != "" {
+		key, err := initCacheKey(binPath, initDBArgs("", config))
+		if err != nil {
+			return StartPlan{}, err
+		}
+		cacheEntry = filepath.Join(config.InitDBCache, key)
+	}
+
+	return StartPlan{
+		BinPath: binPath,
+
+		Dir:       dir,
+		DataDir:   dataDir,
+		SocketDir: sockDir,
+
+		InitDB:      append([]string{filepath.Join(binPath, "initdb"), "-D", dataDir}, initDBArgs(walDir, config)...),
+		InitDBCache: cacheEntry,
+		Server:      append([]string{filepath.Join(binPath, "postgres"), "-D", dataDir}, serverArgs(sockDir, config.Port, preload, config)...),
+
+		Labels: config.Labels,
+	}, nil
+}
@@ -0,0 +1,120 @@
+package pgxtest
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestPlan(t *testing.T) {
+	// Fake installation, Plan must not run anything
+	binDir := t.TempDir()
+	for _, name := range []string{"initdb", "postgres"} {
+		if err := os.WriteFile(filepath.Join(binDir, name), nil, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	plan, err := Plan(Config{
+		BinDir:         binDir,
+		Dir:            "/srv/pgxtest",
+		TrackFunctions: true,
+		AdditionalArgs: []string{"-c", "work_mem=64MB"},
+	})
+	if err != nil {
+		t.Fatalf("failed to plan: %v", err)
+	}
+
+	if plan.BinPath != binDir {
+		t.Errorf("expected bin path %q, got %q", binDir, plan.BinPath)
+	}
+	if plan.DataDir != "/srv/pgxtest/data" {
+		t.Errorf("unexpected data directory %q", plan.DataDir)
+	}
+
+	expected := []string{
+		filepath.Join(binDir, "postgres"), "-D", "/srv/pgxtest/data",
+		"-k", "/srv/pgxtest/sock", "-h", "", "-F",
+		"-c", "track_functions=pl",
+		"-c", "work_mem=64MB",
+	}
+	if !slices.Equal(plan.Server, expected) {
+		t.Errorf("expected server command %q, got %q", expected, plan.Server)
+	}
+	if plan.InitDB[0] != filepath.Join(binDir, "initdb") {
+		t.Errorf("unexpected initdb command %q", plan.InitDB)
+	}
+}
+
+func TestServerArgsTCP(t *testing.T) {
+	args := serverArgs("/sock", 15432, nil, Config{Port: 15432})
+	expected := []string{"-k", "/sock", "-h", "localhost", "-F", "-p", "15432"}
+	if !slices.Equal(args, expected) {
+		t.Errorf("expected %q, got %q", expected, args)
+	}
+}
+
+func TestPlanWALDir(t *testing.T) {
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, "initdb"), nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := Plan(Config{BinDir: binDir, WALDir: "/mnt/wal"})
+	if err != nil {
+		t.Fatalf("failed to plan: %v", err)
+	}
+	if !slices.Contains(plan.InitDB, "--waldir=/mnt/wal/pgxtest*") {
+		t.Errorf("expected --waldir in %q", plan.InitDB)
+	}
+}
+
+func TestPlanInitDBOptions(t *testing.T) {
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, "initdb"), nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := Plan(Config{
+		BinDir:        binDir,
+		DataChecksums: true,
+		Encoding:      "UTF8",
+		Locale:        "C",
+		Auth:          "trust",
+		InitDBArgs:    []string{"--lc-messages=C"},
+	})
+	if err != nil {
+		t.Fatalf("failed to plan: %v", err)
+	}
+	for _, arg := range []string{"--data-checksums", "--encoding=UTF8", "--locale=C", "--auth=trust", "--lc-messages=C"} {
+		if !slices.Contains(plan.InitDB, arg) {
+			t.Errorf("expected %s in %q", arg, plan.InitDB)
+		}
+	}
+}
+
+func TestPlanInitDBCache(t *testing.T) {
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, "initdb"), nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := Plan(Config{BinDir: binDir, InitDBCache: "/var/cache/pgxtest"})
+	if err != nil {
+		t.Fatalf("failed to plan: %v", err)
+	}
+	if filepath.Dir(plan.InitDBCache) != "/var/cache/pgxtest" {
+		t.Errorf("expected an entry of the initdb cache, got %q", plan.InitDBCache)
+	}
+}
+
+func TestPlanOtherBackends(t *testing.T) {
+	t.Setenv(externalURLEnv, "")
+
+	for _, backend := range []Backend{DockerBackend{}, DownloadBackend{}, ExternalBackend{URL: "postgres://localhost/postgres"}} {
+		if _, err := Plan(Config{Backend: backend}); err == nil {
+			t.Errorf("expected Plan to fail for %T", backend)
+		}
+	}
+}
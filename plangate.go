@@ -0,0 +1,216 @@
+package pgxtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Default relative cost increase tolerated by PlanGate
+const defaultCostThreshold = 0.2
+
+// Marks a query whose plan is checked by PlanGate, e.g.
+//
+//	SELECT * FROM orders WHERE customer_id = $1 /* pgxtest:plan=orders-by-customer */
+var planTag = regexp.MustCompile(`pgxtest:plan=([\w.-]+)`)
+
+// QueryPlan summarizes the plan of a tagged query
+type QueryPlan struct {
+	Cost  float64  `json:"cost"`  // Estimated total cost
+	Nodes []string `json:"nodes"` // Distinct node types, sorted
+}
+
+// PlanGate collects plans of the queries tagged with a pgxtest:plan=NAME
+// comment and compares them with a stored baseline. Set it as Config.PlanGate
+// and call Check once the tagged queries have run, e.g. at the end of the
+// test or in TestMain.
+type PlanGate struct {
+	BaselineFile string // JSON file with the baseline plans
+
+	// Relative increase of the estimated cost to tolerate, default 0.2
+	CostThreshold float64
+
+	// Write the collected plans to BaselineFile instead of comparing, e.g.
+	// Update: os.Getenv("UPDATE_PLANS") != ""
+	Update bool
+
+	mu      sync.Mutex
+	queries map[string]taggedQuery
+}
+
+type taggedQuery struct {
+	sql  string
+	args []any
+}
+
+func (g *PlanGate) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	m := planTag.FindStringSubmatch(data.SQL)
+	if m == nil || strings.HasPrefix(strings.TrimSpace(data.SQL), "EXPLAIN") {
+		return ctx
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.queries == nil {
+		g.queries = map[string]taggedQuery{}
+	}
+	// Plans of the first execution are checked
+	if _, ok := g.queries[m[1]]; !ok {
+		g.queries[m[1]] = taggedQuery{sql: data.SQL, args: data.Args}
+	}
+	return ctx
+}
+
+func (g *PlanGate) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+}
+
+// Check explains the tagged queries seen so far and compares their plans
+// with the baseline. A plan regresses if its cost grows beyond the threshold
+// or it uses node types absent from the baseline plan.
+//
+// The queries are explained with the arguments of their first execution, so
+// the tables they use must still exist.
+func (g *PlanGate) Check(ctx context.Context, p *PG) error {
+	g.mu.Lock()
+	queries := make(map[string]taggedQuery, len(g.queries))
+	for name, q := range g.queries {
+		queries[name] = q
+	}
+	g.mu.Unlock()
+
+	plans := map[string]QueryPlan{}
+	for name, q := range queries {
+		var plan []byte
+		if err := p.Pool.QueryRow(ctx, "EXPLAIN (FORMAT JSON) "+q.sql, q.args...).Scan(&plan); err != nil {
+			return fmt.Errorf("Failed to explain query %s: %w", name, err)
+		}
+		summary, err := summarizePlan(plan)
+		if err != nil {
+			return fmt.Errorf("Failed to parse plan of query %s: %w", name, err)
+		}
+		plans[name] = summary
+	}
+
+	if g.Update {
+		return updatePlanBaseline(g.BaselineFile, plans)
+	}
+
+	baseline, err := readPlanBaseline(g.BaselineFile)
+	if err != nil {
+		return err
+	}
+
+	threshold := g.CostThreshold
+	if threshold <= 0 {
+		threshold = defaultCostThreshold
+	}
+	return comparePlans(baseline, plans, threshold)
+}
+
+func comparePlans(baseline, plans map[string]QueryPlan, threshold float64) error {
+	var names []string
+	for name := range plans {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	for _, name := range names {
+		plan := plans[name]
+		base, ok := baseline[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: no baseline plan", name))
+			continue
+		}
+		if plan.Cost > base.Cost*(1+threshold) {
+			problems = append(problems, fmt.Sprintf("%s: cost %.2f exceeds baseline %.2f", name, plan.Cost, base.Cost))
+		}
+		for _, node := range plan.Nodes {
+			if !slices.Contains(base.Nodes, node) {
+				problems = append(problems, fmt.Sprintf("%s: uses %s, not in baseline %v", name, node, base.Nodes))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New("Plan regressions:\n  " + strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// summarizePlan returns the cost and node types of a JSON plan
+func summarizePlan(plan []byte) (QueryPlan, error) {
+	var root []struct {
+		Plan map[string]any
+	}
+	if err := json.Unmarshal(plan, &root); err != nil {
+		return QueryPlan{}, err
+	}
+	if len(root) == 0 {
+		return QueryPlan{}, fmt.Errorf("empty plan")
+	}
+
+	seen := map[string]bool{}
+	var walk func(node map[string]any)
+	walk = func(node map[string]any) {
+		if t, ok := node["Node Type"].(string); ok {
+			seen[t] = true
+		}
+		children, _ := node["Plans"].([]any)
+		for _, c := range children {
+			if c, ok := c.(map[string]any); ok {
+				walk(c)
+			}
+		}
+	}
+	walk(root[0].Plan)
+
+	var summary QueryPlan
+	summary.Cost, _ = root[0].Plan["Total Cost"].(float64)
+	for t := range seen {
+		summary.Nodes = append(summary.Nodes, t)
+	}
+	sort.Strings(summary.Nodes)
+	return summary, nil
+}
+
+func readPlanBaseline(path string) (map[string]QueryPlan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read plan baseline: %w", err)
+	}
+	var baseline map[string]QueryPlan
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("Failed to parse plan baseline: %w", err)
+	}
+	return baseline, nil
+}
+
+// updatePlanBaseline replaces the plans in the baseline file, keeping plans of
+// queries that have not run
+func updatePlanBaseline(path string, plans map[string]QueryPlan) error {
+	baseline, err := readPlanBaseline(path)
+	if errors.Is(err, os.ErrNotExist) {
+		baseline = map[string]QueryPlan{}
+	} else if err != nil {
+		return err
+	}
+	for name, plan := range plans {
+		baseline[name] = plan
+	}
+
+	data, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,90 @@
+package pgxtest
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlanGate(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	gate := &PlanGate{BaselineFile: filepath.Join(t.TempDir(), "plans.json"), Update: true}
+	pg, err := Start(ctx, Config{PlanGate: gate})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	if _, err := pg.Pool.Exec(ctx, `
+		CREATE TABLE test (id int PRIMARY KEY, val text);
+		INSERT INTO test SELECT i, i::text FROM generate_series(1, 10000) i;
+		ANALYZE test`); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	query := "SELECT val FROM test WHERE id = $1 /* pgxtest:plan=by-id */"
+	var val string
+	if err := pg.Pool.QueryRow(ctx, query, 42).Scan(&val); err != nil {
+		t.Fatalf("failed to query: %v", err)
+	}
+	if err := gate.Check(ctx, pg); err != nil {
+		t.Fatalf("failed to record baseline: %v", err)
+	}
+
+	gate.Update = false
+	if err := gate.Check(ctx, pg); err != nil {
+		t.Errorf("expected unchanged plan to pass: %v", err)
+	}
+
+	if _, err := pg.Pool.Exec(ctx, "ALTER TABLE test DROP CONSTRAINT test_pkey"); err != nil {
+		t.Fatalf("failed to drop index: %v", err)
+	}
+	err = gate.Check(ctx, pg)
+	if err == nil || !strings.Contains(err.Error(), "by-id") {
+		t.Errorf("expected plan regression of by-id, got %v", err)
+	}
+}
+
+func TestComparePlans(t *testing.T) {
+	baseline := map[string]QueryPlan{
+		"a": {Cost: 10, Nodes: []string{"Index Scan"}},
+	}
+
+	if err := comparePlans(baseline, map[string]QueryPlan{"a": {Cost: 11, Nodes: []string{"Index Scan"}}}, 0.2); err != nil {
+		t.Errorf("expected cost within threshold to pass: %v", err)
+	}
+	if err := comparePlans(baseline, map[string]QueryPlan{"a": {Cost: 13, Nodes: []string{"Index Scan"}}}, 0.2); err == nil {
+		t.Errorf("expected cost above threshold to fail")
+	}
+	if err := comparePlans(baseline, map[string]QueryPlan{"a": {Cost: 5, Nodes: []string{"Seq Scan"}}}, 0.2); err == nil {
+		t.Errorf("expected new node type to fail")
+	}
+	if err := comparePlans(baseline, map[string]QueryPlan{"b": {Cost: 5}}, 0.2); err == nil {
+		t.Errorf("expected query without baseline to fail")
+	}
+}
+
+func TestSummarizePlan(t *testing.T) {
+	plan := `[{"Plan": {"Node Type": "Hash Join", "Total Cost": 42.5, "Plans": [
+		{"Node Type": "Seq Scan"},
+		{"Node Type": "Hash", "Plans": [{"Node Type": "Seq Scan"}]}
+	]}}]`
+
+	summary, err := summarizePlan([]byte(plan))
+	if err != nil {
+		t.Fatalf("failed to summarize plan: %v", err)
+	}
+	if summary.Cost != 42.5 {
+		t.Errorf("expected cost 42.5, got %v", summary.Cost)
+	}
+	if strings.Join(summary.Nodes, ",") != "Hash,Hash Join,Seq Scan" {
+		t.Errorf("unexpected nodes %q", summary.Nodes)
+	}
+}
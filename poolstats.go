@@ -0,0 +1,144 @@
+package pgxtest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PoolStatSample is a snapshot of pgxpool statistics of the PG.Pool
+type PoolStatSample struct {
+	Time time.Time
+
+	AcquiredConns int32
+	IdleConns     int32
+	TotalConns    int32
+	MaxConns      int32
+
+	AcquireCount         int64
+	AcquireDuration      time.Duration // Total time spent waiting in Acquire
+	EmptyAcquireCount    int64         // Acquires that had to wait for a connection
+	CanceledAcquireCount int64
+}
+
+func samplePoolStat(stat *pgxpool.Stat) PoolStatSample {
+	return PoolStatSample{
+		Time: time.Now(),
+
+		AcquiredConns: stat.AcquiredConns(),
+		IdleConns:     stat.IdleConns(),
+		TotalConns:    stat.TotalConns(),
+		MaxConns:      stat.MaxConns(),
+
+		AcquireCount:         stat.AcquireCount(),
+		AcquireDuration:      stat.AcquireDuration(),
+		EmptyAcquireCount:    stat.EmptyAcquireCount(),
+		CanceledAcquireCount: stat.CanceledAcquireCount(),
+	}
+}
+
+// Number of samples kept, the oldest ones are dropped first. At one sample a
+// second, this is almost three hours of a long-lived instance.
+const maxPoolStatSamples = 10000
+
+type poolSampler struct {
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu      sync.Mutex
+	pool    *pgxpool.Pool
+	samples []PoolStatSample
+	start   int // Position of the oldest sample once samples is full
+}
+
+func startPoolSampler(pool *pgxpool.Pool, interval time.Duration) *poolSampler {
+	s := &poolSampler{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+		pool: pool,
+	}
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			s.record()
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return s
+}
+
+func (s *poolSampler) record() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sample := samplePoolStat(s.pool.Stat())
+	if len(s.samples) < maxPoolStatSamples {
+		s.samples = append(s.samples, sample)
+		return
+	}
+	s.samples[s.start] = sample
+	s.start = (s.start + 1) % len(s.samples)
+}
+
+// SetPool switches sampling to a new pool after the server is restarted,
+// recording the final sample of the old pool
+func (s *poolSampler) SetPool(pool *pgxpool.Pool) {
+	s.record()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pool = pool
+}
+
+// Close stops sampling after recording the final sample. Later calls do
+// nothing.
+func (s *poolSampler) Close() {
+	s.closeOnce.Do(func() {
+		close(s.stop)
+		<-s.done
+		s.record()
+	})
+}
+
+// Samples returns the kept samples, oldest first
+func (s *poolSampler) Samples() []PoolStatSample {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append(append([]PoolStatSample(nil), s.samples[s.start:]...), s.samples[:s.start]...)
+}
+
+// PoolStats returns the history of Pool statistics sampled every
+// Config.PoolStatsInterval, oldest first. Only the last 10000 samples are
+// kept. Returns nil if sampling is disabled.
+//
+// Counters in the samples are cumulative, compare the last sample with the
+// first one to find out how many acquires had to wait during the test.
+func (p *PG) PoolStats() []PoolStatSample {
+	if p.poolSampler == nil {
+		return nil
+	}
+	return p.poolSampler.Samples()
+}
+
+// MaxAcquiredConns returns the largest number of simultaneously acquired
+// connections across the samples.
+func MaxAcquiredConns(samples []PoolStatSample) int32 {
+	var max int32
+	for _, s := range samples {
+		if s.AcquiredConns > max {
+			max = s.AcquiredConns
+		}
+	}
+	return max
+}
@@ -0,0 +1,71 @@
+package pgxtest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestPoolStats(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{PoolStatsInterval: 5 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := pg.Pool.Exec(ctx, "SELECT pg_sleep(0.1)"); err != nil {
+				t.Errorf("failed to sleep: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	samples := pg.PoolStats()
+	if len(samples) < 2 {
+		t.Fatalf("expected several samples, got %d", len(samples))
+	}
+	if max := MaxAcquiredConns(samples); max < 2 {
+		t.Errorf("expected concurrent acquires to be sampled, max acquired %d", max)
+	}
+}
+
+func TestPoolSampler(t *testing.T) {
+	// The pool connects lazily, its statistics are available without a server
+	pool, err := pgxpool.New(context.Background(), "postgres://test@127.0.0.1:1/test")
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	defer pool.Close()
+
+	s := startPoolSampler(pool, time.Hour)
+	for i := 0; i < maxPoolStatSamples+10; i++ {
+		s.record()
+	}
+	s.Close()
+	s.Close() // Stopping twice is fine
+
+	samples := s.Samples()
+	if len(samples) != maxPoolStatSamples {
+		t.Fatalf("expected %d samples, got %d", maxPoolStatSamples, len(samples))
+	}
+	for i := 1; i < len(samples); i++ {
+		if samples[i].Time.Before(samples[i-1].Time) {
+			t.Fatalf("expected samples oldest first, sample %d is older than %d", i, i-1)
+		}
+	}
+}
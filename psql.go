@@ -0,0 +1,49 @@
+package pgxtest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// Psql runs psql with the arguments against the test database and returns its
+// standard output. Unlike queries through Pool, input of psql may use
+// meta-commands such as \copy, \i and \set. ~/.psqlrc is ignored and psql
+// stops at the first error, which is returned together with its error output.
+//
+// psql is taken from the binaries of the server, or found like those of
+// LocalBackend.
+func (p *PG) Psql(ctx context.Context, args ...string) ([]byte, error) {
+	cmd, err := p.psqlCommand(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return stdout.Bytes(), fmt.Errorf("Failed to run psql: %w\n%s", err, stderr.Bytes())
+	}
+	return stdout.Bytes(), nil
+}
+
+// RunScript runs the SQL script file with psql against the test database, see
+// Psql. Use \ir to include files relative to the script.
+func (p *PG) RunScript(ctx context.Context, path string) error {
+	_, err := p.Psql(ctx, "--file="+path)
+	return err
+}
+
+// psqlCommand returns a command running psql against the test database
+func (p *PG) psqlCommand(ctx context.Context, args ...string) (*exec.Cmd, error) {
+	psql, err := p.clientProgram("psql")
+	if err != nil {
+		return nil, err
+	}
+	args = append([]string{"--no-psqlrc", "--quiet", "--set=ON_ERROR_STOP=1"}, args...)
+	cmd := exec.CommandContext(ctx, psql, args...)
+	cmd.Env = append(os.Environ(), p.clientEnv()...)
+	return cmd, nil
+}
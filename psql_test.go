@@ -0,0 +1,58 @@
+package pgxtest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPsql(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg := StartT(t, Config{})
+
+	out, err := pg.Psql(ctx, "--tuples-only", "--no-align", "--command=SELECT 40 + 2")
+	if err != nil {
+		t.Fatalf("failed to run psql: %v", err)
+	}
+	if strings.TrimSpace(string(out)) != "42" {
+		t.Errorf("expected 42, got %q", out)
+	}
+
+	if _, err := pg.Psql(ctx, "--command=SELECT nonsense"); err == nil {
+		t.Errorf("expected an error for a failing command")
+	}
+}
+
+func TestRunScript(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg := StartT(t, Config{})
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"data.csv":   "1\n2\n3\n",
+		"schema.sql": "CREATE TABLE t (val int);\n",
+		"seed.sql":   "\\ir schema.sql\n\\copy t FROM '" + filepath.Join(dir, "data.csv") + "' WITH (FORMAT csv)\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	if err := pg.RunScript(ctx, filepath.Join(dir, "seed.sql")); err != nil {
+		t.Fatalf("failed to run script: %v", err)
+	}
+	var n int
+	if err := pg.Pool.QueryRow(ctx, "SELECT count(*) FROM t").Scan(&n); err != nil {
+		t.Fatalf("failed to count rows: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("expected 3 rows, got %d", n)
+	}
+}
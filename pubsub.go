@@ -0,0 +1,100 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Name of the publication and the subscription of a LogicalPair
+const logicalPairName = "pgxtest"
+
+// LogicalPair is a publisher and a subscriber connected by logical
+// replication, started by StartLogicalPair
+type LogicalPair struct {
+	Publisher  *PG
+	Subscriber *PG
+	Name       string // Name of the publication, the subscription and its slot
+
+	env *Environment
+}
+
+// StartLogicalPair starts a publisher with wal_level=logical and a subscriber,
+// and replicates the tables (all tables if none are given) with a publication
+// and a subscription. The tables must exist on both sides once the instances
+// are prepared, e.g. created by the same Config.Migrate. See EnvironmentSpec
+// for larger topologies.
+func StartLogicalPair(ctx context.Context, publisher Config, subscriber Config, tables ...string) (*LogicalPair, error) {
+	env, err := StartEnvironment(ctx, EnvironmentSpec{
+		Instances: map[string]Config{"publisher": publisher, "subscriber": subscriber},
+		Links: []Link{{
+			From:   "publisher",
+			To:     "subscriber",
+			Tables: tables,
+			Name:   logicalPairName,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &LogicalPair{
+		Publisher:  env.Instances["publisher"],
+		Subscriber: env.Instances["subscriber"],
+		Name:       logicalPairName,
+		env:        env,
+	}, nil
+}
+
+// WaitForReplication waits until the initial copy of the tables has finished
+// and the subscriber has applied all changes made on the publisher so far
+func (l *LogicalPair) WaitForReplication(ctx context.Context) error {
+	lsn, err := l.Publisher.CurrentWALLSN(ctx)
+	if err != nil {
+		return err
+	}
+	for {
+		synced, err := l.caughtUp(ctx, lsn)
+		if err != nil {
+			return err
+		}
+		if synced {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("Subscription %s has not caught up with %s: %w", l.Name, lsn, ctx.Err())
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// caughtUp reports whether all tables of the subscription are synchronized and
+// the subscriber has confirmed applying WAL up to lsn
+func (l *LogicalPair) caughtUp(ctx context.Context, lsn string) (bool, error) {
+	var syncing bool
+	err := l.Subscriber.Pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT FROM pg_subscription_rel r JOIN pg_subscription s ON s.oid = r.srsubid
+			WHERE s.subname = $1 AND r.srsubstate NOT IN ('r', 's'))`, l.Name).Scan(&syncing)
+	if err != nil {
+		return false, fmt.Errorf("Failed to get state of subscription %s: %w", l.Name, err)
+	}
+	if syncing {
+		return false, nil
+	}
+
+	var applied bool
+	err = l.Publisher.Pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT FROM pg_stat_replication
+			WHERE application_name = $1 AND replay_lsn >= $2::pg_lsn)`, l.Name, lsn).Scan(&applied)
+	if err != nil {
+		return false, fmt.Errorf("Failed to get replication state of %s: %w", l.Name, err)
+	}
+	return applied, nil
+}
+
+// Stop stops the subscriber and then the publisher
+func (l *LogicalPair) Stop() error {
+	return l.env.Stop()
+}
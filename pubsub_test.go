@@ -0,0 +1,40 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLogicalPair(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	schema := Config{InitScripts: []string{"CREATE TABLE items (id int PRIMARY KEY)"}}
+	pair, err := StartLogicalPair(ctx, schema, schema, "items")
+	if err != nil {
+		t.Fatalf("failed to start logical pair: %v", err)
+	}
+	defer func() {
+		if err := pair.Stop(); err != nil {
+			t.Errorf("failed to stop logical pair: %v", err)
+		}
+	}()
+
+	if _, err := pair.Publisher.Pool.Exec(ctx, "INSERT INTO items SELECT generate_series(1, 10)"); err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := pair.WaitForReplication(waitCtx); err != nil {
+		t.Fatalf("failed to wait for replication: %v", err)
+	}
+
+	var n int
+	if err := pair.Subscriber.Pool.QueryRow(ctx, "SELECT count(*) FROM items").Scan(&n); err != nil {
+		t.Fatalf("failed to count rows: %v", err)
+	}
+	if n != 10 {
+		t.Errorf("expected 10 replicated rows, got %d", n)
+	}
+}
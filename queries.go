@@ -0,0 +1,72 @@
+package pgxtest
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// Queries returns the statements executed through the Pool so far, recorded
+// by Config.Recorder (see Config.RecordQueries), or nil if there is none
+func (p *PG) Queries() Transcript {
+	if p.recorder == nil {
+		return nil
+	}
+	return p.recorder.Transcript()
+}
+
+// ResetQueries discards the recorded statements, e.g. after setting up the
+// test data and before the code under test runs
+func (p *PG) ResetQueries() {
+	if p.recorder != nil {
+		p.recorder.Reset()
+	}
+}
+
+// AssertQueryCount fails the test unless n of the recorded statements match
+// the regular expression, e.g. to catch N+1 queries:
+//
+//	pg.ResetQueries()
+//	listOrders(ctx, pg.Pool)
+//	pg.AssertQueryCount(t, `FROM order_items`, 1)
+//
+// Needs Config.RecordQueries or Config.Recorder.
+func (p *PG) AssertQueryCount(t testing.TB, pattern string, n int) {
+	t.Helper()
+	p.assertCount(t, "executed", p.Queries(), pattern, n)
+}
+
+// AssertPrepareCount fails the test unless n of the statements prepared on
+// the server match the regular expression, e.g. to check that the statement
+// cache reuses prepared statements of repeated queries. Needs
+// Config.RecordQueries or Config.Recorder.
+func (p *PG) AssertPrepareCount(t testing.TB, pattern string, n int) {
+	t.Helper()
+	var prepared Transcript
+	if p.recorder != nil {
+		prepared = p.recorder.Prepared()
+	}
+	p.assertCount(t, "prepared", prepared, pattern, n)
+}
+
+func (p *PG) assertCount(t testing.TB, what string, statements Transcript, pattern string, n int) {
+	t.Helper()
+
+	if p.recorder == nil {
+		t.Fatalf("queries are not recorded, set Config.RecordQueries")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		t.Fatalf("invalid pattern %q: %v", pattern, err)
+	}
+
+	var matched []string
+	for _, s := range statements {
+		if re.MatchString(s.SQL) {
+			matched = append(matched, s.SQL)
+		}
+	}
+	if len(matched) != n {
+		t.Fatalf("expected %d statements matching %q to be %s, got %d:\n%s", n, pattern, what, len(matched), strings.Join(matched, "\n"))
+	}
+}
@@ -0,0 +1,56 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+)
+
+func TestQueries(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg := StartT(t, Config{RecordQueries: true})
+	if _, err := pg.Pool.Exec(ctx, "CREATE TABLE items (id int)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	pg.ResetQueries()
+	if queries := pg.Queries(); len(queries) != 0 {
+		t.Fatalf("expected no queries after reset, got %v", queries)
+	}
+
+	conn, err := pg.Pool.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("failed to acquire connection: %v", err)
+	}
+	defer conn.Release()
+	for i := 0; i < 3; i++ {
+		if _, err := conn.Exec(ctx, "INSERT INTO items VALUES ($1)", i); err != nil {
+			t.Fatalf("failed to insert: %v", err)
+		}
+	}
+	if _, err := conn.Exec(ctx, "SELECT count(*) FROM items"); err != nil {
+		t.Fatalf("failed to query: %v", err)
+	}
+
+	if queries := pg.Queries(); len(queries) != 4 {
+		t.Errorf("expected 4 queries, got %v", queries)
+	}
+	pg.AssertQueryCount(t, `^INSERT INTO items`, 3)
+	pg.AssertQueryCount(t, `FROM items`, 1)
+	pg.AssertPrepareCount(t, `^INSERT INTO items`, 1)
+}
+
+func TestQueryAssertions(t *testing.T) {
+	pg := &PG{}
+	if queries := pg.Queries(); queries != nil {
+		t.Errorf("expected no queries, got %v", queries)
+	}
+	pg.ResetQueries()
+
+	pg.recorder = &Recorder{}
+	pg.recorder.record(RecordedStatement{SQL: "SELECT * FROM users WHERE id = $1"}, nil)
+	pg.recorder.record(RecordedStatement{SQL: "SELECT * FROM orders"}, nil)
+	pg.AssertQueryCount(t, `FROM users`, 1)
+	pg.AssertQueryCount(t, `^SELECT`, 2)
+	pg.AssertPrepareCount(t, `.`, 0)
+}
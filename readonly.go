@@ -0,0 +1,80 @@
+package pgxtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ReadOnlyStorage simulates the storage of the server going read-only, e.g.
+// a disk remounted read-only after I/O errors: write permissions are removed
+// from the data directory (and WAL and temporary tablespace directories), and
+// sessions of the test database are terminated, so that new sessions fail to
+// open files for writing. Call restore to make the storage writable again.
+//
+// Writes start failing with permission errors once the files are reopened.
+// Background processes of the server keep their open files and may shut the
+// server down when they fail to write, as they would on real storage.
+//
+// The server runs as the user running the tests, so it is not available when
+// testing as root, where permissions are not enforced.
+func (p *PG) ReadOnlyStorage(ctx context.Context) (restore func() error, err error) {
+	if p.dataDir == "" {
+		return nil, fmt.Errorf("Storage of the server is not local")
+	}
+	if os.Geteuid() == 0 {
+		return nil, fmt.Errorf("Permissions are not enforced for root")
+	}
+
+	modes := map[string]fs.FileMode{}
+	restore = func() error {
+		var errs []error
+		for path, mode := range modes {
+			if err := os.Chmod(path, mode); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+
+	for _, dir := range []string{p.dataDir, p.walDir, p.tempTablespaceDir} {
+		if dir == "" {
+			continue
+		}
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				// Files come and go while the server is running
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if d.Type()&fs.ModeSymlink != 0 {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			modes[path] = info.Mode().Perm()
+			return os.Chmod(path, info.Mode().Perm()&^0222)
+		})
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("Failed to make storage read-only: %w", err), restore())
+		}
+	}
+
+	p.Pool.Reset()
+	_, err = p.Pool.Exec(ctx, `
+		SELECT pg_terminate_backend(pid) FROM pg_stat_activity
+		WHERE datname = current_database() AND pid <> pg_backend_pid()`)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("Failed to terminate sessions: %w", err), restore())
+	}
+	// The session that terminated the others still has its files open
+	p.Pool.Reset()
+	return restore, nil
+}
@@ -0,0 +1,29 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+)
+
+func TestReadOnlyStorage(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg := StartT(t, Config{})
+
+	restore, err := pg.ReadOnlyStorage(ctx)
+	if err != nil {
+		t.Fatalf("failed to make storage read-only: %v", err)
+	}
+	_, err = pg.Pool.Exec(ctx, "CREATE TABLE t AS SELECT generate_series(1, 1000) AS id")
+	if restoreErr := restore(); restoreErr != nil {
+		t.Fatalf("failed to restore storage: %v", restoreErr)
+	}
+	if err == nil {
+		t.Errorf("expected writes to fail on read-only storage")
+	}
+
+	if _, err := pg.Pool.Exec(ctx, "CREATE TABLE t AS SELECT generate_series(1, 1000) AS id"); err != nil {
+		t.Errorf("expected writes to succeed after restore: %v", err)
+	}
+}
@@ -0,0 +1,243 @@
+package pgxtest
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// RecordedStatement is a statement executed through the Pool
+type RecordedStatement struct {
+	Session  uint32        `json:"session"` // Backend PID of the connection
+	SQL      string        `json:"sql"`
+	Args     []any         `json:"args,omitempty"`
+	Duration time.Duration `json:"duration,omitempty"` // Zero for statements of batches
+	Err      string        `json:"error,omitempty"`
+}
+
+// Transcript is a sequence of recorded statements in the order of completion
+type Transcript []RecordedStatement
+
+// Recorder is a pgx tracer recording the statements executed through the
+// Pool, set it as Config.Recorder. The zero value is ready to use.
+type Recorder struct {
+	mu         sync.Mutex
+	statements Transcript
+	prepared   Transcript
+}
+
+type recorderQueryKey struct{}
+
+type recorderQuery struct {
+	start time.Time
+	sql   string
+	args  []any
+}
+
+func (r *Recorder) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, recorderQueryKey{}, recorderQuery{start: time.Now(), sql: data.SQL, args: data.Args})
+}
+
+func (r *Recorder) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	q, ok := ctx.Value(recorderQueryKey{}).(recorderQuery)
+	if !ok {
+		return
+	}
+	r.record(RecordedStatement{
+		Session:  conn.PgConn().PID(),
+		SQL:      q.sql,
+		Args:     q.args,
+		Duration: time.Since(q.start),
+	}, data.Err)
+}
+
+func (r *Recorder) TraceBatchStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceBatchStartData) context.Context {
+	return ctx
+}
+
+func (r *Recorder) TraceBatchQuery(ctx context.Context, conn *pgx.Conn, data pgx.TraceBatchQueryData) {
+	r.record(RecordedStatement{
+		Session: conn.PgConn().PID(),
+		SQL:     data.SQL,
+		Args:    data.Args,
+	}, data.Err)
+}
+
+func (r *Recorder) TraceBatchEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceBatchEndData) {
+}
+
+type recorderPrepareKey struct{}
+
+func (r *Recorder) TracePrepareStart(ctx context.Context, conn *pgx.Conn, data pgx.TracePrepareStartData) context.Context {
+	return context.WithValue(ctx, recorderPrepareKey{}, recorderQuery{start: time.Now(), sql: data.SQL})
+}
+
+func (r *Recorder) TracePrepareEnd(ctx context.Context, conn *pgx.Conn, data pgx.TracePrepareEndData) {
+	q, ok := ctx.Value(recorderPrepareKey{}).(recorderQuery)
+	// Statements found in the cache of the connection are not prepared again
+	if !ok || data.AlreadyPrepared {
+		return
+	}
+	s := RecordedStatement{
+		Session:  conn.PgConn().PID(),
+		SQL:      q.sql,
+		Duration: time.Since(q.start),
+	}
+	if data.Err != nil {
+		s.Err = data.Err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prepared = append(r.prepared, s)
+}
+
+func (r *Recorder) record(s RecordedStatement, err error) {
+	if err != nil {
+		s.Err = err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statements = append(r.statements, s)
+}
+
+// Transcript returns the statements recorded so far
+func (r *Recorder) Transcript() Transcript {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append(Transcript(nil), r.statements...)
+}
+
+// Prepared returns the statements prepared on the server so far, by
+// Conn.Prepare or by the statement caches of pgx. They are not part of the
+// Transcript.
+func (r *Recorder) Prepared() Transcript {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append(Transcript(nil), r.prepared...)
+}
+
+// Reset discards the recorded and prepared statements
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statements = nil
+	r.prepared = nil
+}
+
+// sessions splits the transcript into statements of each session, in the
+// order of the first statement of the session
+func (t Transcript) sessions() []Transcript {
+	var order []uint32
+	bySession := map[uint32]Transcript{}
+	for _, s := range t {
+		if _, ok := bySession[s.Session]; !ok {
+			order = append(order, s.Session)
+		}
+		bySession[s.Session] = append(bySession[s.Session], s)
+	}
+
+	sessions := make([]Transcript, 0, len(order))
+	for _, id := range order {
+		sessions = append(sessions, bySession[id])
+	}
+	return sessions
+}
+
+// ReplayOptions control Replay
+type ReplayOptions struct {
+	Concurrency int // Number of copies of the transcript replayed simultaneously, default 1
+}
+
+// Replay executes the statements of the transcript against the database.
+// Every recorded session is replayed in order on a connection of its own,
+// sessions are replayed concurrently. Statements that failed during recording
+// may fail during replay as well, other failures abort the replay.
+//
+// Arguments are interpolated into the statements by pgx (simple protocol), so
+// transcripts read by ReadTranscript replay the same although their arguments
+// have lost their Go types.
+func (p *PG) Replay(ctx context.Context, transcript Transcript, opts ReplayOptions) error {
+	copies := opts.Concurrency
+	if copies <= 0 {
+		copies = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	for i := 0; i < copies; i++ {
+		for _, session := range transcript.sessions() {
+			wg.Add(1)
+			go func(session Transcript) {
+				defer wg.Done()
+				if err := p.replaySession(ctx, session); err != nil {
+					mu.Lock()
+					defer mu.Unlock()
+					if firstErr == nil {
+						firstErr = err
+						cancel()
+					}
+				}
+			}(session)
+		}
+	}
+	wg.Wait()
+	return firstErr
+}
+
+func (p *PG) replaySession(ctx context.Context, session Transcript) error {
+	conn, err := p.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	for _, s := range session {
+		args := append([]any{pgx.QueryExecModeSimpleProtocol}, s.Args...)
+		if _, err := conn.Exec(ctx, s.SQL, args...); err != nil && s.Err == "" {
+			return fmt.Errorf("Failed to replay %q: %w", s.SQL, err)
+		}
+	}
+	return nil
+}
+
+// WriteTranscript writes the transcript as JSON lines, one statement per line
+func WriteTranscript(w io.Writer, transcript Transcript) error {
+	enc := json.NewEncoder(w)
+	for _, s := range transcript {
+		if err := enc.Encode(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadTranscript reads a transcript written by WriteTranscript
+func ReadTranscript(r io.Reader) (Transcript, error) {
+	var transcript Transcript
+	dec := json.NewDecoder(bufio.NewReader(r))
+	for {
+		var s RecordedStatement
+		err := dec.Decode(&s)
+		if err == io.EOF {
+			return transcript, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		transcript = append(transcript, s)
+	}
+}
@@ -0,0 +1,90 @@
+package pgxtest
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestRecordReplay(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	rec := &Recorder{}
+	pg, err := Start(ctx, Config{Recorder: rec})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	if _, err := pg.Pool.Exec(ctx, "CREATE TABLE test (id int, val text)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	tx, err := pg.Pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("failed to begin: %v", err)
+	}
+	if _, err := tx.Exec(ctx, "INSERT INTO test VALUES ($1, $2)", 1, "it's"); err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	if _, err := pg.Pool.Exec(ctx, "SELECT * FROM missing"); err == nil {
+		t.Fatalf("expected query to fail")
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTranscript(&buf, rec.Transcript()); err != nil {
+		t.Fatalf("failed to write transcript: %v", err)
+	}
+	transcript, err := ReadTranscript(&buf)
+	if err != nil {
+		t.Fatalf("failed to read transcript: %v", err)
+	}
+
+	replica, err := Start(ctx, Config{})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = replica.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	if err := replica.Replay(ctx, transcript, ReplayOptions{}); err != nil {
+		t.Fatalf("failed to replay: %v", err)
+	}
+
+	var val string
+	if err := replica.Pool.QueryRow(ctx, "SELECT val FROM test WHERE id = 1").Scan(&val); err != nil {
+		t.Fatalf("failed to query replayed data: %v", err)
+	}
+	if val != "it's" {
+		t.Errorf("expected %q, got %q", "it's", val)
+	}
+}
+
+func TestTranscriptSessions(t *testing.T) {
+	transcript := Transcript{
+		{Session: 2, SQL: "a"},
+		{Session: 1, SQL: "b"},
+		{Session: 2, SQL: "c"},
+	}
+
+	sessions := transcript.sessions()
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(sessions))
+	}
+	if len(sessions[0]) != 2 || sessions[0][0].SQL != "a" || sessions[0][1].SQL != "c" {
+		t.Errorf("unexpected first session %+v", sessions[0])
+	}
+	if len(sessions[1]) != 1 || sessions[1][0].SQL != "b" {
+		t.Errorf("unexpected second session %+v", sessions[1])
+	}
+}
@@ -0,0 +1,147 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/tracelog"
+)
+
+// Replacement of redacted parameters, unless Redaction.Replacement is set
+const defaultRedactionReplacement = "[REDACTED]"
+
+// Redaction hides bind parameters in pgx trace logs, so that statements can be
+// logged with their parameters without leaking sensitive fixture data.
+//
+// Columns are matched against the statement text: parameters compared with a
+// column (email = $1, email IN ($1, $2)), assigned to it in UPDATE or listed
+// for it in INSERT are redacted. The matching is textual and does not
+// understand every SQL construct, use Values as a safety net.
+type Redaction struct {
+	Columns     []string         // Names of the columns to redact parameters of, case-insensitive
+	Values      []*regexp.Regexp // Redact parameters whose text matches any of these
+	Replacement string           // Default "[REDACTED]"
+}
+
+// Parameter compared with or assigned to a column: col = $1, col LIKE $1,
+// col IN ($1, $2)
+var columnParam = regexp.MustCompile(`(?i)"?(\w+)"?\s*(?:=|<>|!=|<=|>=|<|>|\bI?LIKE\b|\bIN\s*\()\s*((?:\$\d+\s*,?\s*)+)`)
+
+// INSERT with a column list, the parameter lists follow VALUES
+var insertColumns = regexp.MustCompile(`(?is)\bINSERT\s+INTO\s+[\w."]+\s*\(([^)]*)\)\s*VALUES\s*(.*)`)
+
+var paramNumber = regexp.MustCompile(`\$(\d+)`)
+
+// redactedParams returns 1-based numbers of the parameters of sql bound to
+// the columns
+func (r *Redaction) redactedParams(sql string) map[int]bool {
+	columns := map[string]bool{}
+	for _, c := range r.Columns {
+		columns[strings.ToLower(c)] = true
+	}
+
+	params := map[int]bool{}
+	addParams := func(s string) {
+		for _, m := range paramNumber.FindAllStringSubmatch(s, -1) {
+			n, _ := strconv.Atoi(m[1])
+			params[n] = true
+		}
+	}
+
+	for _, m := range columnParam.FindAllStringSubmatch(sql, -1) {
+		if columns[strings.ToLower(m[1])] {
+			addParams(m[2])
+		}
+	}
+
+	if m := insertColumns.FindStringSubmatch(sql); m != nil {
+		var positions []int
+		for i, c := range strings.Split(m[1], ",") {
+			if columns[strings.ToLower(strings.Trim(strings.TrimSpace(c), `"`))] {
+				positions = append(positions, i)
+			}
+		}
+		for _, row := range valueRows(m[2]) {
+			for _, i := range positions {
+				if i < len(row) {
+					addParams(row[i])
+				}
+			}
+		}
+	}
+	return params
+}
+
+// valueRows splits VALUES (...), (...) into the expressions of the rows. Nested
+// parentheses are kept within expressions.
+func valueRows(values string) [][]string {
+	var rows [][]string
+	var row []string
+	depth, start := 0, 0
+	for i, c := range values {
+		switch c {
+		case '(':
+			depth++
+			if depth == 1 {
+				row, start = nil, i+1
+			}
+		case ',':
+			if depth == 1 {
+				row = append(row, values[start:i])
+				start = i + 1
+			}
+		case ')':
+			if depth == 1 {
+				rows = append(rows, append(row, values[start:i]))
+			}
+			depth--
+			if depth < 0 {
+				return rows
+			}
+		}
+	}
+	return rows
+}
+
+func (r *Redaction) redact(sql string, args []any) []any {
+	replacement := r.Replacement
+	if replacement == "" {
+		replacement = defaultRedactionReplacement
+	}
+
+	params := r.redactedParams(sql)
+	redacted := make([]any, len(args))
+	for i, a := range args {
+		redacted[i] = a
+		if params[i+1] {
+			redacted[i] = replacement
+			continue
+		}
+		text := fmt.Sprint(a)
+		for _, re := range r.Values {
+			if re.MatchString(text) {
+				redacted[i] = replacement
+				break
+			}
+		}
+	}
+	return redacted
+}
+
+// redactingLogger redacts arguments of the statements before passing log
+// records on
+type redactingLogger struct {
+	next      tracelog.Logger
+	redaction *Redaction
+}
+
+func (l redactingLogger) Log(ctx context.Context, level tracelog.LogLevel, msg string, data map[string]any) {
+	sql, _ := data["sql"].(string)
+	if args, ok := data["args"].([]any); ok {
+		data["args"] = l.redaction.redact(sql, args)
+	}
+	l.next.Log(ctx, level, msg, data)
+}
@@ -0,0 +1,79 @@
+package pgxtest
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRedactedParams(t *testing.T) {
+	r := &Redaction{Columns: []string{"email", "Password"}}
+
+	for _, tc := range []struct {
+		sql      string
+		expected []int
+	}{
+		{"SELECT * FROM users WHERE email = $1 AND id = $2", []int{1}},
+		{`SELECT * FROM users WHERE "email" IN ($2, $3) OR name LIKE $1`, []int{2, 3}},
+		{"UPDATE users SET password = $1, name = $2 WHERE id = $3", []int{1}},
+		{"INSERT INTO users (id, email, password) VALUES ($1, $2, $3), ($4, lower($5), $6)", []int{2, 3, 5, 6}},
+		{"INSERT INTO users (id, name) VALUES ($1, $2)", nil},
+	} {
+		params := r.redactedParams(tc.sql)
+		if len(params) != len(tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tc.sql, tc.expected, params)
+			continue
+		}
+		for _, n := range tc.expected {
+			if !params[n] {
+				t.Errorf("%s: expected %v, got %v", tc.sql, tc.expected, params)
+			}
+		}
+	}
+}
+
+func TestRedactValues(t *testing.T) {
+	r := &Redaction{Values: []*regexp.Regexp{regexp.MustCompile(`@example\.com$`)}, Replacement: "***"}
+
+	args := r.redact("SELECT $1, $2", []any{"someone@example.com", 42})
+	if args[0] != "***" || args[1] != 42 {
+		t.Errorf("unexpected redacted arguments %v", args)
+	}
+}
+
+func TestRedactLogs(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	var buf bytes.Buffer
+	pg, err := Start(ctx, Config{
+		Logger: slog.New(slog.NewTextHandler(&buf, nil)),
+		Redact: &Redaction{Columns: []string{"email"}},
+	})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	if _, err := pg.Pool.Exec(ctx, "CREATE TABLE users (name text, email text)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	if _, err := pg.Pool.Exec(ctx, "INSERT INTO users (name, email) VALUES ($1, $2)", "visible", "secret@example.com"); err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+
+	logs := buf.String()
+	if strings.Contains(logs, "secret@example.com") {
+		t.Errorf("expected email to be redacted: %s", logs)
+	}
+	if !strings.Contains(logs, "visible") || !strings.Contains(logs, defaultRedactionReplacement) {
+		t.Errorf("expected redacted statement to be logged: %s", logs)
+	}
+}
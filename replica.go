@@ -0,0 +1,142 @@
+package pgxtest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// StaleReplica approximates a lagging read replica on a single server. Its
+// Pool sees the test database as of the last refresh: every acquired
+// connection runs in a read-only REPEATABLE READ transaction importing a
+// snapshot exported at refresh time, which is rolled back on release.
+//
+// Use it to surface read-your-writes bugs in code that sends reads to a
+// replica. Like on a replica, transactions on the Pool are read-only: Begin
+// only gets a warning from the server, as the connection is in the snapshot
+// transaction already, so the transaction sees the snapshot, fails on writes
+// and its Commit ends the snapshot transaction.
+type StaleReplica struct {
+	Pool *pgxpool.Pool
+
+	connConfig *pgx.ConnConfig
+
+	mu       sync.RWMutex
+	holder   *pgx.Conn // Keeps the exported snapshot alive
+	snapshot string
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// StaleReplica returns a replica of the test database refreshed every
+// interval. If interval is zero, the replica is refreshed only by Refresh,
+// which makes the staleness deterministic.
+func (p *PG) StaleReplica(ctx context.Context, interval time.Duration) (*StaleReplica, error) {
+	conf, err := testPoolConfig(p.Host, p.Port, p.Name, p.config)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &StaleReplica{
+		connConfig: conf.ConnConfig.Copy(),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	if err := r.Refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	conf.BeforeAcquire = r.beginSnapshot
+	conf.AfterRelease = func(conn *pgx.Conn) bool {
+		_, err := conn.Exec(context.Background(), "ROLLBACK")
+		return err == nil
+	}
+	r.Pool, err = pgxpool.NewWithConfig(ctx, conf)
+	if err != nil {
+		r.holder.Close(ctx)
+		return nil, err
+	}
+
+	go r.refreshEvery(interval)
+	return r, nil
+}
+
+func (r *StaleReplica) beginSnapshot(ctx context.Context, conn *pgx.Conn) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, err := conn.Exec(ctx, "BEGIN ISOLATION LEVEL REPEATABLE READ READ ONLY"); err != nil {
+		return false
+	}
+	if _, err := conn.Exec(ctx, "SET TRANSACTION SNAPSHOT "+quoteLiteral(r.snapshot)); err != nil {
+		_, _ = conn.Exec(ctx, "ROLLBACK")
+		return false
+	}
+	return true
+}
+
+// Refresh makes connections acquired from now on see the current state of
+// the database
+func (r *StaleReplica) Refresh(ctx context.Context) error {
+	holder, err := pgx.ConnectConfig(ctx, r.connConfig)
+	if err != nil {
+		return err
+	}
+
+	var snapshot string
+	_, err = holder.Exec(ctx, "BEGIN ISOLATION LEVEL REPEATABLE READ READ ONLY")
+	if err == nil {
+		err = holder.QueryRow(ctx, "SELECT pg_export_snapshot()").Scan(&snapshot)
+	}
+	if err != nil {
+		holder.Close(ctx)
+		return err
+	}
+
+	r.mu.Lock()
+	old := r.holder
+	r.holder, r.snapshot = holder, snapshot
+	r.mu.Unlock()
+
+	// Connections that have imported the old snapshot don't need it anymore
+	if old != nil {
+		return old.Close(ctx)
+	}
+	return nil
+}
+
+func (r *StaleReplica) refreshEvery(interval time.Duration) {
+	defer close(r.done)
+
+	if interval <= 0 {
+		<-r.stop
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			// A failed refresh leaves the replica more stale than requested
+			_ = r.Refresh(context.Background())
+		}
+	}
+}
+
+// Close closes the Pool and releases the snapshot
+func (r *StaleReplica) Close() {
+	close(r.stop)
+	<-r.done
+	r.Pool.Close()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.holder.Close(context.Background())
+}
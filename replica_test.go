@@ -0,0 +1,74 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStaleReplica(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	if _, err := pg.Pool.Exec(ctx, "CREATE TABLE test (val text)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	replica, err := pg.StaleReplica(ctx, 0)
+	if err != nil {
+		t.Fatalf("failed to create replica: %v", err)
+	}
+	defer replica.Close()
+
+	if _, err := pg.Pool.Exec(ctx, "INSERT INTO test VALUES ('written')"); err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+
+	count := func() int {
+		var n int
+		if err := replica.Pool.QueryRow(ctx, "SELECT count(*) FROM test").Scan(&n); err != nil {
+			t.Fatalf("failed to query replica: %v", err)
+		}
+		return n
+	}
+
+	if n := count(); n != 0 {
+		t.Errorf("expected stale replica to miss the write, got %d rows", n)
+	}
+
+	if _, err := replica.Pool.Exec(ctx, "INSERT INTO test VALUES ('replica')"); err == nil {
+		t.Errorf("expected write to the replica to fail")
+	}
+
+	tx, err := replica.Pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("failed to begin: %v", err)
+	}
+	var n int
+	if err := tx.QueryRow(ctx, "SELECT count(*) FROM test").Scan(&n); err != nil {
+		t.Fatalf("failed to query in transaction: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("expected transaction on the replica to see the snapshot, got %d rows", n)
+	}
+	if _, err := tx.Exec(ctx, "INSERT INTO test VALUES ('replica')"); err == nil {
+		t.Errorf("expected write in transaction on the replica to fail")
+	}
+	_ = tx.Rollback(ctx)
+
+	if err := replica.Refresh(ctx); err != nil {
+		t.Fatalf("failed to refresh replica: %v", err)
+	}
+	if n := count(); n != 1 {
+		t.Errorf("expected refreshed replica to see the write, got %d rows", n)
+	}
+}
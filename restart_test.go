@@ -0,0 +1,107 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRestart(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	if _, err := pg.Pool.Exec(ctx, "CREATE TABLE kept (val int); INSERT INTO kept VALUES (1)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	workMem := func() string {
+		var v string
+		if err := pg.Pool.QueryRow(ctx, "SHOW work_mem").Scan(&v); err != nil {
+			t.Fatalf("failed to show work_mem: %v", err)
+		}
+		return v
+	}
+
+	if err := pg.Restart(ctx, "-c", "work_mem=12MB"); err != nil {
+		t.Fatalf("failed to restart: %v", err)
+	}
+	if v := workMem(); v != "12MB" {
+		t.Errorf("expected work_mem 12MB after restart, got %s", v)
+	}
+	var n int
+	if err := pg.Pool.QueryRow(ctx, "SELECT count(*) FROM kept").Scan(&n); err != nil || n != 1 {
+		t.Errorf("expected the data to be kept, got %d rows: %v", n, err)
+	}
+
+	if err := pg.Restart(ctx); err != nil {
+		t.Fatalf("failed to restart: %v", err)
+	}
+	if v := workMem(); v == "12MB" {
+		t.Errorf("expected extra arguments to be dropped by the next restart")
+	}
+}
+
+func TestRestartAfterCrash(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	if err := pg.Crash(); err != nil {
+		t.Fatalf("failed to crash: %v", err)
+	}
+	if err := pg.Restart(ctx); err != nil {
+		t.Fatalf("failed to restart after crash: %v", err)
+	}
+	if _, err := pg.Pool.Exec(ctx, "SELECT 1"); err != nil {
+		t.Errorf("failed to query after restart: %v", err)
+	}
+}
+
+func TestRestartFailure(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	pool := pg.Pool
+	if err := pg.Restart(ctx, "-c", "no_such_setting=1"); err == nil {
+		t.Fatalf("expected restart with an invalid setting to fail")
+	}
+	if pg.Pool != pool {
+		t.Errorf("expected the Pool to be kept")
+	}
+
+	// The server is down, restarting starts it without stopping it first
+	if err := pg.Restart(ctx); err != nil {
+		t.Fatalf("failed to restart: %v", err)
+	}
+	if _, err := pg.Pool.Exec(ctx, "SELECT 1"); err != nil {
+		t.Errorf("failed to query after restart: %v", err)
+	}
+}
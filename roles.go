@@ -0,0 +1,81 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Role is a login role created at startup, to run the code under test with
+// the privileges it has in production, see PoolAs
+type Role struct {
+	Name      string
+	Password  string // Needed only if Config.Auth requires passwords
+	Superuser bool
+
+	// Privileges granted on the test database after the migrations, as GRANT
+	// statements without the GRANT keyword and the grantee, e.g.
+	// "SELECT, INSERT ON ALL TABLES IN SCHEMA public" or a role to become a
+	// member of, such as "pg_read_all_data"
+	Grants []string
+}
+
+// validateRoles checks Config.Roles
+func validateRoles(roles []Role) error {
+	seen := map[string]bool{"test": true}
+	for _, r := range roles {
+		if r.Name == "" {
+			return fmt.Errorf("Role without a name")
+		}
+		if seen[r.Name] {
+			return fmt.Errorf("Duplicate role %s", r.Name)
+		}
+		seen[r.Name] = true
+	}
+	return nil
+}
+
+// createRoles creates Config.Roles. It runs before the migrations, so that
+// they can refer to the roles, e.g. in row-level security policies.
+func createRoles(ctx context.Context, pool *pgxpool.Pool, roles []Role) error {
+	for _, r := range roles {
+		sql := "CREATE ROLE " + pgx.Identifier{r.Name}.Sanitize() + " LOGIN"
+		if r.Superuser {
+			sql += " SUPERUSER"
+		}
+		if r.Password != "" {
+			sql += " PASSWORD " + quoteLiteral(r.Password)
+		}
+		if _, err := pool.Exec(ctx, sql); err != nil {
+			return fmt.Errorf("Failed to create role %s: %w", r.Name, err)
+		}
+	}
+	return nil
+}
+
+// grantRoles applies Role.Grants of Config.Roles
+func grantRoles(ctx context.Context, pool *pgxpool.Pool, roles []Role) error {
+	for _, r := range roles {
+		for _, grant := range r.Grants {
+			sql := "GRANT " + strings.TrimSpace(grant) + " TO " + pgx.Identifier{r.Name}.Sanitize()
+			if _, err := pool.Exec(ctx, sql); err != nil {
+				return fmt.Errorf("Failed to grant %s to %s: %w", grant, r.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// PoolAs returns a pool connected to the test database as the role from
+// Config.Roles. Pools are created on first use and closed by Stop.
+func (p *PG) PoolAs(role string) (*pgxpool.Pool, error) {
+	for _, r := range p.config.Roles {
+		if r.Name == role {
+			return p.pool(p.Name, r)
+		}
+	}
+	return nil, fmt.Errorf("Role %s not found in Config.Roles", role)
+}
@@ -0,0 +1,67 @@
+package pgxtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestRoles(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{
+		Roles: []Role{
+			{Name: "app", Grants: []string{"SELECT, INSERT ON ALL TABLES IN SCHEMA public"}},
+			{Name: "admin", Superuser: true},
+		},
+		Migrate: func(ctx context.Context, pool *pgxpool.Pool) error {
+			_, err := pool.Exec(ctx, "CREATE TABLE orders (id int)")
+			return err
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	app, err := pg.PoolAs("app")
+	if err != nil {
+		t.Fatalf("failed to get pool: %v", err)
+	}
+	var user string
+	if err := app.QueryRow(ctx, "SELECT current_user").Scan(&user); err != nil {
+		t.Fatalf("failed to query current user: %v", err)
+	}
+	if user != "app" {
+		t.Errorf("expected to be connected as app, got %s", user)
+	}
+	if _, err := app.Exec(ctx, "INSERT INTO orders VALUES (1)"); err != nil {
+		t.Errorf("failed to insert as app: %v", err)
+	}
+
+	var pgErr *pgconn.PgError
+	_, err = app.Exec(ctx, "DELETE FROM orders")
+	if !errors.As(err, &pgErr) || pgErr.Code != "42501" {
+		t.Errorf("expected insufficient privilege error, got %v", err)
+	}
+
+	if _, err := pg.PoolAs("missing"); err == nil {
+		t.Errorf("expected an error for a role not in Config.Roles")
+	}
+}
+
+func TestValidateRoles(t *testing.T) {
+	for _, roles := range [][]Role{{{Name: "test"}}, {{}}, {{Name: "a"}, {Name: "a"}}} {
+		if err := validateRoles(roles); err == nil {
+			t.Errorf("%v: expected an error", roles)
+		}
+	}
+}
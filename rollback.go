@@ -0,0 +1,47 @@
+package pgxtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrRollbackOnly is returned by Commit of the transaction passed to the body
+// of WithRollback
+var ErrRollbackOnly = errors.New("Transaction of WithRollback can't be committed")
+
+// WithRollback runs fn in a transaction on the test database that is always
+// rolled back, so changes made by fn are never seen by other tests. The test
+// fails if fn returns an error.
+//
+// The transaction can't be committed, use tx.Begin for savepoints. Code under
+// test must use tx rather than the Pool, and can't observe committed data
+// (e.g. from another connection or after LISTEN/NOTIFY delivery).
+func (p *PG) WithRollback(ctx context.Context, t testing.TB, fn func(tx pgx.Tx) error) {
+	t.Helper()
+
+	tx, err := p.Pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+	defer func() {
+		if err := tx.Rollback(context.Background()); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			t.Errorf("failed to roll back transaction: %v", err)
+		}
+	}()
+
+	if err := fn(rollbackOnlyTx{tx}); err != nil {
+		t.Fatalf("failed in transaction: %v", err)
+	}
+}
+
+// rollbackOnlyTx refuses to commit the transaction
+type rollbackOnlyTx struct {
+	pgx.Tx
+}
+
+func (tx rollbackOnlyTx) Commit(ctx context.Context) error {
+	return ErrRollbackOnly
+}
@@ -0,0 +1,53 @@
+package pgxtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestWithRollback(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	if _, err := pg.Pool.Exec(ctx, "CREATE TABLE items (name text)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	pg.WithRollback(ctx, t, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, "INSERT INTO items VALUES ('a')"); err != nil {
+			return err
+		}
+		var n int
+		if err := tx.QueryRow(ctx, "SELECT count(*) FROM items").Scan(&n); err != nil {
+			return err
+		}
+		if n != 1 {
+			t.Errorf("expected 1 row within transaction, got %d", n)
+		}
+		if err := tx.Commit(ctx); !errors.Is(err, ErrRollbackOnly) {
+			t.Errorf("expected ErrRollbackOnly from Commit, got %v", err)
+		}
+		return nil
+	})
+
+	var n int
+	if err := pg.Pool.QueryRow(ctx, "SELECT count(*) FROM items").Scan(&n); err != nil {
+		t.Fatalf("failed to count rows: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("expected 0 rows after rollback, got %d", n)
+	}
+}
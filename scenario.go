@@ -0,0 +1,168 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Availability is a state of the server in a Scenario
+type Availability int
+
+const (
+	Up        Availability = iota // Server accepts connections
+	Down                          // Server is stopped, connections are refused
+	Suspended                     // Server processes are frozen, connections and queries hang
+)
+
+func (a Availability) String() string {
+	switch a {
+	case Up:
+		return "up"
+	case Down:
+		return "down"
+	case Suspended:
+		return "suspended"
+	}
+	return fmt.Sprintf("Availability(%d)", int(a))
+}
+
+// Step keeps the server in a state for a while
+type Step struct {
+	State    Availability
+	Duration time.Duration
+}
+
+// Scenario scripts the availability of the server over time, to test that
+// the application's connection handling (retries, backoff, pool health
+// checks) recovers from outages. For example, down for 5s, up for 1s, frozen
+// for 2s:
+//
+//	pg.RunScenario(ctx, t, pgxtest.Scenario{
+//		Steps: []pgxtest.Step{{pgxtest.Down, 5 * time.Second}, {pgxtest.Up, time.Second}, {pgxtest.Suspended, 2 * time.Second}},
+//		Probe: func(ctx context.Context) error { return app.Ping(ctx) },
+//		SLA:   3 * time.Second,
+//	})
+type Scenario struct {
+	Steps []Step
+
+	// Exercises the application, e.g. a request using its pool. Called
+	// repeatedly once the server is back up, until it succeeds
+	Probe func(ctx context.Context) error
+
+	// Time the Probe must succeed within after the server is back up
+	SLA time.Duration
+}
+
+// Interval between calls of Scenario.Probe
+const probeInterval = 50 * time.Millisecond
+
+// RunScenario plays the steps of the scenario, brings the server up and fails
+// the test unless the probe succeeds within the SLA. Connections of the Pool
+// are broken by the outages, and it recovers like any other pool.
+func (p *PG) RunScenario(ctx context.Context, t testing.TB, s Scenario) {
+	t.Helper()
+
+	if p.cmd == nil {
+		t.Fatalf("availability scenarios need a server started by LocalBackend")
+	}
+	if err := p.pauseExpiry(); err != nil {
+		t.Fatalf("failed to run scenario: %v", err)
+	}
+	defer p.resumeExpiry()
+
+	state := Up
+	var frozen []int
+	setState := func(next Availability) error {
+		if next == state {
+			return nil
+		}
+		// Back to up first, then to the next state
+		switch state {
+		case Down:
+			if err := p.restartServer(ctx); err != nil {
+				return err
+			}
+		case Suspended:
+			if err := freezeProcesses(frozen, false); err != nil {
+				return err
+			}
+		}
+		state = Up
+
+		switch next {
+		case Down:
+			if err := p.stopServer(); err != nil {
+				return err
+			}
+		case Suspended:
+			pids, err := p.serverProcesses(ctx)
+			if err != nil {
+				return err
+			}
+			if err := freezeProcesses(pids, true); err != nil {
+				return err
+			}
+			frozen = pids
+		}
+		state = next
+		return nil
+	}
+
+	for i, step := range s.Steps {
+		if err := setState(step.State); err != nil {
+			_ = setState(Up)
+			t.Fatalf("failed to make server %s in step %d: %v", step.State, i, err)
+		}
+		select {
+		case <-ctx.Done():
+			_ = setState(Up)
+			t.Fatalf("scenario interrupted: %v", ctx.Err())
+		case <-time.After(step.Duration):
+		}
+	}
+	if err := setState(Up); err != nil {
+		t.Fatalf("failed to bring server up: %v", err)
+	}
+
+	if s.Probe == nil {
+		return
+	}
+	up := time.Now()
+	probeCtx, cancel := context.WithTimeout(ctx, s.SLA)
+	defer cancel()
+	for {
+		err := s.Probe(probeCtx)
+		if err == nil {
+			t.Logf("recovered in %s", time.Since(up).Round(time.Millisecond))
+			return
+		}
+		select {
+		case <-probeCtx.Done():
+			t.Fatalf("not recovered within %s after the server came up: %v", s.SLA, err)
+		case <-time.After(probeInterval):
+		}
+	}
+}
+
+// serverProcesses returns the PIDs of the postmaster and the processes it
+// has started
+func (p *PG) serverProcesses(ctx context.Context) ([]int, error) {
+	var pids []int
+	err := withAdminConn(ctx, p.Host, p.Port, func(conn *pgx.Conn) error {
+		rows, err := conn.Query(ctx, "SELECT pid FROM pg_stat_activity")
+		if err != nil {
+			return err
+		}
+		pids, err = pgx.CollectRows(rows, pgx.RowTo[int])
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The postmaster last, so that it does not notice the others stopping
+	return append(pids, p.cmd.Process.Pid), nil
+}
@@ -0,0 +1,47 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRunScenario(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg := StartT(t, Config{})
+
+	if _, err := pg.Pool.Exec(ctx, "CREATE TABLE t (id int)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	pg.RunScenario(ctx, t, Scenario{
+		Steps: []Step{
+			{Down, 200 * time.Millisecond},
+			{Up, 100 * time.Millisecond},
+			{Suspended, 200 * time.Millisecond},
+		},
+		Probe: func(ctx context.Context) error {
+			ctx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+			defer cancel()
+			_, err := pg.Pool.Exec(ctx, "INSERT INTO t VALUES (1)")
+			return err
+		},
+		SLA: 5 * time.Second,
+	})
+
+	var n int
+	if err := pg.Pool.QueryRow(ctx, "SELECT count(*) FROM t").Scan(&n); err != nil {
+		t.Fatalf("failed to query after scenario: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 row, got %d", n)
+	}
+}
+
+func TestAvailabilityString(t *testing.T) {
+	if Suspended.String() != "suspended" || Availability(7).String() != "Availability(7)" {
+		t.Errorf("unexpected names %s, %s", Suspended, Availability(7))
+	}
+}
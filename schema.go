@@ -0,0 +1,130 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Assertions on the schema of the test database, e.g. for testing migrations.
+// Tables are named as in SQL, optionally schema-qualified, and resolved with
+// the search_path.
+
+// AssertTableExists fails the test unless the table (or partitioned table)
+// exists
+func (p *PG) AssertTableExists(ctx context.Context, t testing.TB, table string) {
+	t.Helper()
+
+	var kind *string
+	if err := p.Pool.QueryRow(ctx, "SELECT relkind::text FROM pg_class WHERE oid = to_regclass($1)", table).Scan(&kind); err != nil && err != pgx.ErrNoRows {
+		t.Fatalf("failed to look up table %s: %v", table, err)
+	}
+	if kind == nil {
+		t.Fatalf("expected table %s to exist", table)
+	}
+	if *kind != "r" && *kind != "p" {
+		t.Fatalf("expected %s to be a table, got relkind %s", table, *kind)
+	}
+}
+
+// AssertColumn fails the test unless the column of the table has the
+// definition: its type, optionally followed by NOT NULL and DEFAULT, e.g.
+// "varchar(255) NOT NULL" or "timestamptz NOT NULL DEFAULT now()". Types and
+// defaults are compared as normalized by the server, so aliases match. The
+// default is only checked if the definition has one.
+func (p *PG) AssertColumn(ctx context.Context, t testing.TB, table string, column string, definition string) {
+	t.Helper()
+
+	p.AssertTableExists(ctx, t, table)
+	actual, found, err := columnDefinition(ctx, p.Pool, table, column)
+	if err != nil {
+		t.Fatalf("failed to look up column %s.%s: %v", table, column, err)
+	}
+	if !found {
+		t.Fatalf("expected column %s.%s to exist", table, column)
+	}
+	expected, err := normalizeColumnDefinition(ctx, p.Pool, definition)
+	if err != nil {
+		t.Fatalf("failed to parse column definition %q: %v", definition, err)
+	}
+	if expected.def == "" {
+		actual.def = ""
+	}
+	if actual != expected {
+		t.Fatalf("expected column %s.%s to be %s, got %s", table, column, expected, actual)
+	}
+}
+
+// AssertIndexExists fails the test unless the table has the index with the
+// name
+func (p *PG) AssertIndexExists(ctx context.Context, t testing.TB, table string, index string) {
+	t.Helper()
+
+	p.AssertTableExists(ctx, t, table)
+	var exists bool
+	err := p.Pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT FROM pg_index i JOIN pg_class c ON c.oid = i.indexrelid
+			WHERE i.indrelid = to_regclass($1) AND c.relname = $2)`, table, index).Scan(&exists)
+	if err != nil {
+		t.Fatalf("failed to look up index %s: %v", index, err)
+	}
+	if !exists {
+		t.Fatalf("expected table %s to have index %s", table, index)
+	}
+}
+
+// Definition of a column as the server prints it
+type columnDef struct {
+	typ     string
+	notNull bool
+	def     string // Default expression, empty if none
+}
+
+func (c columnDef) String() string {
+	s := c.typ
+	if c.notNull {
+		s += " NOT NULL"
+	}
+	if c.def != "" {
+		s += " DEFAULT " + c.def
+	}
+	return s
+}
+
+// queryer is satisfied by pools and transactions
+type queryer interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// columnDefinition reads the definition of the column of the table
+func columnDefinition(ctx context.Context, q queryer, table string, name string) (columnDef, bool, error) {
+	var c columnDef
+	err := q.QueryRow(ctx, `
+		SELECT format_type(a.atttypid, a.atttypmod), a.attnotnull, coalesce(pg_get_expr(d.adbin, d.adrelid), '')
+		FROM pg_attribute a LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE a.attrelid = to_regclass($1) AND a.attname = $2 AND a.attnum > 0 AND NOT a.attisdropped`,
+		table, name).Scan(&c.typ, &c.notNull, &c.def)
+	if err == pgx.ErrNoRows {
+		return c, false, nil
+	}
+	return c, err == nil, err
+}
+
+// normalizeColumnDefinition has the server normalize the column definition by
+// creating a temporary table with it in a transaction that is rolled back
+func normalizeColumnDefinition(ctx context.Context, pool *pgxpool.Pool, definition string) (columnDef, error) {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return columnDef{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "CREATE TEMPORARY TABLE pgxtest_column (c "+definition+")"); err != nil {
+		return columnDef{}, err
+	}
+	c, _, err := columnDefinition(ctx, tx, "pg_temp.pgxtest_column", "c")
+	return c, err
+}
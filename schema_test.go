@@ -0,0 +1,48 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSchemaAssertions(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg := StartT(t, Config{
+		InitScripts: []string{`
+			CREATE SCHEMA app;
+			CREATE TABLE app.users (
+				id bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+				email character varying(255) NOT NULL,
+				created_at timestamp with time zone NOT NULL DEFAULT now(),
+				note text
+			);
+			CREATE UNIQUE INDEX users_email_idx ON app.users (email);
+		`},
+	})
+
+	pg.AssertTableExists(ctx, t, "app.users")
+	pg.AssertColumn(ctx, t, "app.users", "email", "varchar(255) NOT NULL")
+	pg.AssertColumn(ctx, t, "app.users", "created_at", "timestamptz NOT NULL DEFAULT now()")
+	pg.AssertColumn(ctx, t, "app.users", "created_at", "timestamptz NOT NULL")
+	pg.AssertColumn(ctx, t, "app.users", "note", "text")
+	pg.AssertIndexExists(ctx, t, "app.users", "users_email_idx")
+
+	actual, found, err := columnDefinition(ctx, pg.Pool, "app.users", "email")
+	if err != nil || !found {
+		t.Fatalf("failed to look up column: %v", err)
+	}
+	for _, definition := range []string{"text NOT NULL", "varchar(100) NOT NULL", "varchar(255)"} {
+		expected, err := normalizeColumnDefinition(ctx, pg.Pool, definition)
+		if err != nil {
+			t.Fatalf("failed to normalize %q: %v", definition, err)
+		}
+		if expected == actual {
+			t.Errorf("expected %q not to match %s", definition, actual)
+		}
+	}
+	if _, found, err := columnDefinition(ctx, pg.Pool, "app.users", "missing"); err != nil || found {
+		t.Errorf("expected a missing column not to be found, got %v, %v", found, err)
+	}
+}
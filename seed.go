@@ -0,0 +1,60 @@
+package pgxtest
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Environment variable setting the master seed, to reproduce a failure
+const seedEnv = "PGXTEST_SEED"
+
+// masterSeed is the seed all per-test seeds are derived from: PGXTEST_SEED if
+// set, random otherwise
+var masterSeed = sync.OnceValues(func() (int64, error) {
+	if s := os.Getenv(seedEnv); s != "" {
+		seed, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("Failed to parse %s: %w", seedEnv, err)
+		}
+		return seed, nil
+	}
+	return time.Now().UnixNano(), nil
+})
+
+// Rand returns a source of randomness for the test, e.g. for generated data
+// or fault schedules. Its seed is derived from the master seed and the name of
+// the test, so a test gets the same values regardless of which other tests
+// run.
+//
+// If the test fails, the master seed is logged together with the way to
+// reproduce the run: PGXTEST_SEED=seed go test -run ...
+func Rand(t testing.TB) *rand.Rand {
+	t.Helper()
+
+	master, err := masterSeed()
+	if err != nil {
+		t.Fatalf("failed to get seed: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if t.Failed() {
+			t.Logf("pgxtest: random seed %d, reproduce with %s=%d go test -run '^%s$'", master, seedEnv, master, t.Name())
+		}
+	})
+	return rand.New(rand.NewSource(testSeed(master, t.Name())))
+}
+
+// testSeed derives the seed of the named test from the master seed
+func testSeed(master int64, name string) int64 {
+	h := fnv.New64a()
+	_ = binary.Write(h, binary.LittleEndian, master)
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
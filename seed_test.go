@@ -0,0 +1,23 @@
+package pgxtest
+
+import (
+	"testing"
+)
+
+func TestRand(t *testing.T) {
+	if Rand(t).Int63() != Rand(t).Int63() {
+		t.Errorf("expected the same values for the same test")
+	}
+}
+
+func TestTestSeed(t *testing.T) {
+	if testSeed(42, "TestA") != testSeed(42, "TestA") {
+		t.Errorf("expected seed to be deterministic")
+	}
+	if testSeed(42, "TestA") == testSeed(42, "TestB") {
+		t.Errorf("expected different seeds for different tests")
+	}
+	if testSeed(42, "TestA") == testSeed(43, "TestA") {
+		t.Errorf("expected different seeds for different master seeds")
+	}
+}
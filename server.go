@@ -0,0 +1,192 @@
+package pgxtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// initDB creates a new cluster in dataDir
+func initDB(binPath string, dataDir string, args []string, outputLimit int) (*ringBuffer, *ringBuffer, error) {
+	init := prepareCommand(filepath.Join(binPath, "initdb"),
+		append([]string{"-D", dataDir}, args...)...,
+	)
+	stdout := newRingBuffer(outputLimit)
+	stderr := newRingBuffer(outputLimit)
+	init.Stdout = stdout
+	init.Stderr = stderr
+	if err := init.Run(); err != nil {
+		return stdout, stderr, fmt.Errorf("Failed to initialize DB: %w -> %s%s", err, stdout, stderr)
+	}
+	return stdout, stderr, nil
+}
+
+// Time waiting for the server gives the processes it has started (backends,
+// archive_command) to close their copies of its stdout and stderr after it
+// has exited, so that a killed server can't keep Stop waiting
+const serverWaitDelay = 5 * time.Second
+
+// launch starts the postgres server on dataDir. Output of the server is
+// captured instead of piped, so that the server never blocks on unread output.
+// The output is copied to serverLog and Config.LogFile if set.
+func launch(binPath string, dataDir string, args []string, config Config, serverLog *serverLogWriter) (*exec.Cmd, *ringBuffer, *ringBuffer, error) {
+	postgres := filepath.Join(binPath, "postgres")
+	args = append([]string{"-D", dataDir}, args...)
+
+	var cmd *exec.Cmd
+	if config.CoreDumpDir != "" {
+		// Go can't set resource limits of a child alone. The shell execs the
+		// server, so the PID stays the same
+		cmd = prepareCommand("/bin/sh", append([]string{"-c", `ulimit -c unlimited && exec "$0" "$@"`, postgres}, args...)...)
+	} else {
+		cmd = prepareCommand(postgres, args...)
+	}
+	cmd.WaitDelay = serverWaitDelay
+
+	stdout := newRingBuffer(config.OutputLimit)
+	stderr := newRingBuffer(config.OutputLimit)
+	var err error
+	if cmd.Stdout, cmd.Stderr, err = serverWriters(config, serverLog, stdout, stderr); err != nil {
+		return cmd, stdout, stderr, err
+	}
+
+	return cmd, stdout, stderr, cmd.Start()
+}
+
+// waitReady waits until the server accepts connections
+func waitReady(ctx context.Context, sockDir string, port int) error {
+	conf, err := postgresqlDBConf(sockDir, port, "postgres")
+	if err != nil {
+		return err
+	}
+	return retry(func() error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		conn, err := pgx.ConnectConfig(ctx, conf.ConnConfig)
+		if err != nil {
+			return err
+		}
+		return conn.Close(ctx)
+	}, 1000, 10*time.Millisecond)
+}
+
+// stopServer stops the server, keeping the data directory
+func (p *PG) stopServer() error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultShutdownTimeout)
+	defer cancel()
+	return p.shutdownServer(ctx, ShutdownFast)
+}
+
+// Restart cleanly stops the server and starts it again on the same data
+// directory, with extraArgs added to the arguments of postgres (e.g. "-c",
+// "shared_preload_libraries=pg_stat_statements") until the next Restart. The
+// Pool is replaced by a new one, pools of PoolFor and PoolAs reconnect.
+//
+// A crashed server (see Crash) is started again without a clean shutdown,
+// running recovery. If the server fails to start, the Pool is kept and
+// reconnects once the server is started again.
+//
+// Use it to apply settings requiring a restart, or to test recovery and
+// reconnection of the application. Expired instances can't be restarted, see
+// ErrInstanceExpired.
+func (p *PG) Restart(ctx context.Context, extraArgs ...string) error {
+	if p.cmd == nil {
+		return fmt.Errorf("the server is not owned by this instance")
+	}
+	if err := p.pauseExpiry(); err != nil {
+		return err
+	}
+	defer p.resumeExpiry()
+
+	// The Pool is kept in case the server fails to start again
+	p.Pool.Reset()
+	if !p.crashed {
+		if err := p.stopServer(); err != nil {
+			return err
+		}
+	}
+	p.extraArgs = extraArgs
+	if err := p.relaunch(ctx); err != nil {
+		return fmt.Errorf("Failed to restart PostgreSQL: %w", err)
+	}
+	return nil
+}
+
+// relaunch starts the server again on the current data directory, waits for
+// it to become ready and replaces the Pool. The Pool is kept if the server
+// fails to start.
+func (p *PG) relaunch(ctx context.Context) error {
+	if err := p.restartServer(ctx); err != nil {
+		return err
+	}
+
+	conf, err := testPoolConfig(p.Host, p.Port, p.Name, p.config)
+	if err != nil {
+		return err
+	}
+	conf.BeforeConnect = refuseExpired(p.expired)
+	pool, err := pgxpool.NewWithConfig(ctx, conf)
+	if err != nil {
+		return err
+	}
+	old := p.Pool
+	p.Pool = pool
+	old.Close()
+	if p.poolSampler != nil {
+		p.poolSampler.SetPool(pool)
+	}
+
+	// The server may have been upgraded
+	p.MajorVersion, err = serverMajorVersion(ctx, pool)
+	return err
+}
+
+// restartServer starts the server stopped by stopServer again and waits for
+// it to become ready. The Pool is kept and reconnects.
+func (p *PG) restartServer(ctx context.Context) (err error) {
+	args := append(slices.Clip(p.serverArgs), p.extraArgs...)
+	serverLog := newServerLogWriter(p.config.ServerLogger)
+	defer func() {
+		if err != nil {
+			serverLog.Close()
+			// Not running, there is nothing to stop
+			p.crashed = true
+		}
+	}()
+	cmd, stdout, stderr, err := launch(p.binPath, p.dataDir, args, p.config, serverLog)
+	if err != nil {
+		return abort("Failed to start PostgreSQL", cmd, stderr, stdout, err)
+	}
+	p.cmd, p.stdout, p.stderr, p.serverLog = cmd, stdout, stderr, serverLog
+
+	if md, err := readMetadata(p.dir); err == nil {
+		md.PID = cmd.Process.Pid
+		md.DataDir = p.dataDir
+		if err := writeMetadata(p.dir, md); err != nil {
+			return abort("Failed to write instance metadata", cmd, stderr, stdout, err)
+		}
+	}
+
+	if err := waitReady(ctx, p.Host, p.Port); err != nil {
+		return abort("PostgreSQL did not become ready", cmd, stderr, stdout, err)
+	}
+	p.crashed = false
+	return nil
+}
+
+// stderrOf returns the error output captured by exec.Cmd.Output
+func stderrOf(err error) string {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return string(exitErr.Stderr)
+	}
+	return ""
+}
@@ -0,0 +1,29 @@
+package pgxtest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLaunchWaitDelay(t *testing.T) {
+	t.Parallel()
+
+	// A server exiting while a child it has started holds its stderr
+	binPath := t.TempDir()
+	script := "#!/bin/sh\nsleep 30 &\nexit 0\n"
+	if err := os.WriteFile(filepath.Join(binPath, "postgres"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd, _, _, err := launch(binPath, t.TempDir(), nil, Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to launch: %v", err)
+	}
+	start := time.Now()
+	_ = cmd.Wait()
+	if elapsed := time.Since(start); elapsed > 2*serverWaitDelay {
+		t.Errorf("expected Wait to return after %s, took %s", serverWaitDelay, elapsed)
+	}
+}
@@ -0,0 +1,173 @@
+package pgxtest
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// log_line_prefix set for Config.ServerLogger: time, PID and SQLSTATE
+const serverLogPrefix = "%m [%p] %e "
+
+// How long an entry is held for the lines following it (DETAIL, STATEMENT...)
+// before it is logged
+const serverLogDelay = 50 * time.Millisecond
+
+// A line of the server log: prefix, optional SQLSTATE, severity and message
+var serverLogLine = regexp.MustCompile(`^(?:.*?\s)?(?:([0-9A-Z]{5}) )?(DEBUG[1-5]|INFO|NOTICE|WARNING|ERROR|LOG|FATAL|PANIC|DETAIL|HINT|QUERY|CONTEXT|LOCATION|STATEMENT):  (.*)$`)
+
+// Severities of the lines adding to the preceding entry
+var serverLogDetails = map[string]string{
+	"DETAIL":    "detail",
+	"HINT":      "hint",
+	"QUERY":     "query",
+	"CONTEXT":   "context",
+	"LOCATION":  "location",
+	"STATEMENT": "statement",
+}
+
+// A server log entry being collected
+type serverLogEntry struct {
+	level   slog.Level
+	message string
+	attrs   []slog.Attr
+}
+
+// serverLogWriter parses the server log written to it and logs its entries
+type serverLogWriter struct {
+	logger *slog.Logger
+
+	mu     sync.Mutex
+	buf    []byte
+	entry  *serverLogEntry
+	timer  *time.Timer
+	closed bool
+}
+
+// newServerLogWriter returns a writer logging to logger, or nil if logger is
+// nil
+func newServerLogWriter(logger *slog.Logger) *serverLogWriter {
+	if logger == nil {
+		return nil
+	}
+	return &serverLogWriter{logger: logger}
+}
+
+func (w *serverLogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return len(p), nil
+	}
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.line(strings.TrimSuffix(string(w.buf[:i]), "\r"))
+		w.buf = w.buf[i+1:]
+	}
+
+	// Log the last entry unless more lines of it follow soon
+	if w.entry != nil {
+		if w.timer == nil {
+			w.timer = time.AfterFunc(serverLogDelay, func() {
+				w.mu.Lock()
+				defer w.mu.Unlock()
+				w.flush()
+			})
+		} else {
+			w.timer.Reset(serverLogDelay)
+		}
+	}
+	return len(p), nil
+}
+
+// Close logs the pending entry and the incomplete last line, and stops
+// logging. Call it once the server has exited, so that its last entries are
+// logged before Stop returns rather than after the test has completed.
+func (w *serverLogWriter) Close() {
+	if w == nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	if len(w.buf) > 0 {
+		w.line(string(w.buf))
+		w.buf = nil
+	}
+	w.flush()
+	w.closed = true
+}
+
+// line adds a complete line to the current entry or starts a new one
+func (w *serverLogWriter) line(line string) {
+	// Multi-line messages and statements continue with tabs
+	if strings.HasPrefix(line, "\t") && w.entry != nil {
+		e := w.entry
+		if len(e.attrs) == 0 {
+			e.message += "\n" + line[1:]
+		} else {
+			last := &e.attrs[len(e.attrs)-1]
+			last.Value = slog.StringValue(last.Value.String() + "\n" + line[1:])
+		}
+		return
+	}
+
+	m := serverLogLine.FindStringSubmatch(line)
+	if m == nil {
+		w.flush()
+		w.logger.Info(line)
+		return
+	}
+	sqlState, severity, message := m[1], m[2], m[3]
+
+	if key, ok := serverLogDetails[severity]; ok && w.entry != nil {
+		w.entry.attrs = append(w.entry.attrs, slog.String(key, message))
+		return
+	}
+
+	w.flush()
+	w.entry = &serverLogEntry{
+		level:   serverLogLevel(severity),
+		message: message,
+		attrs:   []slog.Attr{slog.String("severity", severity)},
+	}
+	if sqlState != "" && sqlState != "00000" {
+		w.entry.attrs = append(w.entry.attrs, slog.String("sqlstate", sqlState))
+	}
+}
+
+// flush logs the current entry
+func (w *serverLogWriter) flush() {
+	if w.entry == nil {
+		return
+	}
+	w.logger.LogAttrs(context.Background(), w.entry.level, w.entry.message, w.entry.attrs...)
+	w.entry = nil
+}
+
+// serverLogLevel maps the severity of a server log entry to a slog level
+func serverLogLevel(severity string) slog.Level {
+	switch {
+	case strings.HasPrefix(severity, "DEBUG"):
+		return slog.LevelDebug
+	case severity == "WARNING":
+		return slog.LevelWarn
+	case severity == "ERROR" || severity == "FATAL" || severity == "PANIC":
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
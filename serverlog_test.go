@@ -0,0 +1,168 @@
+package pgxtest
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingHandler keeps the records logged through it
+type recordingHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *recordingHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h *recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *recordingHandler) Records() []slog.Record {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]slog.Record(nil), h.records...)
+}
+
+func recordAttrs(r slog.Record) map[string]string {
+	attrs := map[string]string{}
+	r.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value.String()
+		return true
+	})
+	return attrs
+}
+
+func TestServerLogWriter(t *testing.T) {
+	h := &recordingHandler{}
+	w := newServerLogWriter(slog.New(h))
+
+	w.Write([]byte("2024-05-01 10:00:00.000 UTC [42] 00000 LOG:  database system is ready to accept connections\n" +
+		"2024-05-01 10:00:01.000 UTC [43] 42P01 ERROR:  relation \"missing\" does not exist at character 15\n" +
+		"2024-05-01 10:00:01.000 UTC [43] 42P01 STATEMENT:  SELECT * FROM\n\tmissing\n" +
+		"2024-05-01 10:00:02.000 UTC [44] 01000 WARN"))
+	w.Write([]byte("ING:  careful\n" +
+		"2024-05-01 10:00:02.000 UTC [44] 01000 HINT:  be careful\n" +
+		"not a log line\n"))
+
+	records := h.Records()
+	if len(records) != 4 {
+		t.Fatalf("expected 4 records, got %d", len(records))
+	}
+
+	expected := []struct {
+		level   slog.Level
+		message string
+		attrs   map[string]string
+	}{
+		{slog.LevelInfo, "database system is ready to accept connections", map[string]string{"severity": "LOG"}},
+		{slog.LevelError, `relation "missing" does not exist at character 15`,
+			map[string]string{"severity": "ERROR", "sqlstate": "42P01", "statement": "SELECT * FROM\nmissing"}},
+		{slog.LevelWarn, "careful", map[string]string{"severity": "WARNING", "sqlstate": "01000", "hint": "be careful"}},
+		{slog.LevelInfo, "not a log line", map[string]string{}},
+	}
+	for i, e := range expected {
+		r := records[i]
+		if r.Level != e.level || r.Message != e.message {
+			t.Errorf("record %d: expected %s %q, got %s %q", i, e.level, e.message, r.Level, r.Message)
+		}
+		attrs := recordAttrs(r)
+		if len(attrs) != len(e.attrs) {
+			t.Errorf("record %d: expected attributes %v, got %v", i, e.attrs, attrs)
+			continue
+		}
+		for k, v := range e.attrs {
+			if attrs[k] != v {
+				t.Errorf("record %d: expected attributes %v, got %v", i, e.attrs, attrs)
+			}
+		}
+	}
+}
+
+func TestServerLogWriterFlushes(t *testing.T) {
+	h := &recordingHandler{}
+	w := newServerLogWriter(slog.New(h))
+
+	// Default log_line_prefix of the server
+	w.Write([]byte("2024-05-01 10:00:00.000 UTC [42] FATAL:  terminating connection\n"))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(h.Records()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the entry to be logged")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	r := h.Records()[0]
+	if r.Level != slog.LevelError || r.Message != "terminating connection" {
+		t.Errorf("unexpected record: %s %q", r.Level, r.Message)
+	}
+}
+
+func TestServerLogWriterClose(t *testing.T) {
+	h := &recordingHandler{}
+	w := newServerLogWriter(slog.New(h))
+
+	w.Write([]byte("LOG:  shutting down\nLOG:  database system is shut down"))
+	w.Close()
+	w.Write([]byte("LOG:  ignored\n"))
+
+	records := h.Records()
+	if len(records) != 2 || records[1].Message != "database system is shut down" {
+		t.Fatalf("expected the pending entries to be logged on Close, got %v", records)
+	}
+
+	time.Sleep(2 * serverLogDelay)
+	if len(h.Records()) != 2 {
+		t.Errorf("expected nothing logged after Close, got %v", h.Records())
+	}
+
+	var none *serverLogWriter
+	none.Close()
+}
+
+func TestServerLogger(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	h := &recordingHandler{}
+	pg, err := Start(ctx, Config{ServerLogger: slog.New(h)})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err := pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+		// The last entries are logged by the time Stop returns
+		records := h.Records()
+		if last := records[len(records)-1]; last.Message != "database system is shut down" {
+			t.Errorf("expected the shutdown to be logged last, got %q", last.Message)
+		}
+	}()
+
+	if _, err := pg.Pool.Exec(ctx, "SELECT * FROM missing"); err == nil {
+		t.Fatalf("expected the query to fail")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		for _, r := range h.Records() {
+			attrs := recordAttrs(r)
+			if r.Level == slog.LevelError && attrs["sqlstate"] == "42P01" && attrs["statement"] == "SELECT * FROM missing" {
+				return
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the error to be logged")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,44 @@
+package pgxtest
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Name of a configuration parameter, including custom ones of extensions
+// (auto_explain.log_min_duration)
+var settingName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// validateSettings checks Config.Settings before they are passed to the
+// server, so that mistakes are reported by name instead of as a server that
+// failed to start
+func validateSettings(settings map[string]string) error {
+	for name, value := range settings {
+		if !settingName.MatchString(name) {
+			return fmt.Errorf("Invalid setting name %q", name)
+		}
+		if strings.ContainsAny(value, "\x00\n\r") {
+			return fmt.Errorf("Invalid value of setting %s: %q", name, value)
+		}
+	}
+	return nil
+}
+
+// settingArgs returns -c arguments of postgres for the settings, sorted by
+// name
+func settingArgs(settings map[string]string) []string {
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var args []string
+	for _, name := range names {
+		// Passed as a separate argument, the value needs no quoting
+		args = append(args, "-c", name+"="+settings[name])
+	}
+	return args
+}
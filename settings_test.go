@@ -0,0 +1,49 @@
+package pgxtest
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestValidateSettings(t *testing.T) {
+	valid := map[string]string{"work_mem": "64MB", "auto_explain.log_min_duration": "0", "search_path": `"$user", public`}
+	if err := validateSettings(valid); err != nil {
+		t.Errorf("expected settings to be valid: %v", err)
+	}
+	for _, invalid := range []map[string]string{
+		{"work mem": "64MB"},
+		{"-c": "x"},
+		{"work_mem=1": "64MB"},
+		{"log_line_prefix": "a\nb"},
+	} {
+		if err := validateSettings(invalid); err == nil {
+			t.Errorf("expected %q to be invalid", invalid)
+		}
+	}
+}
+
+func TestPlanSettings(t *testing.T) {
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, "initdb"), nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := Plan(Config{
+		BinDir:         binDir,
+		Settings:       map[string]string{"work_mem": "64MB", "search_path": "app, public"},
+		AdditionalArgs: []string{"-c", "work_mem=1MB"},
+	})
+	if err != nil {
+		t.Fatalf("failed to plan: %v", err)
+	}
+	expected := []string{"-c", "search_path=app, public", "-c", "work_mem=64MB", "-c", "work_mem=1MB"}
+	if !slices.Equal(plan.Server[len(plan.Server)-len(expected):], expected) {
+		t.Errorf("expected settings followed by additional arguments, got %q", plan.Server)
+	}
+
+	if _, err := Plan(Config{BinDir: binDir, Settings: map[string]string{"bad name": "1"}}); err == nil {
+		t.Errorf("expected invalid setting to fail")
+	}
+}
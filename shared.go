@@ -0,0 +1,332 @@
+package pgxtest
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Shared instances live in the user cache directory and outlive the test
+// processes that started them. There is no coordinating daemon: processes
+// serialize startup of an instance with a lock file next to its directory and
+// find running instances through their UNIX sockets.
+
+// Default number of shared instances per configuration
+const defaultSharedInstances = 2
+
+// Time allowed for probing whether a shared instance is running
+const sharedProbeTimeout = time.Second
+
+// StartShared returns a fresh database on one of the shared instances, starting
+// the instance if it is not running yet.
+//
+// Shared instances are reused by all test processes of the user with the same
+// BinDir and server arguments, so running `go test ./...` over many packages
+// pays for initdb and server startup only a few times. Every call gets its own
+// database cloned from template1. Stop drops the database and leaves the
+// instance running; use StopShared to shut the instances down.
+//
+// Options affecting only the started instance (Dir, Labels, PoolStatsInterval
+// and such) are honored when the instance is started, and ignored if it is
+// already running.
+func StartShared(ctx context.Context, config Config) (*PG, error) {
+	root, err := sharedRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	instances := config.SharedInstances
+	if instances <= 0 {
+		instances = defaultSharedInstances
+	}
+	// Configs asking for different versions share instances only if they
+	// resolve to the same binaries
+	binPath, err := findBinaries(config)
+	if err != nil {
+		return nil, err
+	}
+	preload, err := preloadLibraries(binPath, config)
+	if err != nil {
+		return nil, err
+	}
+	slot := filepath.Join(root, sharedConfigKey(binPath, preload, config), strconv.Itoa(os.Getpid()%instances))
+
+	sockDir, err := ensureSharedInstance(ctx, slot, config)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := randomDatabaseName()
+	if err != nil {
+		return nil, err
+	}
+	if err := withAdminConn(ctx, sockDir, 0, func(conn *pgx.Conn) error {
+		return createDatabase(ctx, conn, name, "template1")
+	}); err != nil {
+		return nil, fmt.Errorf("Failed to create database on shared instance: %w", err)
+	}
+
+	if config.RecordQueries && config.Recorder == nil {
+		config.Recorder = &Recorder{}
+	}
+	poolConf, err := testPoolConfig(sockDir, 0, name, config)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := pgxpool.NewWithConfig(ctx, poolConf)
+	if err != nil {
+		return nil, err
+	}
+
+	pg := &PG{
+		Pool: pool,
+
+		Host: sockDir,
+		Port: defaultPort,
+		User: "test",
+		Name: name,
+
+		recorder: config.Recorder,
+	}
+	pg.release = func() error {
+		return withAdminConn(context.Background(), sockDir, 0, func(conn *pgx.Conn) error {
+			return dropDatabase(context.Background(), conn, name)
+		})
+	}
+
+	if err := prepareDatabase(ctx, pool, config); err != nil {
+		_ = pg.Stop()
+		return nil, fmt.Errorf("Failed to prepare database on shared instance: %w", err)
+	}
+	if pg.MajorVersion, err = serverMajorVersion(ctx, pool); err != nil {
+		_ = pg.Stop()
+		return nil, err
+	}
+
+	if config.PoolStatsInterval > 0 {
+		pg.poolSampler = startPoolSampler(pool, config.PoolStatsInterval)
+	}
+
+	return pg, nil
+}
+
+// StopShared stops all shared instances of the user and removes their data.
+// Useful at the end of a CI job.
+func StopShared() error {
+	root, err := sharedRoot()
+	if err != nil {
+		return err
+	}
+
+	configs, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, c := range configs {
+		if !c.IsDir() {
+			continue
+		}
+		slots, err := filepath.Glob(filepath.Join(root, c.Name(), "*.lock"))
+		if err != nil {
+			return err
+		}
+		for _, lock := range slots {
+			slot := lock[:len(lock)-len(".lock")]
+			err := withFileLock(lock, func() error {
+				stopDetached(slot)
+				return os.RemoveAll(slot)
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func sharedRoot() (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cache, "pgxtest", "shared"), nil
+}
+
+// sharedConfigKey identifies instances that can be shared by configurations
+// running the binaries in binPath with the preloaded libraries
+func sharedConfigKey(binPath string, preload []string, config Config) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%q %q %q %q %q %v", binPath, preload, initDBArgs("", config), config.Settings, config.AdditionalArgs, config.TrackFunctions)
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// sharedInstanceConfig returns the config a shared instance in the slot
+// directory is started with: options affecting only the databases handed out,
+// or only the process starting the instance, are dropped
+func sharedInstanceConfig(slot string, config Config) Config {
+	config.Dir = slot
+	config.Labels = map[string]string{"shared": filepath.Base(slot)}
+	config.PoolStatsInterval = 0
+	config.ListenTCP = false
+	config.SocketDir = ""
+	config.Password = ""
+	config.TLS = false
+	config.HBA = nil
+	config.BindToContext = false
+	// Other processes use the server after this one has exited
+	config.TTL = 0
+	// Databases handed out are prepared instead
+	config.Migrate = nil
+	config.InitScripts = nil
+	config.Port = 0
+	config.WALArchive = nil
+	config.PITR = false
+	config.Databases = nil
+	config.Roles = nil
+	// Other processes find the server by its files
+	config.Backend = LocalBackend{}
+	return config
+}
+
+// ensureSharedInstance starts the instance in the slot directory unless it
+// is running already, and returns its socket directory
+func ensureSharedInstance(ctx context.Context, slot string, config Config) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(slot), 0755); err != nil {
+		return "", err
+	}
+
+	sockDir := socketDir(slot)
+	err := withFileLock(slot+".lock", func() error {
+		if sharedInstanceRunning(ctx, sockDir) {
+			return nil
+		}
+
+		// Leftovers of a crashed or stopped instance
+		stopDetached(slot)
+		if err := os.RemoveAll(slot); err != nil {
+			return err
+		}
+
+		pg, err := Start(ctx, sharedInstanceConfig(slot, config))
+		if err != nil {
+			return err
+		}
+		// Keep the server running after this process exits
+		pg.Pool.Close()
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return sockDir, nil
+}
+
+func sharedInstanceRunning(ctx context.Context, sockDir string) bool {
+	ctx, cancel := context.WithTimeout(ctx, sharedProbeTimeout)
+	defer cancel()
+
+	err := withAdminConn(ctx, sockDir, 0, func(conn *pgx.Conn) error {
+		return conn.Ping(ctx)
+	})
+	return err == nil
+}
+
+// stopDetached stops the server of an instance started by another process
+func stopDetached(dir string) {
+	md, err := readMetadata(dir)
+	// No PID if the server has not been started
+	if err != nil || md.PID == 0 {
+		return
+	}
+	if proc, err := os.FindProcess(md.PID); err == nil {
+		_ = proc.Signal(os.Interrupt)
+	}
+}
+
+// withAdminConn runs fn with a connection to the maintenance database
+func withAdminConn(ctx context.Context, sockDir string, port int, fn func(conn *pgx.Conn) error) error {
+	conf, err := postgresqlDBConf(sockDir, port, "postgres")
+	if err != nil {
+		return err
+	}
+	conn, err := pgx.ConnectConfig(ctx, conf.ConnConfig)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	return fn(conn)
+}
+
+// createDatabase creates a copy of the template database, including its
+// settings (ALTER DATABASE ... SET) which are not copied by PostgreSQL
+func createDatabase(ctx context.Context, conn *pgx.Conn, name string, template string) error {
+	_, err := conn.Exec(ctx, fmt.Sprintf("CREATE DATABASE %s TEMPLATE %s",
+		pgx.Identifier{name}.Sanitize(), pgx.Identifier{template}.Sanitize()))
+	if err != nil {
+		return err
+	}
+	if err := copyDatabaseSettings(ctx, conn, template, name); err != nil {
+		_ = dropDatabase(ctx, conn, name)
+		return err
+	}
+	return nil
+}
+
+func copyDatabaseSettings(ctx context.Context, conn *pgx.Conn, from string, to string) error {
+	rows, err := conn.Query(ctx, `
+		SELECT unnest(s.setconfig)
+		FROM pg_db_role_setting s JOIN pg_database d ON d.oid = s.setdatabase
+		WHERE d.datname = $1 AND s.setrole = 0`, from)
+	if err != nil {
+		return err
+	}
+	settings, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return err
+	}
+	for _, setting := range settings {
+		key, value, _ := strings.Cut(setting, "=")
+		// The stored value is in the format of postgresql.conf, which
+		// set_config understands for list settings such as search_path too
+		err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, "SELECT set_config($1, $2, true)", key, value); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, fmt.Sprintf("ALTER DATABASE %s SET %s FROM CURRENT",
+				pgx.Identifier{to}.Sanitize(), pgx.Identifier{key}.Sanitize()))
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func dropDatabase(ctx context.Context, conn *pgx.Conn, name string) error {
+	_, err := conn.Exec(ctx, fmt.Sprintf("DROP DATABASE IF EXISTS %s WITH (FORCE)", pgx.Identifier{name}.Sanitize()))
+	return err
+}
+
+func randomDatabaseName() (string, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return "pgxtest_" + hex.EncodeToString(b[:]), nil
+}
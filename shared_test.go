@@ -0,0 +1,71 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStartShared(t *testing.T) {
+	ctx := context.Background()
+
+	var names []string
+	for i := 0; i < 2; i++ {
+		pg, err := StartShared(ctx, Config{})
+		if err != nil {
+			t.Fatalf("failed to start shared pgxtest: %v", err)
+		}
+
+		if _, err := pg.Pool.Exec(ctx, "CREATE TABLE test (val text)"); err != nil {
+			t.Errorf("failed to create table: %v", err)
+		}
+		names = append(names, pg.Name)
+
+		if err := pg.Stop(); err != nil {
+			t.Errorf("failed to stop shared pgxtest: %v", err)
+		}
+	}
+
+	if names[0] == names[1] {
+		t.Errorf("expected distinct databases, got %q twice", names[0])
+	}
+
+	if err := StopShared(); err != nil {
+		t.Errorf("failed to stop shared instances: %v", err)
+	}
+}
+
+func TestSharedInstanceConfig(t *testing.T) {
+	config := sharedInstanceConfig("/tmp/slot", Config{
+		TTL:           time.Minute,
+		BindToContext: true,
+		ListenTCP:     true,
+		Settings:      map[string]string{"work_mem": "8MB"},
+	})
+
+	if config.TTL != 0 {
+		t.Errorf("expected no TTL, got %s", config.TTL)
+	}
+	if config.BindToContext || config.ListenTCP {
+		t.Errorf("expected BindToContext and ListenTCP to be cleared")
+	}
+	if config.Dir != "/tmp/slot" {
+		t.Errorf("expected Dir /tmp/slot, got %s", config.Dir)
+	}
+	if config.Settings["work_mem"] != "8MB" {
+		t.Errorf("expected settings to be kept, got %v", config.Settings)
+	}
+}
+
+func TestSharedConfigKey(t *testing.T) {
+	key := sharedConfigKey("/usr/lib/postgresql/16/bin", nil, Config{})
+	if sharedConfigKey("/usr/lib/postgresql/16/bin", nil, Config{Version: "16"}) != key {
+		t.Errorf("expected configs resolving to the same binaries to share the key")
+	}
+	if sharedConfigKey("/usr/lib/postgresql/15/bin", nil, Config{}) == key {
+		t.Errorf("expected different binaries to have different keys")
+	}
+	if sharedConfigKey("/usr/lib/postgresql/16/bin", []string{"pg_stat_statements"}, Config{}) == key {
+		t.Errorf("expected different preloaded libraries to have different keys")
+	}
+}
@@ -0,0 +1,73 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ShutdownMode selects how the server is shut down by Stop, see the
+// PostgreSQL documentation on shutting down the server
+type ShutdownMode int
+
+const (
+	ShutdownFast      ShutdownMode = iota // Roll back transactions, disconnect sessions and shut down cleanly
+	ShutdownSmart                         // Wait for sessions to disconnect, then shut down cleanly
+	ShutdownImmediate                     // Quit without a checkpoint, like a crash
+)
+
+func (m ShutdownMode) String() string {
+	switch m {
+	case ShutdownFast:
+		return "fast"
+	case ShutdownSmart:
+		return "smart"
+	case ShutdownImmediate:
+		return "immediate"
+	}
+	return fmt.Sprintf("ShutdownMode(%d)", int(m))
+}
+
+// Time Stop waits for the server to shut down before killing it
+const defaultShutdownTimeout = time.Minute
+
+// shutdownServer asks the server to shut down in the mode and waits for it to
+// exit. The server is killed once ctx is done, and an error is returned.
+func (p *PG) shutdownServer(ctx context.Context, mode ShutdownMode) error {
+	if err := p.cmd.Process.Signal(shutdownSignal(mode)); err != nil {
+		return err
+	}
+
+	exited := make(chan error, 1)
+	go func() { exited <- p.cmd.Wait() }()
+
+	var err error
+	killed := false
+	select {
+	case err = <-exited:
+	case <-ctx.Done():
+		_ = p.cmd.Process.Signal(os.Kill)
+		err = <-exited
+		killed = true
+	}
+	p.serverLog.Close()
+
+	// Doesn't matter if the server exits with an error
+	if err != nil {
+		_ = p.cmd.Process.Signal(os.Kill)
+
+		// Remove UNIX sockets
+		files, err := os.ReadDir(p.Host)
+		if err == nil {
+			for _, file := range files {
+				_ = os.Remove(filepath.Join(p.Host, file.Name()))
+			}
+		}
+	}
+	if killed {
+		return fmt.Errorf("PostgreSQL did not shut down in %s mode, killed: %w", mode, ctx.Err())
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestStopCtxKillsServer(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{ShutdownMode: ShutdownSmart})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+
+	// Smart shutdown waits for this session to end
+	conn, err := pgx.Connect(ctx, pg.URL())
+	if err != nil {
+		_ = pg.Stop()
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close(ctx)
+
+	stopCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	start := time.Now()
+	if err := pg.StopCtx(stopCtx); err == nil {
+		t.Errorf("expected an error for a server killed after the deadline")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("expected StopCtx to return soon after the deadline, took %s", elapsed)
+	}
+}
+
+func TestShutdownModeString(t *testing.T) {
+	for mode, want := range map[ShutdownMode]string{
+		ShutdownFast:      "fast",
+		ShutdownSmart:     "smart",
+		ShutdownImmediate: "immediate",
+		ShutdownMode(7):   "ShutdownMode(7)",
+	} {
+		if got := mode.String(); got != want {
+			t.Errorf("expected %s, got %s", want, got)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+//go:build !unix
+
+package pgxtest
+
+import (
+	"errors"
+	"os"
+)
+
+func freezeProcesses(pids []int, freeze bool) error {
+	return errors.New("suspending the server is not supported on this platform")
+}
+
+// Only fast shutdown can be requested on this platform, immediate shutdown
+// kills the server
+func shutdownSignal(mode ShutdownMode) os.Signal {
+	if mode == ShutdownImmediate {
+		return os.Kill
+	}
+	return os.Interrupt
+}
+
+// Liveness of processes can't be checked on this platform, they are assumed
+// to be alive
+func processAlive(pid int) bool {
+	return true
+}
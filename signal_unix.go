@@ -0,0 +1,44 @@
+//go:build unix
+
+package pgxtest
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// freezeProcesses stops the processes if freeze is set, and continues them
+// otherwise
+func freezeProcesses(pids []int, freeze bool) error {
+	sig := syscall.SIGCONT
+	if freeze {
+		sig = syscall.SIGSTOP
+	}
+	var errs []error
+	for _, pid := range pids {
+		// Backends come and go
+		if err := syscall.Kill(pid, sig); err != nil && !errors.Is(err, syscall.ESRCH) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// shutdownSignal returns the signal asking the postmaster to shut down in the
+// mode
+func shutdownSignal(mode ShutdownMode) os.Signal {
+	switch mode {
+	case ShutdownSmart:
+		return syscall.SIGTERM
+	case ShutdownImmediate:
+		return syscall.SIGQUIT
+	}
+	return syscall.SIGINT
+}
+
+// processAlive reports whether the process exists
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,101 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Helpers for testing CDC consumers (e.g. built on pglogrepl) against the
+// server. Logical slots require wal_level=logical, see EmitLogicalMessage.
+
+// ReplicationSlot describes a replication slot of the server
+type ReplicationSlot struct {
+	Name              string
+	Plugin            string // Output plugin, empty for physical slots
+	Type              string // logical or physical
+	Active            bool   // A consumer is connected
+	RestartLSN        string // Oldest WAL the slot retains, empty if none
+	ConfirmedFlushLSN string // Position confirmed by the consumer, empty for physical slots
+}
+
+// CreateLogicalSlot creates a logical replication slot with the output plugin
+// (e.g. pgoutput, test_decoding, wal2json) and returns its consistent point:
+// changes committed after it are streamed to the consumer
+func (p *PG) CreateLogicalSlot(ctx context.Context, slot string, plugin string) (string, error) {
+	var lsn string
+	err := p.Pool.QueryRow(ctx,
+		"SELECT lsn::text FROM pg_create_logical_replication_slot($1, $2)", slot, plugin,
+	).Scan(&lsn)
+	if err != nil {
+		return "", fmt.Errorf("Failed to create replication slot %s: %w", slot, err)
+	}
+	return lsn, nil
+}
+
+// DropSlot drops the replication slot. It fails while a consumer is connected
+// to it.
+func (p *PG) DropSlot(ctx context.Context, slot string) error {
+	if _, err := p.Pool.Exec(ctx, "SELECT pg_drop_replication_slot($1)", slot); err != nil {
+		return fmt.Errorf("Failed to drop replication slot %s: %w", slot, err)
+	}
+	return nil
+}
+
+// Slot returns the state of the replication slot
+func (p *PG) Slot(ctx context.Context, slot string) (ReplicationSlot, error) {
+	rows, err := p.Pool.Query(ctx, `
+		SELECT slot_name, coalesce(plugin, ''), slot_type, active,
+			coalesce(restart_lsn::text, ''), coalesce(confirmed_flush_lsn::text, '')
+		FROM pg_replication_slots
+		WHERE slot_name = $1`, slot)
+	if err != nil {
+		return ReplicationSlot{}, err
+	}
+	s, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByPos[ReplicationSlot])
+	if err == pgx.ErrNoRows {
+		return ReplicationSlot{}, fmt.Errorf("Replication slot %s not found", slot)
+	}
+	return s, err
+}
+
+// WaitForSlotConfirmed waits until the consumer of the logical slot has
+// confirmed processing changes up to lsn, e.g. one returned by
+// CurrentWALLSN after the changes under test
+func (p *PG) WaitForSlotConfirmed(ctx context.Context, slot string, lsn string) error {
+	for {
+		var confirmed *bool
+		err := p.Pool.QueryRow(ctx,
+			"SELECT confirmed_flush_lsn >= $2::pg_lsn FROM pg_replication_slots WHERE slot_name = $1",
+			slot, lsn,
+		).Scan(&confirmed)
+		if err == pgx.ErrNoRows {
+			return fmt.Errorf("Replication slot %s not found", slot)
+		}
+		if err != nil {
+			return err
+		}
+		if confirmed != nil && *confirmed {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("Replication slot %s has not confirmed %s: %w", slot, lsn, ctx.Err())
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// ReplicationURL returns a connection URL of the test database in logical
+// replication mode, for consumers speaking the replication protocol such as
+// pglogrepl
+func (p *PG) ReplicationURL() string {
+	u := p.URL()
+	if strings.Contains(u, "?") {
+		return u + "&replication=database"
+	}
+	return u + "?replication=database"
+}
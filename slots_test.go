@@ -0,0 +1,64 @@
+package pgxtest
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestLogicalSlot(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg := StartT(t, Config{Settings: map[string]string{"wal_level": "logical"}})
+
+	if _, err := pg.CreateLogicalSlot(ctx, "cdc", "test_decoding"); err != nil {
+		t.Fatalf("failed to create slot: %v", err)
+	}
+	slot, err := pg.Slot(ctx, "cdc")
+	if err != nil {
+		t.Fatalf("failed to get slot: %v", err)
+	}
+	if slot.Plugin != "test_decoding" || slot.Type != "logical" || slot.Active {
+		t.Errorf("unexpected slot state %+v", slot)
+	}
+
+	if _, err := pg.Pool.Exec(ctx, "CREATE TABLE t (val int); INSERT INTO t VALUES (1)"); err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+	lsn, err := pg.CurrentWALLSN(ctx)
+	if err != nil {
+		t.Fatalf("failed to get WAL position: %v", err)
+	}
+
+	// A consumer confirms by advancing the slot
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_, _ = pg.Pool.Exec(ctx, "SELECT pg_replication_slot_advance('cdc', $1::pg_lsn)", lsn)
+	}()
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := pg.WaitForSlotConfirmed(waitCtx, "cdc", lsn); err != nil {
+		t.Errorf("failed to wait for the slot: %v", err)
+	}
+
+	if err := pg.DropSlot(ctx, "cdc"); err != nil {
+		t.Fatalf("failed to drop slot: %v", err)
+	}
+	if _, err := pg.Slot(ctx, "cdc"); err == nil {
+		t.Errorf("expected an error for a dropped slot")
+	}
+}
+
+func TestReplicationURL(t *testing.T) {
+	pg := &PG{Host: "/tmp/sock", Port: 5432, User: "test", Name: "test"}
+	u, err := url.Parse(pg.ReplicationURL())
+	if err != nil {
+		t.Fatalf("failed to parse URL: %v", err)
+	}
+	q := u.Query()
+	if q.Get("replication") != "database" || q.Get("host") != "/tmp/sock" {
+		t.Errorf("unexpected replication URL %s", u)
+	}
+}
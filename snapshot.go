@@ -0,0 +1,92 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Snapshot saves the current state of the test database under the name, to
+// be restored by Restore. An existing snapshot with the same name is
+// replaced.
+//
+// The snapshot is a copy of the database made with CREATE DATABASE, so it
+// takes as long as copying the files of the database. Idle connections of the
+// Pool are closed, do not hold connections while taking a snapshot.
+func (p *PG) Snapshot(ctx context.Context, name string) error {
+	snapshot, err := p.snapshotDatabase(name)
+	if err != nil {
+		return err
+	}
+
+	err = withAdminConn(ctx, p.Host, p.Port, func(conn *pgx.Conn) error {
+		return dropDatabase(ctx, conn, snapshot)
+	})
+	if err != nil {
+		return err
+	}
+	if err := p.cloneDatabase(ctx, p.Name, snapshot); err != nil {
+		return fmt.Errorf("Failed to snapshot %s: %w", p.Name, err)
+	}
+
+	if !slices.Contains(p.snapshots, snapshot) {
+		p.snapshots = append(p.snapshots, snapshot)
+	}
+	return nil
+}
+
+// Restore replaces the test database with the snapshot taken by Snapshot. The
+// snapshot is kept and can be restored again.
+//
+// Sessions connected to the test database are terminated, connections of the
+// Pool acquired at the time fail.
+func (p *PG) Restore(ctx context.Context, name string) error {
+	snapshot, err := p.snapshotDatabase(name)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(p.snapshots, snapshot) {
+		return fmt.Errorf("Snapshot %s not found", name)
+	}
+
+	p.Pool.Reset()
+	err = withAdminConn(ctx, p.Host, p.Port, func(conn *pgx.Conn) error {
+		if err := dropDatabase(ctx, conn, p.Name); err != nil {
+			return err
+		}
+		return createDatabase(ctx, conn, p.Name, snapshot)
+	})
+	// Drop connections that have failed while the database was recreated
+	p.Pool.Reset()
+	if err != nil {
+		return fmt.Errorf("Failed to restore snapshot %s: %w", name, err)
+	}
+	return nil
+}
+
+// snapshotDatabase returns the name of the database keeping the snapshot
+func (p *PG) snapshotDatabase(name string) (string, error) {
+	snapshot := p.Name + "__" + name
+	if name == "" || len(snapshot) > maxDatabaseName {
+		return "", fmt.Errorf("Invalid snapshot name %q", name)
+	}
+	return snapshot, nil
+}
+
+// dropSnapshots drops the snapshots of a database on a server that is not
+// owned by p
+func (p *PG) dropSnapshots() error {
+	if len(p.snapshots) == 0 {
+		return nil
+	}
+	return withAdminConn(context.Background(), p.Host, p.Port, func(conn *pgx.Conn) error {
+		for _, s := range p.snapshots {
+			if err := dropDatabase(context.Background(), conn, s); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
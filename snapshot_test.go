@@ -0,0 +1,68 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	if _, err := pg.Pool.Exec(ctx, "CREATE TABLE test (val text); INSERT INTO test VALUES ('migrated')"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	if err := pg.SetIdleTimeouts(ctx, IdleTimeouts{IdleInTransaction: time.Minute}); err != nil {
+		t.Fatalf("failed to set timeouts: %v", err)
+	}
+	if err := pg.Snapshot(ctx, "migrated"); err != nil {
+		t.Fatalf("failed to take snapshot: %v", err)
+	}
+
+	count := func() int {
+		var n int
+		if err := pg.Pool.QueryRow(ctx, "SELECT count(*) FROM test").Scan(&n); err != nil {
+			t.Fatalf("failed to count rows: %v", err)
+		}
+		return n
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := pg.Pool.Exec(ctx, "INSERT INTO test VALUES ('test')"); err != nil {
+			t.Fatalf("failed to insert: %v", err)
+		}
+		if n := count(); n != 2 {
+			t.Errorf("expected 2 rows before restore, got %d", n)
+		}
+
+		if err := pg.Restore(ctx, "migrated"); err != nil {
+			t.Fatalf("failed to restore snapshot: %v", err)
+		}
+		if n := count(); n != 1 {
+			t.Errorf("expected 1 row after restore, got %d", n)
+		}
+	}
+
+	var timeout string
+	if err := pg.Pool.QueryRow(ctx, "SHOW idle_in_transaction_session_timeout").Scan(&timeout); err != nil {
+		t.Fatalf("failed to query setting: %v", err)
+	}
+	if timeout != "1min" {
+		t.Errorf("expected database settings to be restored, got %q", timeout)
+	}
+
+	if err := pg.Restore(ctx, "missing"); err == nil {
+		t.Errorf("expected restoring a missing snapshot to fail")
+	}
+}
@@ -0,0 +1,30 @@
+package pgxtest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+)
+
+// Longest UNIX socket path: sun_path of macOS is 104 bytes including the
+// terminating NUL, Linux allows 107
+const maxSocketPath = 103
+
+// Name of the socket in the socket directory, for the longest port
+const socketName = ".s.PGSQL.65535"
+
+// Directory for socket directories of instances whose own directory is too
+// deep. Not os.TempDir, which is deep itself on macOS
+const shortSocketRoot = "/tmp"
+
+// socketDir returns the socket directory of the instance in dir: a
+// subdirectory of dir if the socket path fits the limit, a directory under
+// /tmp named after the hash of dir otherwise
+func socketDir(dir string) string {
+	sockDir := filepath.Join(dir, "sock")
+	if len(sockDir)+1+len(socketName) <= maxSocketPath {
+		return sockDir
+	}
+	h := sha256.Sum256([]byte(dir))
+	return filepath.Join(shortSocketRoot, "pgxs-"+hex.EncodeToString(h[:8]))
+}
@@ -0,0 +1,29 @@
+package pgxtest
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSocketDir(t *testing.T) {
+	short := "/tmp/pgxtest123"
+	if got := socketDir(short); got != filepath.Join(short, "sock") {
+		t.Errorf("expected the socket in the instance directory, got %s", got)
+	}
+
+	deep := "/private/var/folders/xy/abcdefghijklmnopqrstuvwxyz0123/T/bazel-sandbox/1234/execroot/pgxtest123"
+	got := socketDir(deep)
+	if !strings.HasPrefix(got, shortSocketRoot+"/pgxs-") {
+		t.Errorf("expected a short socket directory, got %s", got)
+	}
+	if len(got)+1+len(socketName) > maxSocketPath {
+		t.Errorf("socket path in %s exceeds the limit", got)
+	}
+	if socketDir(deep) != got {
+		t.Errorf("expected the socket directory to be stable")
+	}
+	if socketDir(deep+"2") == got {
+		t.Errorf("expected different instances to get different socket directories")
+	}
+}
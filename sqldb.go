@@ -0,0 +1,14 @@
+package pgxtest
+
+import (
+	"database/sql"
+
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// SQLDB returns a database/sql handle of the test database for code using
+// database/sql, sqlx or GORM. Connections are taken from the Pool, so they
+// share its configuration and tracing. Close the handle before Stop.
+func (p *PG) SQLDB() *sql.DB {
+	return stdlib.OpenDBFromPool(p.Pool)
+}
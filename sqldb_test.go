@@ -0,0 +1,39 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSQLDB(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	db := pg.SQLDB()
+	defer db.Close()
+
+	if _, err := db.ExecContext(ctx, "CREATE TABLE test (val text)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, "INSERT INTO test VALUES ($1)", "sql"); err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+
+	var val string
+	if err := pg.Pool.QueryRow(ctx, "SELECT val FROM test").Scan(&val); err != nil {
+		t.Fatalf("failed to query: %v", err)
+	}
+	if val != "sql" {
+		t.Errorf("expected %q, got %q", "sql", val)
+	}
+}
@@ -0,0 +1,135 @@
+package pgxtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// ReplicaPair is a primary server and a hot standby replicating from it with
+// streaming replication, started by StartWithReplica
+type ReplicaPair struct {
+	Primary *PG
+	Replica *PG // Read-only, pg_is_in_recovery() is true
+}
+
+// StartWithReplica starts a primary server with config and a hot standby
+// replica of it, created by pg_basebackup. Migrations, init scripts, databases
+// and roles are applied on the primary and reach the replica through
+// replication.
+//
+// Use it to test routing of reads to replicas, handling of replication lag
+// (see PauseReplay) and code paths depending on pg_is_in_recovery(). Needs
+// LocalBackend; TempTablespaceDir and Persistent are not supported.
+func StartWithReplica(ctx context.Context, config Config) (_ *ReplicaPair, err error) {
+	if config.TempTablespaceDir != "" {
+		return nil, fmt.Errorf("Config.TempTablespaceDir is not supported by StartWithReplica")
+	}
+	if config.Persistent {
+		return nil, fmt.Errorf("Config.Persistent is not supported by StartWithReplica")
+	}
+	if config.Backend == nil {
+		config.Backend = LocalBackend{}
+	}
+	if _, ok := config.Backend.(LocalBackend); !ok {
+		return nil, fmt.Errorf("StartWithReplica needs LocalBackend")
+	}
+
+	primary, err := Start(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Join(err, primary.Stop())
+		}
+	}()
+
+	replicaConfig := config
+	replicaConfig.primary = primary
+	replicaConfig.Dir = ""
+	replicaConfig.Port = 0
+	replicaConfig.WALArchive = nil
+	replicaConfig.PITR = false
+	replica, err := Start(ctx, replicaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to start replica: %w", err)
+	}
+	return &ReplicaPair{Primary: primary, Replica: replica}, nil
+}
+
+// Stop stops the replica and then the primary
+func (r *ReplicaPair) Stop() error {
+	return errors.Join(r.Replica.Stop(), r.Primary.Stop())
+}
+
+// WaitForReplay waits until the replica has replayed all WAL written by the
+// primary so far, so that reads from the replica see the preceding writes
+func (r *ReplicaPair) WaitForReplay(ctx context.Context) error {
+	lsn, err := r.Primary.CurrentWALLSN(ctx)
+	if err != nil {
+		return err
+	}
+	for {
+		var replayed bool
+		err := r.Replica.Pool.QueryRow(ctx,
+			"SELECT pg_last_wal_replay_lsn() >= $1::pg_lsn", lsn).Scan(&replayed)
+		if err != nil {
+			return fmt.Errorf("Failed to get replay position: %w", err)
+		}
+		if replayed {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("Replica has not replayed WAL up to %s: %w", lsn, ctx.Err())
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// PauseReplay stops applying WAL on the replica, simulating replication lag
+// until ResumeReplay. WAL is still received.
+func (r *ReplicaPair) PauseReplay(ctx context.Context) error {
+	_, err := r.Replica.Pool.Exec(ctx, "SELECT pg_wal_replay_pause()")
+	return err
+}
+
+// ResumeReplay resumes applying WAL on the replica after PauseReplay
+func (r *ReplicaPair) ResumeReplay(ctx context.Context) error {
+	_, err := r.Replica.Pool.Exec(ctx, "SELECT pg_wal_replay_resume()")
+	return err
+}
+
+// replicaBaseBackup creates the data directory of a replica of config.primary,
+// configured to stream WAL from the primary
+func replicaBaseBackup(binPath string, dataDir string, walDir string, config Config) (*ringBuffer, *ringBuffer, error) {
+	args := []string{"--wal-method=stream", "--write-recovery-conf"}
+	if walDir != "" {
+		args = append(args, "--waldir="+walDir)
+	}
+	return baseBackup(binPath, config.primary, dataDir, args, config.OutputLimit)
+}
+
+// baseBackup copies the cluster of the server to dataDir with pg_basebackup
+func baseBackup(binPath string, source *PG, dataDir string, args []string, outputLimit int) (*ringBuffer, *ringBuffer, error) {
+	args = append([]string{
+		"-D", dataDir,
+		"-h", source.Host,
+		"-p", strconv.Itoa(source.Port),
+		"-U", source.User,
+		"--checkpoint=fast",
+	}, args...)
+	cmd := prepareCommand(filepath.Join(binPath, "pg_basebackup"), args...)
+	stdout := newRingBuffer(outputLimit)
+	stderr := newRingBuffer(outputLimit)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return stdout, stderr, fmt.Errorf("Failed to take base backup: %w -> %s%s", err, stdout, stderr)
+	}
+	return stdout, stderr, nil
+}
@@ -0,0 +1,71 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStartWithReplica(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pair, err := StartWithReplica(ctx, Config{
+		InitScripts: []string{"CREATE TABLE t (val int)"},
+	})
+	if err != nil {
+		t.Fatalf("failed to start primary and replica: %v", err)
+	}
+	defer func() {
+		if err := pair.Stop(); err != nil {
+			t.Errorf("failed to stop primary and replica: %v", err)
+		}
+	}()
+
+	var inRecovery bool
+	if err := pair.Replica.Pool.QueryRow(ctx, "SELECT pg_is_in_recovery()").Scan(&inRecovery); err != nil {
+		t.Fatalf("failed to query replica: %v", err)
+	}
+	if !inRecovery {
+		t.Errorf("expected the replica to be in recovery")
+	}
+
+	if _, err := pair.Primary.Pool.Exec(ctx, "INSERT INTO t VALUES (1)"); err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := pair.WaitForReplay(waitCtx); err != nil {
+		t.Fatalf("failed to wait for replay: %v", err)
+	}
+	countRows := func() int {
+		var n int
+		if err := pair.Replica.Pool.QueryRow(ctx, "SELECT count(*) FROM t").Scan(&n); err != nil {
+			t.Fatalf("failed to count rows on replica: %v", err)
+		}
+		return n
+	}
+	if n := countRows(); n != 1 {
+		t.Errorf("expected 1 row on replica, got %d", n)
+	}
+
+	if err := pair.PauseReplay(ctx); err != nil {
+		t.Fatalf("failed to pause replay: %v", err)
+	}
+	if _, err := pair.Primary.Pool.Exec(ctx, "INSERT INTO t VALUES (2)"); err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if n := countRows(); n != 1 {
+		t.Errorf("expected 1 row on replica while replay is paused, got %d", n)
+	}
+	if err := pair.ResumeReplay(ctx); err != nil {
+		t.Fatalf("failed to resume replay: %v", err)
+	}
+	if err := pair.WaitForReplay(waitCtx); err != nil {
+		t.Fatalf("failed to wait for replay: %v", err)
+	}
+	if n := countRows(); n != 2 {
+		t.Errorf("expected 2 rows on replica, got %d", n)
+	}
+}
@@ -0,0 +1,110 @@
+package pgxtest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+)
+
+// AssertRows streams the rows of the query to check, failing the test at the
+// first row check rejects. Rows are not collected, so results of any size can
+// be verified. check receives the 0-based number of the row and its values.
+func (p *PG) AssertRows(ctx context.Context, t testing.TB, query string, check func(n int64, row []any) error, args ...any) {
+	t.Helper()
+
+	rows, err := p.Pool.Query(ctx, query, args...)
+	if err != nil {
+		t.Fatalf("failed to query: %v\nquery: %s", err, query)
+	}
+	defer rows.Close()
+
+	var n int64
+	for rows.Next() {
+		row, err := rows.Values()
+		if err != nil {
+			t.Fatalf("failed to read row %d: %v\nquery: %s", n, err, query)
+		}
+		if err := check(n, row); err != nil {
+			t.Fatalf("row %d: %v\nquery: %s\nrow: %v", n, err, query, row)
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("failed to read rows: %v\nquery: %s", err, query)
+	}
+}
+
+// AssertCount fails the test unless the query returns the number of rows. The
+// rows are counted by the server.
+func (p *PG) AssertCount(ctx context.Context, t testing.TB, query string, want int64, args ...any) {
+	t.Helper()
+
+	var count int64
+	if err := p.Pool.QueryRow(ctx, "SELECT count(*) FROM ("+query+") q", args...).Scan(&count); err != nil {
+		t.Fatalf("failed to count rows: %v\nquery: %s", err, query)
+	}
+	if count != want {
+		t.Fatalf("expected %d rows, got %d\nquery: %s", want, count, query)
+	}
+}
+
+// RowsChecksum returns a checksum of the rows of the query regardless of their
+// order: the rows are sorted by their text representation on the server and
+// hashed as they stream in. Equal checksums mean equal sets of rows, e.g. of a
+// result and a table of expected values.
+func (p *PG) RowsChecksum(ctx context.Context, query string, args ...any) (string, error) {
+	rows, err := p.Pool.Query(ctx, `SELECT r::text FROM (`+query+`) r ORDER BY 1 COLLATE "C"`, args...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	h := sha256.New()
+	var line []byte
+	for rows.Next() {
+		if err := rows.Scan(&line); err != nil {
+			return "", err
+		}
+		h.Write(line)
+		h.Write([]byte{'\n'})
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// AssertSameRows fails the test unless the queries return the same rows, in
+// any order. The results are compared by RowsChecksum, so they may be large.
+func (p *PG) AssertSameRows(ctx context.Context, t testing.TB, query string, expected string) {
+	t.Helper()
+
+	got, err := p.RowsChecksum(ctx, query)
+	if err != nil {
+		t.Fatalf("failed to checksum rows: %v\nquery: %s", err, query)
+	}
+	want, err := p.RowsChecksum(ctx, expected)
+	if err != nil {
+		t.Fatalf("failed to checksum expected rows: %v\nquery: %s", err, expected)
+	}
+	if got != want {
+		t.Fatalf("rows differ\nquery: %s\nexpected: %s\n%s", query, expected, p.rowsDiffSample(ctx, query, expected))
+	}
+}
+
+// Rows of each side shown when AssertSameRows fails
+const rowsDiffSampleSize = 5
+
+// rowsDiffSample describes a few rows present in only one of the results
+func (p *PG) rowsDiffSample(ctx context.Context, query string, expected string) string {
+	sample := func(a, b string) []string {
+		lines, err := p.queryStrings(ctx, fmt.Sprintf(`SELECT r::text FROM ((%s) EXCEPT ALL (%s)) r ORDER BY 1 COLLATE "C" LIMIT %d`, a, b, rowsDiffSampleSize))
+		if err != nil {
+			return []string{"(" + err.Error() + ")"}
+		}
+		return lines
+	}
+	return fmt.Sprintf("unexpected rows: %q\nmissing rows: %q", sample(query, expected), sample(expected, query))
+}
@@ -0,0 +1,54 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestStreamingAssertions(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	if _, err := pg.Pool.Exec(ctx, "CREATE TABLE numbers AS SELECT g AS n, g * 2 AS doubled FROM generate_series(1, 100000) g"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	pg.AssertCount(ctx, t, "SELECT * FROM numbers WHERE n > $1", 50000, 50000)
+
+	pg.AssertRows(ctx, t, "SELECT n, doubled FROM numbers ORDER BY n", func(i int64, row []any) error {
+		if row[0].(int32) != int32(i+1) || row[1].(int32) != 2*int32(i+1) {
+			return fmt.Errorf("unexpected values")
+		}
+		return nil
+	})
+
+	pg.AssertSameRows(ctx, t, "SELECT n, doubled FROM numbers ORDER BY n DESC", "SELECT g, g * 2 FROM generate_series(1, 100000) g")
+
+	a, err := pg.RowsChecksum(ctx, "SELECT n FROM numbers")
+	if err != nil {
+		t.Fatalf("failed to checksum rows: %v", err)
+	}
+	b, err := pg.RowsChecksum(ctx, "SELECT n FROM numbers WHERE n <> 42")
+	if err != nil {
+		t.Fatalf("failed to checksum rows: %v", err)
+	}
+	if a == b {
+		t.Errorf("expected checksums of different rows to differ")
+	}
+
+	diff := pg.rowsDiffSample(ctx, "SELECT n FROM numbers WHERE n <> 42", "SELECT n FROM numbers")
+	if diff != `unexpected rows: []`+"\n"+`missing rows: ["(42)"]` {
+		t.Errorf("unexpected diff sample: %s", diff)
+	}
+}
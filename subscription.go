@@ -0,0 +1,118 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Helpers for testing conflict handling of logical replication subscribers.
+// They are called on the subscriber instance and require PostgreSQL 15+.
+
+// SubscriptionStatus describes the error state of a subscription
+type SubscriptionStatus struct {
+	Name            string
+	Enabled         bool
+	SkipLSN         string // Finish LSN of a remote transaction to skip, empty if none
+	ApplyErrorCount int64
+	SyncErrorCount  int64
+}
+
+// SubscriptionStatus returns the error state of the subscription
+func (p *PG) SubscriptionStatus(ctx context.Context, subscription string) (SubscriptionStatus, error) {
+	var st SubscriptionStatus
+	var skipLSN string
+	err := p.Pool.QueryRow(ctx, `
+		SELECT s.subname, s.subenabled, s.subskiplsn::text,
+		       coalesce(st.apply_error_count, 0), coalesce(st.sync_error_count, 0)
+		FROM pg_subscription s
+		LEFT JOIN pg_stat_subscription_stats st ON st.subid = s.oid
+		WHERE s.subname = $1`,
+		subscription,
+	).Scan(&st.Name, &st.Enabled, &skipLSN, &st.ApplyErrorCount, &st.SyncErrorCount)
+	if err != nil {
+		return st, err
+	}
+	if skipLSN != "0/0" {
+		st.SkipLSN = skipLSN
+	}
+	return st, nil
+}
+
+// InjectDuplicateKey inserts a row directly into a subscriber table, so that
+// replicating a row with the same key from the publisher fails with
+// unique_violation.
+func (p *PG) InjectDuplicateKey(ctx context.Context, table string, row map[string]any) error {
+	if len(row) == 0 {
+		return fmt.Errorf("no columns to insert into %s", table)
+	}
+
+	columns := make([]string, 0, len(row))
+	for column := range row {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	quoted := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	args := make([]any, len(columns))
+	for i, column := range columns {
+		quoted[i] = pgx.Identifier{column}.Sanitize()
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = row[column]
+	}
+
+	_, err := p.Pool.Exec(ctx,
+		fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+			pgx.Identifier(strings.Split(table, ".")).Sanitize(),
+			strings.Join(quoted, ", "), strings.Join(placeholders, ", ")),
+		args...)
+	return err
+}
+
+// InjectMissingRows deletes rows matching the condition from a subscriber
+// table, so that replicated updates and deletes of these rows find nothing to
+// modify.
+func (p *PG) InjectMissingRows(ctx context.Context, table string, where string, args ...any) (int64, error) {
+	tag, err := p.Pool.Exec(ctx,
+		fmt.Sprintf("DELETE FROM %s WHERE %s", pgx.Identifier(strings.Split(table, ".")).Sanitize(), where),
+		args...)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+var conflictFinishLSN = regexp.MustCompile(`finished at ([0-9A-F]+/[0-9A-F]+)`)
+
+// LastConflictLSN returns the finish LSN of the remote transaction that most
+// recently failed to apply, as reported in the server log. Returns an empty
+// string if the log has no apply errors.
+func (p *PG) LastConflictLSN() string {
+	matches := conflictFinishLSN.FindAllSubmatch(p.ServerOutput().Stderr, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+	return string(matches[len(matches)-1][1])
+}
+
+// SkipConflict makes the subscription skip the remote transaction that
+// failed to apply most recently, and enables the subscription if it was
+// disabled by disable_on_error.
+func (p *PG) SkipConflict(ctx context.Context, subscription string) error {
+	lsn := p.LastConflictLSN()
+	if lsn == "" {
+		return fmt.Errorf("no failed remote transaction found in the server log")
+	}
+
+	sub := pgx.Identifier{subscription}.Sanitize()
+	if _, err := p.Pool.Exec(ctx, fmt.Sprintf("ALTER SUBSCRIPTION %s SKIP (lsn = '%s')", sub, lsn)); err != nil {
+		return err
+	}
+	_, err := p.Pool.Exec(ctx, fmt.Sprintf("ALTER SUBSCRIPTION %s ENABLE", sub))
+	return err
+}
@@ -0,0 +1,22 @@
+package pgxtest
+
+import (
+	"testing"
+)
+
+func TestConflictFinishLSN(t *testing.T) {
+	log := `ERROR:  duplicate key value violates unique constraint "items_pkey"
+CONTEXT:  processing remote data for replication origin "pg_16395" during message type "INSERT" for replication target relation "public.items" in transaction 740, finished at 0/14C0378
+ERROR:  duplicate key value violates unique constraint "items_pkey"
+CONTEXT:  processing remote data for replication origin "pg_16395" during message type "INSERT" for replication target relation "public.items" in transaction 741, finished at 0/14C04A0`
+
+	pg := &PG{stderr: newRingBuffer(0)}
+	if lsn := pg.LastConflictLSN(); lsn != "" {
+		t.Errorf("expected no conflict, got %q", lsn)
+	}
+
+	_, _ = pg.stderr.Write([]byte(log))
+	if lsn := pg.LastConflictLSN(); lsn != "0/14C04A0" {
+		t.Errorf("expected last conflict LSN 0/14C04A0, got %q", lsn)
+	}
+}
@@ -0,0 +1,57 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// SwapIn replaces the test database with the database prepared, e.g. a
+// freshly seeded copy, by renaming it over the test database. The previous
+// test database is dropped. This is near-instant regardless of the amount of
+// data, unlike Restore.
+//
+// Sessions connected to either database are terminated, connections of the
+// Pool acquired at the time fail.
+func (p *PG) SwapIn(ctx context.Context, prepared string) error {
+	if prepared == p.Name {
+		return fmt.Errorf("Can't swap %s with itself", prepared)
+	}
+	old, err := randomDatabaseName()
+	if err != nil {
+		return err
+	}
+
+	p.Pool.Reset()
+	err = withAdminConn(ctx, p.Host, p.Port, func(conn *pgx.Conn) error {
+		_, err := conn.Exec(ctx, `
+			SELECT pg_terminate_backend(pid) FROM pg_stat_activity
+			WHERE datname IN ($1, $2) AND pid <> pg_backend_pid()`, p.Name, prepared)
+		if err != nil {
+			return err
+		}
+
+		if err := renameDatabase(ctx, conn, p.Name, old); err != nil {
+			return err
+		}
+		if err := renameDatabase(ctx, conn, prepared, p.Name); err != nil {
+			// Put the test database back
+			_ = renameDatabase(ctx, conn, old, p.Name)
+			return err
+		}
+		return dropDatabase(ctx, conn, old)
+	})
+	// Drop connections to the replaced database
+	p.Pool.Reset()
+	if err != nil {
+		return fmt.Errorf("Failed to swap in database %s: %w", prepared, err)
+	}
+	return nil
+}
+
+func renameDatabase(ctx context.Context, conn *pgx.Conn, name, newName string) error {
+	_, err := conn.Exec(ctx, fmt.Sprintf("ALTER DATABASE %s RENAME TO %s",
+		pgx.Identifier{name}.Sanitize(), pgx.Identifier{newName}.Sanitize()))
+	return err
+}
@@ -0,0 +1,55 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestSwapIn(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg := StartT(t, Config{})
+
+	if _, err := pg.Pool.Exec(ctx, "CREATE TABLE items (name text); INSERT INTO items VALUES ('old')"); err != nil {
+		t.Fatalf("failed to prepare test database: %v", err)
+	}
+	if _, err := pg.Pool.Exec(ctx, "CREATE DATABASE reseeded"); err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	conf, err := postgresqlDBConf(pg.Host, pg.Port, "reseeded")
+	if err != nil {
+		t.Fatalf("failed to configure connection: %v", err)
+	}
+	conn, err := pgx.ConnectConfig(ctx, conf.ConnConfig)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	_, err = conn.Exec(ctx, "CREATE TABLE items (name text); INSERT INTO items VALUES ('new')")
+	conn.Close(ctx)
+	if err != nil {
+		t.Fatalf("failed to seed database: %v", err)
+	}
+
+	if err := pg.SwapIn(ctx, "reseeded"); err != nil {
+		t.Fatalf("failed to swap in database: %v", err)
+	}
+
+	var name string
+	if err := pg.Pool.QueryRow(ctx, "SELECT name FROM items").Scan(&name); err != nil {
+		t.Fatalf("failed to query: %v", err)
+	}
+	if name != "new" {
+		t.Errorf("expected swapped in data, got %q", name)
+	}
+
+	var databases int
+	if err := pg.Pool.QueryRow(ctx, "SELECT count(*) FROM pg_database WHERE NOT datistemplate AND datname <> 'postgres'").Scan(&databases); err != nil {
+		t.Fatalf("failed to count databases: %v", err)
+	}
+	if databases != 1 {
+		t.Errorf("expected the previous test database to be dropped, got %d databases", databases)
+	}
+}
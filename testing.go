@@ -0,0 +1,179 @@
+package pgxtest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// StartT starts the database for the test and stops it in t.Cleanup, failing
+// the test if the database can't be started.
+//
+// If the test fails, the last lines of the server log are written to t.Log,
+// see PG.Logs for all of them. pgx trace logs are written to t.Log as well,
+// unless Config.Logger is set. Labels default to the name of the test.
+//
+// If PGXTEST_TUNNEL is set to a duration (e.g. in CI), the server listens on
+// TCP and is kept alive for that long (at most an hour) after a failed test,
+// with instructions for connecting through the runner's tunnel printed to
+// stderr.
+func StartT(t testing.TB, config Config) *PG {
+	t.Helper()
+
+	hold, err := tunnelHold()
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	if hold > 0 {
+		config.ListenTCP = true
+	}
+
+	if config.Labels == nil {
+		config.Labels = map[string]string{"test": t.Name()}
+	}
+
+	w := &testLogWriter{t: t}
+	if config.Logger == nil {
+		config.Logger = slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{
+			Level: slog.LevelDebug - 1, // pgx trace level
+		}))
+	}
+
+	pg, err := Start(context.Background(), config)
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if t.Failed() {
+			logTail(t, pg, failureLogLines)
+		}
+		if hold > 0 && t.Failed() {
+			holdForDebugging(t, pg, hold)
+		}
+		if err := pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+		// The test is about to complete, logging after that panics
+		w.Close()
+	})
+	return pg
+}
+
+// Lines of the server log StartT writes to t.Log on failure
+const failureLogLines = 100
+
+// logTail logs the last lines of the server log to the test
+func logTail(t testing.TB, p *PG, lines int) {
+	logs := p.Logs()
+	if len(logs) == 0 {
+		return
+	}
+	if len(logs) > lines {
+		logs = logs[len(logs)-lines:]
+	}
+	t.Log(fmt.Sprintf("last %d lines of server log:\n%s", len(logs), strings.Join(logs, "\n")))
+}
+
+// testLogWriter writes complete lines to t.Log
+type testLogWriter struct {
+	t testing.TB
+
+	mu     sync.Mutex
+	buf    []byte
+	closed bool
+}
+
+func (w *testLogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return len(p), nil
+	}
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.t.Log(string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Close logs the incomplete last line and stops logging
+func (w *testLogWriter) Close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) > 0 {
+		w.t.Log(string(w.buf))
+		w.buf = nil
+	}
+	w.closed = true
+}
+
+// Option configures NewServer
+type Option func(*Config)
+
+// WithConfig starts from config instead of the defaults of NewServer. Options
+// following it are applied on top.
+func WithConfig(config Config) Option {
+	return func(c *Config) { *c = config }
+}
+
+// WithBinDir looks for PostgreSQL executables in dir
+func WithBinDir(dir string) Option {
+	return func(c *Config) { c.BinDir = dir }
+}
+
+// WithSetting sets a server configuration parameter
+func WithSetting(name, value string) Option {
+	return func(c *Config) {
+		// The map may be shared with the caller of WithConfig
+		settings := map[string]string{name: value}
+		for k, v := range c.Settings {
+			if k != name {
+				settings[k] = v
+			}
+		}
+		c.Settings = settings
+	}
+}
+
+// WithTCP makes the server listen on an automatically allocated localhost port
+func WithTCP() Option {
+	return func(c *Config) { c.ListenTCP = true }
+}
+
+// WithLabels sets the labels of the instance
+func WithLabels(labels map[string]string) Option {
+	return func(c *Config) { c.Labels = labels }
+}
+
+// Startup attempts of NewServer, see Config.StartAttempts
+const defaultNewServerAttempts = 3
+
+// NewServer starts a server for the test, in the vein of httptest.NewServer,
+// and is the recommended way to get a database in tests. It never returns an
+// error: startup failures fail the test, and the server is stopped when the
+// test completes. See StartT for where the logs go.
+//
+// By default startup is retried on transient failures. Use Start for full
+// control over the lifecycle.
+func NewServer(t testing.TB, opts ...Option) *PG {
+	t.Helper()
+
+	config := Config{StartAttempts: defaultNewServerAttempts}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return StartT(t, config)
+}
@@ -0,0 +1,95 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStartT(t *testing.T) {
+	pg := StartT(t, Config{})
+
+	if _, err := pg.Pool.Exec(context.Background(), "SELECT 1"); err != nil {
+		t.Errorf("failed to query: %v", err)
+	}
+}
+
+type logRecorder struct {
+	testing.TB
+	lines []string
+}
+
+func (r *logRecorder) Log(args ...any) {
+	r.lines = append(r.lines, args[0].(string))
+}
+
+func TestTestLogWriter(t *testing.T) {
+	r := &logRecorder{TB: t}
+	w := &testLogWriter{t: r}
+
+	w.Write([]byte("first\nsec"))
+	w.Write([]byte("ond\nthi"))
+	w.Close()
+	w.Write([]byte("ignored\n"))
+
+	expected := []string{"first", "second", "thi"}
+	if len(r.lines) != len(expected) {
+		t.Fatalf("expected %q, got %q", expected, r.lines)
+	}
+	for i := range expected {
+		if r.lines[i] != expected[i] {
+			t.Errorf("expected %q, got %q", expected, r.lines)
+		}
+	}
+}
+
+func TestLogTail(t *testing.T) {
+	r := &logRecorder{TB: t}
+	stderr := newRingBuffer(0)
+	pg := &PG{stderr: stderr}
+
+	logTail(r, pg, 2)
+	if len(r.lines) != 0 {
+		t.Errorf("expected nothing logged without logs, got %q", r.lines)
+	}
+
+	stderr.Write([]byte("one\ntwo\nthree\n"))
+	logTail(r, pg, 2)
+	expected := "last 2 lines of server log:\ntwo\nthree"
+	if len(r.lines) != 1 || r.lines[0] != expected {
+		t.Errorf("expected %q, got %q", expected, r.lines)
+	}
+}
+
+func TestNewServer(t *testing.T) {
+	pg := NewServer(t, WithSetting("work_mem", "12MB"))
+
+	var workMem string
+	if err := pg.Pool.QueryRow(context.Background(), "SHOW work_mem").Scan(&workMem); err != nil {
+		t.Fatalf("failed to query: %v", err)
+	}
+	if workMem != "12MB" {
+		t.Errorf("expected work_mem 12MB, got %s", workMem)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	config := Config{StartAttempts: defaultNewServerAttempts}
+	for _, opt := range []Option{
+		WithConfig(Config{TrackFunctions: true}),
+		WithBinDir("/opt/pg"),
+		WithSetting("work_mem", "12MB"),
+		WithTCP(),
+	} {
+		opt(&config)
+	}
+
+	if !config.TrackFunctions || config.StartAttempts != 0 {
+		t.Errorf("expected WithConfig to replace the defaults, got %+v", config)
+	}
+	if config.BinDir != "/opt/pg" || !config.ListenTCP {
+		t.Errorf("unexpected config %+v", config)
+	}
+	if config.Settings["work_mem"] != "12MB" {
+		t.Errorf("unexpected settings %q", config.Settings)
+	}
+}
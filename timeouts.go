@@ -0,0 +1,77 @@
+package pgxtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// IdleTimeouts are server-side timeouts that disconnect idle sessions. Zero
+// values restore the server defaults.
+type IdleTimeouts struct {
+	IdleInTransaction time.Duration // idle_in_transaction_session_timeout
+	IdleSession       time.Duration // idle_session_timeout, PostgreSQL 14+
+
+	// TCP keepalives, ignored for connections over UNIX sockets
+	KeepalivesIdle     time.Duration // tcp_keepalives_idle
+	KeepalivesInterval time.Duration // tcp_keepalives_interval
+	KeepalivesCount    int           // tcp_keepalives_count
+}
+
+// SetIdleTimeouts sets the timeouts for new sessions of the test database and
+// resets the Pool, so that all subsequently acquired connections are subject
+// to them.
+//
+// Set the timeouts very low to reproduce disconnects of idle connections and
+// abandoned transactions, and check the errors with IsIdleInTransactionTimeout
+// and IsIdleSessionTimeout.
+func (p *PG) SetIdleTimeouts(ctx context.Context, timeouts IdleTimeouts) error {
+	settings := []struct {
+		name  string
+		value int64
+		unit  string
+	}{
+		{"idle_in_transaction_session_timeout", timeouts.IdleInTransaction.Milliseconds(), "ms"},
+		{"idle_session_timeout", timeouts.IdleSession.Milliseconds(), "ms"},
+		{"tcp_keepalives_idle", int64(timeouts.KeepalivesIdle / time.Second), "s"},
+		{"tcp_keepalives_interval", int64(timeouts.KeepalivesInterval / time.Second), "s"},
+		{"tcp_keepalives_count", int64(timeouts.KeepalivesCount), ""},
+	}
+
+	db := pgx.Identifier{p.Name}.Sanitize()
+	for _, s := range settings {
+		var query string
+		if s.value == 0 {
+			query = fmt.Sprintf("ALTER DATABASE %s RESET %s", db, s.name)
+		} else {
+			query = fmt.Sprintf("ALTER DATABASE %s SET %s = '%d%s'", db, s.name, s.value, s.unit)
+		}
+		if _, err := p.Pool.Exec(ctx, query); err != nil {
+			return fmt.Errorf("Failed to set %s: %w", s.name, err)
+		}
+	}
+
+	p.Pool.Reset()
+	return nil
+}
+
+// IsIdleInTransactionTimeout reports whether err was caused by the server
+// terminating the session because of idle_in_transaction_session_timeout
+func IsIdleInTransactionTimeout(err error) bool {
+	return hasSQLState(err, "25P03")
+}
+
+// IsIdleSessionTimeout reports whether err was caused by the server
+// terminating the session because of idle_session_timeout
+func IsIdleSessionTimeout(err error) bool {
+	return hasSQLState(err, "57P05")
+}
+
+func hasSQLState(err error, code string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == code
+}
@@ -0,0 +1,49 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestIdleInTransactionTimeout(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	if err := pg.SetIdleTimeouts(ctx, IdleTimeouts{IdleInTransaction: 100 * time.Millisecond}); err != nil {
+		t.Fatalf("failed to set timeouts: %v", err)
+	}
+
+	tx, err := pg.Pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "SELECT 1"); err != nil {
+		t.Fatalf("failed to query: %v", err)
+	}
+	time.Sleep(500 * time.Millisecond)
+
+	_, err = tx.Exec(ctx, "SELECT 1")
+	if !IsIdleInTransactionTimeout(err) {
+		t.Errorf("expected idle in transaction timeout, got %v", err)
+	}
+	if IsIdleSessionTimeout(err) {
+		t.Errorf("did not expect idle session timeout for %v", err)
+	}
+
+	if err := pg.SetIdleTimeouts(ctx, IdleTimeouts{}); err != nil {
+		t.Fatalf("failed to reset timeouts: %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package pgxtest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Validity of generated certificates, long enough for any test run
+const certificateValidity = 24 * time.Hour
+
+// generateCertificates generates a CA and a server certificate for localhost
+// signed by it. The server certificate and key are written to the data
+// directory, where PostgreSQL looks for them by default. The CA certificate
+// is written to dir, its path is returned.
+func generateCertificates(dir string, dataDir string) (string, error) {
+	now := time.Now()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "pgxtest CA"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(certificateValidity),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, ca, ca, &caKey.PublicKey, caKey)
+	if err != nil {
+		return "", err
+	}
+
+	serverKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	server := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(certificateValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	serverDER, err := x509.CreateCertificate(rand.Reader, server, ca, &serverKey.PublicKey, caKey)
+	if err != nil {
+		return "", err
+	}
+	serverKeyDER, err := x509.MarshalECPrivateKey(serverKey)
+	if err != nil {
+		return "", err
+	}
+
+	caFile := filepath.Join(dir, "ca.crt")
+	if err := writePEM(caFile, "CERTIFICATE", caDER); err != nil {
+		return "", err
+	}
+	if err := writePEM(filepath.Join(dataDir, "server.crt"), "CERTIFICATE", serverDER); err != nil {
+		return "", err
+	}
+	// PostgreSQL refuses keys readable by others
+	if err := writePEM(filepath.Join(dataDir, "server.key"), "EC PRIVATE KEY", serverKeyDER); err != nil {
+		return "", err
+	}
+	return caFile, nil
+}
+
+func writePEM(path string, blockType string, der []byte) error {
+	return os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600)
+}
+
+// TLSConfig returns a TLS configuration trusting only the CA of the server
+// certificate, e.g. for pgconn.Config.TLSConfig or to test certificate
+// pinning. It returns nil unless Config.TLS is set.
+func (p *PG) TLSConfig() (*tls.Config, error) {
+	if p.CAFile == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(p.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(data)
+	return &tls.Config{RootCAs: roots, ServerName: "localhost"}, nil
+}
@@ -0,0 +1,79 @@
+package pgxtest
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestTLS(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{TLS: true})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	conn, err := pgx.Connect(ctx, pg.TCPURL())
+	if err != nil {
+		t.Fatalf("failed to connect with sslmode=verify-full: %v", err)
+	}
+	defer conn.Close(ctx)
+
+	var ssl bool
+	if err := conn.QueryRow(ctx, "SELECT ssl FROM pg_stat_ssl WHERE pid = pg_backend_pid()").Scan(&ssl); err != nil {
+		t.Fatalf("failed to query pg_stat_ssl: %v", err)
+	}
+	if !ssl {
+		t.Errorf("expected a TLS connection")
+	}
+}
+
+func TestGenerateCertificates(t *testing.T) {
+	dir, dataDir := t.TempDir(), t.TempDir()
+	caFile, err := generateCertificates(dir, dataDir)
+	if err != nil {
+		t.Fatalf("failed to generate certificates: %v", err)
+	}
+
+	roots := x509.NewCertPool()
+	caPEM, err := os.ReadFile(caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots.AppendCertsFromPEM(caPEM)
+
+	serverPEM, err := os.ReadFile(filepath.Join(dataDir, "server.crt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(serverPEM)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse server certificate: %v", err)
+	}
+	for _, name := range []string{"localhost", "127.0.0.1"} {
+		if _, err := cert.Verify(x509.VerifyOptions{DNSName: name, Roots: roots}); err != nil {
+			t.Errorf("failed to verify server certificate for %s: %v", name, err)
+		}
+	}
+
+	info, err := os.Stat(filepath.Join(dataDir, "server.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected server.key mode 0600, got %o", info.Mode().Perm())
+	}
+}
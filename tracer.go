@@ -0,0 +1,108 @@
+package pgxtest
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// multiTracer passes traces to several tracers. Optional tracer interfaces
+// are forwarded to the tracers implementing them.
+type multiTracer []pgx.QueryTracer
+
+func (m multiTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	for _, t := range m {
+		ctx = t.TraceQueryStart(ctx, conn, data)
+	}
+	return ctx
+}
+
+func (m multiTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	for _, t := range m {
+		t.TraceQueryEnd(ctx, conn, data)
+	}
+}
+
+func (m multiTracer) TraceBatchStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceBatchStartData) context.Context {
+	for _, t := range m {
+		if t, ok := t.(pgx.BatchTracer); ok {
+			ctx = t.TraceBatchStart(ctx, conn, data)
+		}
+	}
+	return ctx
+}
+
+func (m multiTracer) TraceBatchQuery(ctx context.Context, conn *pgx.Conn, data pgx.TraceBatchQueryData) {
+	for _, t := range m {
+		if t, ok := t.(pgx.BatchTracer); ok {
+			t.TraceBatchQuery(ctx, conn, data)
+		}
+	}
+}
+
+func (m multiTracer) TraceBatchEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceBatchEndData) {
+	for _, t := range m {
+		if t, ok := t.(pgx.BatchTracer); ok {
+			t.TraceBatchEnd(ctx, conn, data)
+		}
+	}
+}
+
+func (m multiTracer) TraceCopyFromStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	for _, t := range m {
+		if t, ok := t.(pgx.CopyFromTracer); ok {
+			ctx = t.TraceCopyFromStart(ctx, conn, data)
+		}
+	}
+	return ctx
+}
+
+func (m multiTracer) TraceCopyFromEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	for _, t := range m {
+		if t, ok := t.(pgx.CopyFromTracer); ok {
+			t.TraceCopyFromEnd(ctx, conn, data)
+		}
+	}
+}
+
+func (m multiTracer) TracePrepareStart(ctx context.Context, conn *pgx.Conn, data pgx.TracePrepareStartData) context.Context {
+	for _, t := range m {
+		if t, ok := t.(pgx.PrepareTracer); ok {
+			ctx = t.TracePrepareStart(ctx, conn, data)
+		}
+	}
+	return ctx
+}
+
+func (m multiTracer) TracePrepareEnd(ctx context.Context, conn *pgx.Conn, data pgx.TracePrepareEndData) {
+	for _, t := range m {
+		if t, ok := t.(pgx.PrepareTracer); ok {
+			t.TracePrepareEnd(ctx, conn, data)
+		}
+	}
+}
+
+func (m multiTracer) TraceConnectStart(ctx context.Context, data pgx.TraceConnectStartData) context.Context {
+	for _, t := range m {
+		if t, ok := t.(pgx.ConnectTracer); ok {
+			ctx = t.TraceConnectStart(ctx, data)
+		}
+	}
+	return ctx
+}
+
+func (m multiTracer) TraceConnectEnd(ctx context.Context, data pgx.TraceConnectEndData) {
+	for _, t := range m {
+		if t, ok := t.(pgx.ConnectTracer); ok {
+			t.TraceConnectEnd(ctx, data)
+		}
+	}
+}
+
+// Ensure the optional interfaces stay implemented
+var (
+	_ pgx.BatchTracer    = multiTracer{}
+	_ pgx.CopyFromTracer = multiTracer{}
+	_ pgx.PrepareTracer  = multiTracer{}
+	_ pgx.ConnectTracer  = multiTracer{}
+)
@@ -0,0 +1,52 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Bookkeeping tables of migration tools, never truncated by TruncateAll
+var migrationTables = []string{
+	"schema_migrations",     // golang-migrate, dbmate
+	"goose_db_version",      // goose
+	"flyway_schema_history", // Flyway
+	"atlas_schema_revisions",
+	"databasechangelog", // Liquibase
+	"databasechangeloglock",
+}
+
+// TruncateAll empties all tables in the user schemas of the test database with
+// TRUNCATE ... RESTART IDENTITY CASCADE, leaving the schema in place. This is
+// faster than recreating the database between tests.
+//
+// Tables of migration tools (schema_migrations, goose_db_version and the like)
+// and tables belonging to extensions are kept. exceptTables names further
+// tables to keep, either as "table" or "schema.table". A table referencing a
+// truncated one by a foreign key is truncated regardless.
+func (p *PG) TruncateAll(ctx context.Context, exceptTables ...string) error {
+	except := append(append([]string{}, migrationTables...), exceptTables...)
+
+	tables, err := p.queryStrings(ctx, `
+		SELECT format('%I.%I', n.nspname, c.relname)
+		FROM pg_class c
+		JOIN user_schemas n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p') AND NOT c.relispartition
+		  AND c.relname <> ALL($1) AND n.nspname || '.' || c.relname <> ALL($1)
+		  AND NOT EXISTS (
+			SELECT FROM pg_depend d
+			WHERE d.classid = 'pg_class'::regclass AND d.objid = c.oid AND d.deptype = 'e'
+		  )
+		ORDER BY 1`, except)
+	if err != nil {
+		return fmt.Errorf("Failed to list tables: %w", err)
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+
+	if _, err := p.Pool.Exec(ctx, "TRUNCATE "+strings.Join(tables, ", ")+" RESTART IDENTITY CASCADE"); err != nil {
+		return fmt.Errorf("Failed to truncate tables: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTruncateAll(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	_, err = pg.Pool.Exec(ctx, `
+		CREATE TABLE schema_migrations (version bigint);
+		CREATE TABLE users (id serial PRIMARY KEY);
+		CREATE SCHEMA app;
+		CREATE TABLE app.orders (id serial, user_id int REFERENCES users);
+		CREATE TABLE app.countries (code text);
+		INSERT INTO schema_migrations VALUES (1);
+		INSERT INTO users DEFAULT VALUES;
+		INSERT INTO app.orders (user_id) VALUES (1);
+		INSERT INTO app.countries VALUES ('NL');
+	`)
+	if err != nil {
+		t.Fatalf("failed to prepare tables: %v", err)
+	}
+
+	if err := pg.TruncateAll(ctx, "app.countries"); err != nil {
+		t.Fatalf("failed to truncate tables: %v", err)
+	}
+
+	for table, expected := range map[string]int{
+		"schema_migrations": 1,
+		"users":             0,
+		"app.orders":        0,
+		"app.countries":     1,
+	} {
+		var n int
+		if err := pg.Pool.QueryRow(ctx, "SELECT count(*) FROM "+table).Scan(&n); err != nil {
+			t.Fatalf("failed to count rows of %s: %v", table, err)
+		}
+		if n != expected {
+			t.Errorf("expected %d rows in %s, got %d", expected, table, n)
+		}
+	}
+
+	var id int
+	if err := pg.Pool.QueryRow(ctx, "INSERT INTO users DEFAULT VALUES RETURNING id").Scan(&id); err != nil {
+		t.Fatalf("failed to insert user: %v", err)
+	}
+	if id != 1 {
+		t.Errorf("expected identity to restart at 1, got %d", id)
+	}
+}
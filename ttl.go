@@ -0,0 +1,107 @@
+package pgxtest
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrInstanceExpired is returned when connecting to an instance stopped after
+// Config.TTL or when its context was done, see Config.BindToContext
+var ErrInstanceExpired = errors.New("pgxtest instance expired after Config.TTL or cancellation of its context")
+
+// refuseExpired returns a pgxpool BeforeConnect hook failing connections once
+// the instance has expired
+func refuseExpired(expired *atomic.Bool) func(context.Context, *pgx.ConnConfig) error {
+	return func(context.Context, *pgx.ConnConfig) error {
+		if expired.Load() {
+			return ErrInstanceExpired
+		}
+		return nil
+	}
+}
+
+// startExpiry stops the server after ttl, if it is not zero, or once ctx is
+// done, if bind is set
+func (p *PG) startExpiry(ctx context.Context, ttl time.Duration, bind bool) {
+	if ttl > 0 {
+		p.expiryDeadline = time.Now().Add(ttl)
+	}
+	if bind {
+		p.expiryCtx = ctx
+	}
+	p.armExpiry()
+}
+
+// armExpiry starts the goroutine stopping the server once the instance
+// expires
+func (p *PG) armExpiry() {
+	var ttlC <-chan time.Time
+	if !p.expiryDeadline.IsZero() {
+		p.ttlTimer = time.NewTimer(time.Until(p.expiryDeadline))
+		ttlC = p.ttlTimer.C
+	}
+	var done <-chan struct{}
+	if p.expiryCtx != nil {
+		done = p.expiryCtx.Done()
+	}
+	if ttlC == nil && done == nil {
+		return
+	}
+
+	cancel := make(chan struct{})
+	expiredDone := make(chan struct{})
+	p.cancelExpiry, p.expiredDone = cancel, expiredDone
+	go func() {
+		defer close(expiredDone)
+
+		select {
+		case <-cancel:
+			return
+		case <-ttlC:
+		case <-done:
+		}
+		p.expired.Store(true)
+		p.Pool.Reset()
+		_ = p.stopServer()
+	}()
+}
+
+// stopExpiry cancels the expiry, returning true if the instance has expired
+func (p *PG) stopExpiry() bool {
+	if p.cancelExpiry != nil {
+		if p.ttlTimer != nil {
+			p.ttlTimer.Stop()
+		}
+		close(p.cancelExpiry)
+		<-p.expiredDone
+		p.cancelExpiry = nil
+	}
+	return p.Expired()
+}
+
+// pauseExpiry cancels the expiry while the server or the Pool is replaced,
+// failing with ErrInstanceExpired if the instance has expired already. Call
+// resumeExpiry once done.
+func (p *PG) pauseExpiry() error {
+	if p.stopExpiry() {
+		return ErrInstanceExpired
+	}
+	return nil
+}
+
+// resumeExpiry restarts the expiry cancelled by pauseExpiry
+func (p *PG) resumeExpiry() {
+	if !p.Expired() {
+		p.armExpiry()
+	}
+}
+
+// Expired reports whether the instance was stopped after Config.TTL or once
+// its context was done, see Config.BindToContext
+func (p *PG) Expired() bool {
+	return p.expired != nil && p.expired.Load()
+}
@@ -0,0 +1,110 @@
+package pgxtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTTL(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{TTL: time.Second})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	if _, err := pg.Pool.Exec(ctx, "SELECT 1"); err != nil {
+		t.Fatalf("failed to query before expiry: %v", err)
+	}
+
+	time.Sleep(2 * time.Second)
+
+	if !pg.Expired() {
+		t.Errorf("expected the instance to expire")
+	}
+	if _, err := pg.Pool.Exec(ctx, "SELECT 1"); !errors.Is(err, ErrInstanceExpired) {
+		t.Errorf("expected ErrInstanceExpired, got %v", err)
+	}
+	if err := pg.Restart(ctx); !errors.Is(err, ErrInstanceExpired) {
+		t.Errorf("expected Restart to fail with ErrInstanceExpired, got %v", err)
+	}
+}
+
+func TestTTLAcrossRestart(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{TTL: 2 * time.Second})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	if err := pg.Restart(ctx); err != nil {
+		t.Fatalf("failed to restart: %v", err)
+	}
+	if _, err := pg.Pool.Exec(ctx, "SELECT 1"); err != nil {
+		t.Fatalf("failed to query after restart: %v", err)
+	}
+
+	// The TTL counts from Start, not from the restart
+	time.Sleep(3 * time.Second)
+	if !pg.Expired() {
+		t.Errorf("expected the instance to expire after restart")
+	}
+}
+
+func TestTTLStoppedEarly(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{TTL: time.Hour})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	if err := pg.Stop(); err != nil {
+		t.Errorf("failed to stop pgxtest: %v", err)
+	}
+	if pg.Expired() {
+		t.Errorf("expected the instance to be stopped before expiry")
+	}
+}
+
+func TestBindToContext(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pg, err := Start(ctx, Config{BindToContext: true})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	cancel()
+	deadline := time.Now().Add(10 * time.Second)
+	for !pg.Expired() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !pg.Expired() {
+		t.Fatalf("expected the instance to stop once the context is cancelled")
+	}
+	if _, err := pg.Pool.Exec(context.Background(), "SELECT 1"); !errors.Is(err, ErrInstanceExpired) {
+		t.Errorf("expected ErrInstanceExpired, got %v", err)
+	}
+}
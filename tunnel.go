@@ -0,0 +1,63 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+// Environment variable enabling debugging of failed tests through the CI
+// runner's tunnel, set to the time to keep the instance alive, e.g. 15m
+const tunnelEnv = "PGXTEST_TUNNEL"
+
+// Longest time PGXTEST_TUNNEL can keep an instance alive
+const maxTunnelHold = time.Hour
+
+// Channel to notify to stop holding the instance early
+const tunnelReleaseChannel = "pgxtest_release"
+
+// tunnelHold returns the time to keep the instance of a failed test alive, or
+// zero if PGXTEST_TUNNEL is not set
+func tunnelHold() (time.Duration, error) {
+	s := os.Getenv(tunnelEnv)
+	if s == "" {
+		return 0, nil
+	}
+	hold, err := time.ParseDuration(s)
+	if err != nil || hold <= 0 {
+		return 0, fmt.Errorf("Failed to parse %s: expected a positive duration, got %q", tunnelEnv, s)
+	}
+	return min(hold, maxTunnelHold), nil
+}
+
+// holdForDebugging keeps the instance of a failed test alive for the time,
+// printing how to connect to it. It returns early if the channel
+// pgxtest_release is notified.
+//
+// The instructions go to stderr, as t.Log output is shown only after the test
+// completes.
+func holdForDebugging(t testing.TB, p *PG, hold time.Duration) {
+	fmt.Fprintf(os.Stderr, "pgxtest: %s failed, keeping PostgreSQL alive for %s (%s) for debugging.\n", t.Name(), hold, tunnelEnv)
+	fmt.Fprintf(os.Stderr, "  Forward localhost:%d through the runner's tunnel (e.g. ssh -R or tmate) and connect with\n", p.Port)
+	fmt.Fprintf(os.Stderr, "    psql '%s'\n", p.TCPURL())
+	fmt.Fprintf(os.Stderr, "  Run NOTIFY %s to finish earlier. Keep go test -timeout longer than %s.\n", tunnelReleaseChannel, hold)
+
+	ctx, cancel := context.WithTimeout(context.Background(), hold)
+	defer cancel()
+
+	conn, err := p.Pool.Acquire(ctx)
+	if err != nil {
+		<-ctx.Done()
+		return
+	}
+	defer conn.Release()
+	if _, err := conn.Exec(ctx, "LISTEN "+tunnelReleaseChannel); err != nil {
+		<-ctx.Done()
+		return
+	}
+	// Returns on the notification or once the time is up
+	_, _ = conn.Conn().WaitForNotification(ctx)
+	fmt.Fprintf(os.Stderr, "pgxtest: stopping PostgreSQL of %s\n", t.Name())
+}
@@ -0,0 +1,30 @@
+package pgxtest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTunnelHold(t *testing.T) {
+	for value, want := range map[string]time.Duration{
+		"":    0,
+		"15m": 15 * time.Minute,
+		"24h": maxTunnelHold,
+	} {
+		t.Setenv(tunnelEnv, value)
+		hold, err := tunnelHold()
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", value, err)
+		}
+		if hold != want {
+			t.Errorf("%q: expected %s, got %s", value, want, hold)
+		}
+	}
+
+	for _, value := range []string{"yes", "-1m"} {
+		t.Setenv(tunnelEnv, value)
+		if _, err := tunnelHold(); err == nil {
+			t.Errorf("%q: expected an error", value)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package pgxtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// UpgradeMode selects how pg_upgrade transfers the data files to the new
+// cluster
+type UpgradeMode int
+
+const (
+	UpgradeLink  UpgradeMode = iota // Hard-link the files, fastest
+	UpgradeClone                    // Clone the files, needs a filesystem with reflinks (Btrfs, XFS, APFS)
+	UpgradeCopy                     // Copy the files, like most production upgrades
+)
+
+func (m UpgradeMode) String() string {
+	switch m {
+	case UpgradeLink:
+		return "link"
+	case UpgradeClone:
+		return "clone"
+	case UpgradeCopy:
+		return "copy"
+	}
+	return fmt.Sprintf("UpgradeMode(%d)", int(m))
+}
+
+// UpgradeTo upgrades the instance to the PostgreSQL version found in newBinDir
+// using pg_upgrade in the mode, and restarts the server. The Pool is replaced
+// by a new one connected to the upgraded server.
+//
+// Use it to rehearse major version upgrades against a seeded schema.
+func (p *PG) UpgradeTo(ctx context.Context, newBinDir string, mode UpgradeMode) error {
+	if p.release != nil {
+		return fmt.Errorf("the server is not owned by this instance")
+	}
+	if mode < UpgradeLink || mode > UpgradeCopy {
+		return fmt.Errorf("Invalid upgrade mode %s", mode)
+	}
+	if err := p.pauseExpiry(); err != nil {
+		return err
+	}
+	defer p.resumeExpiry()
+
+	newBinPath, err := findBinPath(newBinDir)
+	if err != nil {
+		return err
+	}
+
+	newDataDir, err := os.MkdirTemp(p.dir, "data-")
+	if err != nil {
+		return err
+	}
+	var newWALDir string
+	if p.walDir != "" {
+		if newWALDir, err = os.MkdirTemp(p.config.WALDir, "pgxtest"); err != nil {
+			os.RemoveAll(newDataDir)
+			return err
+		}
+	}
+	if _, _, err := initDB(newBinPath, newDataDir, initDBArgs(newWALDir, p.config), p.config.OutputLimit); err != nil {
+		removeDirs(newDataDir, newWALDir)
+		return err
+	}
+	// Keep the authentication rules, including those set by SetHBA, and the
+	// certificates signed by the CA clients already trust
+	if err := copyServerFiles(p.dataDir, newDataDir); err != nil {
+		removeDirs(newDataDir, newWALDir)
+		return err
+	}
+
+	p.Pool.Close()
+	if err := p.stopServer(); err != nil {
+		return err
+	}
+
+	upgrade := prepareCommand(filepath.Join(newBinPath, "pg_upgrade"),
+		"--old-bindir", p.binPath,
+		"--new-bindir", newBinPath,
+		"--old-datadir", p.dataDir,
+		"--new-datadir", newDataDir,
+		"--username", p.User,
+		"--socketdir", p.Host,
+		"--"+mode.String(),
+	)
+	// pg_upgrade writes its logs into the working directory
+	upgrade.Dir = p.dir
+	if out, err := upgrade.CombinedOutput(); err != nil {
+		// The old cluster is intact unless pg_upgrade in link mode reached
+		// the linking stage
+		if relaunchErr := p.relaunch(ctx); relaunchErr != nil {
+			return fmt.Errorf("pg_upgrade failed: %w -> %s (restarting the old server failed: %v)", err, out, relaunchErr)
+		}
+		removeDirs(newDataDir, newWALDir)
+		return fmt.Errorf("pg_upgrade failed: %w -> %s", err, out)
+	}
+
+	// The old data directory is replaced, after linking it must not be
+	// started anymore. The new one takes its path, where a persistent
+	// instance is found by the next Start.
+	oldDataDir, oldWALDir := newDataDir+".old", p.walDir
+	if err := os.Rename(p.dataDir, oldDataDir); err != nil {
+		return err
+	}
+	if err := os.Rename(newDataDir, p.dataDir); err != nil {
+		return err
+	}
+	p.binPath = newBinPath
+	p.walDir = newWALDir
+	if err := p.relaunch(ctx); err != nil {
+		return err
+	}
+	removeDirs(oldWALDir)
+	return os.RemoveAll(oldDataDir)
+}
+
+// Files of the data directory written by pgxtest rather than initdb
+var serverFiles = []string{"pg_hba.conf", "server.crt", "server.key"}
+
+// copyServerFiles copies the files written by pgxtest into a new data
+// directory, skipping those the old one doesn't have
+func copyServerFiles(oldDataDir string, newDataDir string) error {
+	for _, name := range serverFiles {
+		data, err := os.ReadFile(filepath.Join(oldDataDir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(newDataDir, name), data, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package pgxtest
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestUpgradeTo(t *testing.T) {
+	// Needs two major versions installed side by side, as Ubuntu does
+	versions, _ := filepath.Glob("/usr/lib/postgresql/*/bin")
+	var binDirs []string
+	for _, dir := range versions {
+		if _, err := os.Stat(filepath.Join(dir, "pg_upgrade")); err == nil {
+			binDirs = append(binDirs, dir)
+		}
+	}
+	if len(binDirs) < 2 {
+		t.Skip("two PostgreSQL versions are needed")
+	}
+	// By version, 9.6 is older than 16
+	version := func(binDir string) string { return filepath.Base(filepath.Dir(binDir)) }
+	sort.Slice(binDirs, func(i, j int) bool { return compareVersions(version(binDirs[i]), version(binDirs[j])) < 0 })
+	oldBinDir, newBinDir := binDirs[0], binDirs[len(binDirs)-1]
+
+	for _, mode := range []UpgradeMode{UpgradeLink, UpgradeCopy} {
+		mode := mode
+		t.Run(mode.String(), func(t *testing.T) {
+			t.Parallel()
+			testUpgradeTo(t, oldBinDir, newBinDir, mode)
+		})
+	}
+}
+
+func testUpgradeTo(t *testing.T, oldBinDir string, newBinDir string, mode UpgradeMode) {
+	ctx := context.Background()
+
+	pg, err := Start(ctx, Config{BinDir: oldBinDir, TLS: true, Password: "secret"})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	if _, err := pg.Pool.Exec(ctx, "CREATE TABLE test (val text); INSERT INTO test VALUES ('kept')"); err != nil {
+		t.Fatalf("failed to seed: %v", err)
+	}
+
+	if err := pg.UpgradeTo(ctx, newBinDir, mode); err != nil {
+		t.Fatalf("failed to upgrade: %v", err)
+	}
+
+	var val string
+	if err := pg.Pool.QueryRow(ctx, "SELECT val FROM test").Scan(&val); err != nil || val != "kept" {
+		t.Errorf("expected seeded data to survive the upgrade, got %q (%v)", val, err)
+	}
+	if filepath.Base(pg.dataDir) != "data" {
+		t.Errorf("expected the upgraded cluster in the data directory, got %s", pg.dataDir)
+	}
+
+	// TLS and password authentication survive the upgrade
+	conn, err := pgx.Connect(ctx, pg.TCPURL())
+	if err != nil {
+		t.Fatalf("failed to connect with TLS and password: %v", err)
+	}
+	defer conn.Close(ctx)
+	var ssl bool
+	if err := conn.QueryRow(ctx, "SELECT ssl FROM pg_stat_ssl WHERE pid = pg_backend_pid()").Scan(&ssl); err != nil || !ssl {
+		t.Errorf("expected a TLS connection (%v)", err)
+	}
+	u, err := url.Parse(pg.TCPURL())
+	if err != nil {
+		t.Fatalf("failed to parse URL: %v", err)
+	}
+	u.User = url.UserPassword(pg.User, "wrong")
+	if conn, err := pgx.Connect(ctx, u.String()); err == nil {
+		conn.Close(ctx)
+		t.Errorf("expected connection with a wrong password to fail")
+	}
+}
+
+func TestCopyServerFiles(t *testing.T) {
+	oldDataDir, newDataDir := t.TempDir(), t.TempDir()
+	if err := writeHBA(oldDataDir, nil, Config{Password: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := copyServerFiles(oldDataDir, newDataDir); err != nil {
+		t.Fatalf("failed to copy server files: %v", err)
+	}
+	hba, err := os.ReadFile(filepath.Join(newDataDir, "pg_hba.conf"))
+	if err != nil || string(hba) != hbaConf(nil, Config{Password: "secret"}) {
+		t.Errorf("expected pg_hba.conf to be copied, got %q (%v)", hba, err)
+	}
+	if _, err := os.Stat(filepath.Join(newDataDir, "server.crt")); err == nil {
+		t.Errorf("expected missing certificates to be skipped")
+	}
+}
+
+func TestUpgradeToInvalidMode(t *testing.T) {
+	pg := &PG{}
+	if err := pg.UpgradeTo(context.Background(), "/nonexistent", UpgradeMode(42)); err == nil {
+		t.Errorf("expected an invalid mode to fail")
+	}
+}
@@ -0,0 +1,214 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Installation is a PostgreSQL installation found on the machine
+type Installation struct {
+	BinDir  string // Directory with initdb and postgres
+	Version string // Version reported by initdb, e.g. 16.2
+}
+
+// Places where packages install PostgreSQL binaries, besides PATH
+var installationGlobs = []string{
+	"/usr/lib/postgresql/*/bin",                          // Debian, Ubuntu
+	"/usr/pgsql-*/bin",                                   // PGDG packages for RHEL
+	"/usr/local/pgsql/bin",                               // Source builds
+	"/opt/homebrew/opt/postgresql@*/bin",                 // Homebrew on Apple Silicon
+	"/usr/local/opt/postgresql@*/bin",                    // Homebrew on Intel
+	"/Applications/Postgres.app/Contents/Versions/*/bin", // Postgres.app
+}
+
+// Installations returns the PostgreSQL installations found in PATH and the
+// places packages install to, newest first
+func Installations() []Installation {
+	var dirs []string
+	if p, err := exec.LookPath("initdb"); err == nil {
+		dirs = append(dirs, filepath.Dir(p))
+	}
+	for _, g := range installationGlobs {
+		matches, _ := filepath.Glob(g)
+		dirs = append(dirs, matches...)
+	}
+
+	var installations []Installation
+	seen := map[string]bool{}
+	for _, dir := range dirs {
+		// PATH often points at a symlink to one of the installations
+		real, err := filepath.EvalSymlinks(filepath.Join(dir, "initdb"))
+		if err != nil || seen[real] {
+			continue
+		}
+		seen[real] = true
+		if version, err := installedVersion(dir); err == nil {
+			installations = append(installations, Installation{BinDir: dir, Version: version})
+		}
+	}
+	sort.SliceStable(installations, func(i, j int) bool {
+		return compareVersions(installations[i].Version, installations[j].Version) > 0
+	})
+	return installations
+}
+
+// installedVersion returns the version of initdb in binDir
+func installedVersion(binDir string) (string, error) {
+	out, err := exec.Command(filepath.Join(binDir, "initdb"), "--version").Output()
+	if err != nil {
+		return "", err
+	}
+	// initdb (PostgreSQL) 16.2 (Ubuntu 16.2-1.pgdg22.04+1)
+	fields := strings.Fields(string(out))
+	if len(fields) < 3 {
+		return "", fmt.Errorf("Failed to parse version %q", out)
+	}
+	return fields[2], nil
+}
+
+// findBinaries returns the directory with the binaries to run for config:
+// Config.BinDir or the installation found in the usual places, matching
+// Config.Version if set
+func findBinaries(config Config) (string, error) {
+	if config.Version == "" {
+		return findBinPath(config.BinDir)
+	}
+	if err := validVersionConstraint(config.Version); err != nil {
+		return "", err
+	}
+
+	if config.BinDir != "" {
+		binPath, err := findBinPath(config.BinDir)
+		if err != nil {
+			return "", err
+		}
+		version, err := installedVersion(binPath)
+		if err != nil {
+			return "", fmt.Errorf("Failed to get version of PostgreSQL in %s: %w", binPath, err)
+		}
+		if !matchVersion(version, config.Version) {
+			return "", fmt.Errorf("PostgreSQL %s in %s does not match version %s", version, binPath, config.Version)
+		}
+		return binPath, nil
+	}
+
+	installations := Installations()
+	var found []string
+	for _, inst := range installations {
+		if matchVersion(inst.Version, config.Version) {
+			return inst.BinDir, nil
+		}
+		found = append(found, fmt.Sprintf("%s (%s)", inst.Version, inst.BinDir))
+	}
+	if len(found) == 0 {
+		return "", fmt.Errorf("Did not find PostgreSQL executables installed")
+	}
+	return "", fmt.Errorf("No installed PostgreSQL matches version %s, found: %s", config.Version, strings.Join(found, ", "))
+}
+
+// A clause of a version constraint: >=15, <17, 16.2
+var versionClause = regexp.MustCompile(`^(>=|<=|>|<|=)?\s*(\d+(?:\.\d+)*)$`)
+
+func validVersionConstraint(constraint string) error {
+	for _, clause := range strings.Split(constraint, ",") {
+		if !versionClause.MatchString(strings.TrimSpace(clause)) {
+			return fmt.Errorf("Invalid version constraint %q", constraint)
+		}
+	}
+	return nil
+}
+
+// isExactVersion reports whether the constraint names a single release
+// rather than a range, e.g. 16.4.0
+func isExactVersion(constraint string) bool {
+	return strings.Count(constraint, ".") >= 2 && versionClause.MatchString(constraint) && !strings.ContainsAny(constraint, "<>=")
+}
+
+// matchVersion reports whether version satisfies all comma-separated clauses
+// of the constraint. A clause without an operator is a prefix: 16 matches
+// 16.2 and 16.4, 16.2 matches 16.2 and 16.2.0.
+func matchVersion(version, constraint string) bool {
+	version = leadingVersion(version)
+	for _, clause := range strings.Split(constraint, ",") {
+		m := versionClause.FindStringSubmatch(strings.TrimSpace(clause))
+		if m == nil {
+			return false
+		}
+		op, want := m[1], m[2]
+
+		var ok bool
+		switch op {
+		case "":
+			ok = compareVersionPrefix(version, want) == 0
+		case "=":
+			ok = compareVersions(version, want) == 0
+		case ">=":
+			ok = compareVersions(version, want) >= 0
+		case "<=":
+			ok = compareVersions(version, want) <= 0
+		case ">":
+			ok = compareVersions(version, want) > 0
+		case "<":
+			ok = compareVersions(version, want) < 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// compareVersionPrefix compares version with prefix, considering only as
+// many components as prefix has
+func compareVersionPrefix(version, prefix string) int {
+	n := strings.Count(prefix, ".") + 1
+	parts := strings.SplitN(version, ".", n+1)
+	if len(parts) > n {
+		parts = parts[:n]
+	}
+	return compareVersions(strings.Join(parts, "."), prefix)
+}
+
+// leadingVersion strips suffixes of development releases: 17beta1 is 17
+var leadingVersionRe = regexp.MustCompile(`^\d+(?:\.\d+)*`)
+
+func leadingVersion(version string) string {
+	if v := leadingVersionRe.FindString(version); v != "" {
+		return v
+	}
+	return version
+}
+
+// majorVersion returns the major version, e.g. 16 for 16.2, or 0 if unknown
+func majorVersion(version string) int {
+	major, _, _ := strings.Cut(leadingVersion(version), ".")
+	n, _ := strconv.Atoi(major)
+	return n
+}
+
+// Version returns the version of the running server, e.g. 16.2 (Ubuntu
+// 16.2-1.pgdg22.04+1)
+func (p *PG) Version(ctx context.Context) (string, error) {
+	var version string
+	if err := p.Pool.QueryRow(ctx, "SHOW server_version").Scan(&version); err != nil {
+		return "", err
+	}
+	return version, nil
+}
+
+// serverMajorVersion returns the major version of the server, e.g. 16
+func serverMajorVersion(ctx context.Context, pool *pgxpool.Pool) (int, error) {
+	var num int
+	if err := pool.QueryRow(ctx, "SELECT current_setting('server_version_num')::int").Scan(&num); err != nil {
+		return 0, fmt.Errorf("Failed to get server version: %w", err)
+	}
+	return num / 10000, nil
+}
@@ -0,0 +1,76 @@
+package pgxtest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatchVersion(t *testing.T) {
+	for _, c := range []struct {
+		version, constraint string
+		expected            bool
+	}{
+		{"16.2", "16", true},
+		{"16.2", "16.2", true},
+		{"16.2", "16.2.0", true},
+		{"16.2", "16.3", false},
+		{"14.10", ">=15", false},
+		{"15.0", ">=15", true},
+		{"16.2", ">=15,<17", true},
+		{"17.0", ">=15,<17", false},
+		{"17beta1", "17", true},
+		{"16.2", "=16.2", true},
+		{"16.2", "1", false},
+	} {
+		if got := matchVersion(c.version, c.constraint); got != c.expected {
+			t.Errorf("matchVersion(%s, %s): expected %v, got %v", c.version, c.constraint, c.expected, got)
+		}
+	}
+}
+
+func TestValidVersionConstraint(t *testing.T) {
+	for _, c := range []string{"16", ">=15,<17", "16.4.0", "<= 16"} {
+		if err := validVersionConstraint(c); err != nil {
+			t.Errorf("expected %q to be valid: %v", c, err)
+		}
+	}
+	for _, c := range []string{"latest", "~16", ">=15,", "16.x"} {
+		if err := validVersionConstraint(c); err == nil {
+			t.Errorf("expected %q to be invalid", c)
+		}
+	}
+}
+
+func TestFindBinariesVersion(t *testing.T) {
+	binDir := t.TempDir()
+	initdb := "#!/bin/sh\necho 'initdb (PostgreSQL) 14.10 (Ubuntu 14.10-1)'\n"
+	if err := os.WriteFile(filepath.Join(binDir, "initdb"), []byte(initdb), 0755); err != nil {
+		t.Fatalf("failed to write initdb: %v", err)
+	}
+
+	if _, err := findBinaries(Config{BinDir: binDir, Version: "14"}); err != nil {
+		t.Errorf("expected version 14 to match: %v", err)
+	}
+	_, err := findBinaries(Config{BinDir: binDir, Version: ">=15"})
+	if err == nil || !strings.Contains(err.Error(), "14.10") {
+		t.Errorf("expected error naming the found version, got %v", err)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg := StartT(t, Config{})
+
+	version, err := pg.Version(ctx)
+	if err != nil {
+		t.Fatalf("failed to get version: %v", err)
+	}
+	if pg.MajorVersion < 10 || majorVersion(version) != pg.MajorVersion {
+		t.Errorf("expected major version of %q, got %d", version, pg.MajorVersion)
+	}
+}
@@ -0,0 +1,95 @@
+package pgxtest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+// WALRecord is a WAL record as described by pg_waldump
+type WALRecord struct {
+	ResourceManager string // e.g. Heap, Btree, Transaction
+	RecordLength    int
+	TotalLength     int // Including full-page images
+	XID             uint32
+	LSN             string
+	Prev            string
+	Description     string
+}
+
+// CurrentWALLSN returns the current WAL write location, to be used as a
+// starting point for WALSince and WALBytesSince
+func (p *PG) CurrentWALLSN(ctx context.Context) (string, error) {
+	var lsn string
+	err := p.Pool.QueryRow(ctx, "SELECT pg_current_wal_lsn()::text").Scan(&lsn)
+	return lsn, err
+}
+
+// WALBytesSince returns the amount of WAL generated since lsn
+func (p *PG) WALBytesSince(ctx context.Context, lsn string) (int64, error) {
+	var n int64
+	err := p.Pool.QueryRow(ctx, "SELECT pg_wal_lsn_diff(pg_current_wal_lsn(), $1::pg_lsn)::bigint", lsn).Scan(&n)
+	return n, err
+}
+
+// WALSince returns the WAL records written since lsn, decoded by pg_waldump.
+//
+// Records of all databases and background processes are included, filter by
+// ResourceManager or XID to focus on the operation under test.
+func (p *PG) WALSince(ctx context.Context, lsn string) ([]WALRecord, error) {
+	if p.dataDir == "" {
+		return nil, fmt.Errorf("WAL of a server not owned by this instance is not available")
+	}
+
+	end, err := p.CurrentWALLSN(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if end == lsn {
+		return nil, nil
+	}
+
+	cmd := prepareCommand(filepath.Join(p.binPath, "pg_waldump"),
+		"--path", filepath.Join(p.dataDir, "pg_wal"),
+		"--start", lsn,
+		"--end", end,
+	)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("pg_waldump failed: %w -> %s", err, stderrOf(err))
+	}
+	return parseWALDump(out)
+}
+
+var walDumpLine = regexp.MustCompile(`^rmgr: (\S+)\s+len \(rec/tot\):\s*(\d+)/\s*(\d+), tx:\s*(\d+), lsn: ([0-9A-F]+/[0-9A-F]+), prev ([0-9A-F]+/[0-9A-F]+), desc: (.*)$`)
+
+func parseWALDump(out []byte) ([]WALRecord, error) {
+	var records []WALRecord
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		m := walDumpLine.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+
+		recLen, _ := strconv.Atoi(m[2])
+		totLen, _ := strconv.Atoi(m[3])
+		xid, _ := strconv.ParseUint(m[4], 10, 32)
+		records = append(records, WALRecord{
+			ResourceManager: m[1],
+			RecordLength:    recLen,
+			TotalLength:     totLen,
+			XID:             uint32(xid),
+			LSN:             m[5],
+			Prev:            m[6],
+			Description:     m[7],
+		})
+	}
+	return records, scanner.Err()
+}
@@ -0,0 +1,70 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWALSince(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	if _, err := pg.Pool.Exec(ctx, "CREATE TABLE test (val text)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	lsn, err := pg.CurrentWALLSN(ctx)
+	if err != nil {
+		t.Fatalf("failed to get LSN: %v", err)
+	}
+	if _, err := pg.Pool.Exec(ctx, "INSERT INTO test SELECT 'row' FROM generate_series(1, 10)"); err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+
+	records, err := pg.WALSince(ctx, lsn)
+	if err != nil {
+		t.Fatalf("failed to read WAL: %v", err)
+	}
+	inserts := 0
+	for _, r := range records {
+		if r.ResourceManager == "Heap" {
+			inserts++
+		}
+	}
+	if inserts < 10 {
+		t.Errorf("expected at least 10 heap records, got %d of %d", inserts, len(records))
+	}
+
+	n, err := pg.WALBytesSince(ctx, lsn)
+	if err != nil || n <= 0 {
+		t.Errorf("expected WAL to grow, got %d (%v)", n, err)
+	}
+}
+
+func TestParseWALDump(t *testing.T) {
+	out := []byte(`rmgr: Heap        len (rec/tot):     54/    54, tx:        735, lsn: 0/01A2B3C8, prev 0/01A2B390, desc: INSERT off: 3, flags: 0x08, blkref #0: rel 1663/5/16384 blk 0
+rmgr: Transaction len (rec/tot):     34/    34, tx:        735, lsn: 0/01A2B400, prev 0/01A2B3C8, desc: COMMIT 2024-01-01 00:00:00.000000 UTC
+pg_waldump: error: error in WAL record at 0/1A2B400: invalid record length
+`)
+	records, err := parseWALDump(out)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	r := records[0]
+	if r.ResourceManager != "Heap" || r.RecordLength != 54 || r.XID != 735 || r.LSN != "0/01A2B3C8" || r.Description[:6] != "INSERT" {
+		t.Errorf("unexpected record: %+v", r)
+	}
+}
@@ -0,0 +1,63 @@
+package pgxtest
+
+import (
+	"context"
+	"fmt"
+)
+
+// Largest transaction ID age before PostgreSQL refuses to assign new IDs
+const maxXIDAge = 1<<31 - 1
+
+// ConsumeXIDs burns n transaction IDs by running n tiny committed transactions
+// on the server. It advances the age of the oldest unfrozen transaction ID,
+// so that wraparound monitoring and anti-wraparound vacuum can be exercised.
+//
+// Expect a rate of roughly a million transaction IDs per minute.
+func (p *PG) ConsumeXIDs(ctx context.Context, n int64) error {
+	if n <= 0 {
+		return nil
+	}
+	_, err := p.Pool.Exec(ctx, fmt.Sprintf(`
+		DO $$
+		BEGIN
+			FOR i IN 1..%d LOOP
+				PERFORM pg_current_xact_id();
+				COMMIT;
+			END LOOP;
+		END
+		$$`, n))
+	return err
+}
+
+// XIDAge returns the age of the oldest unfrozen transaction ID of the test
+// database, as monitored for wraparound
+func (p *PG) XIDAge(ctx context.Context) (int64, error) {
+	var age int64
+	err := p.Pool.QueryRow(ctx, "SELECT age(datfrozenxid) FROM pg_database WHERE datname = current_database()").Scan(&age)
+	return age, err
+}
+
+// XIDsUntilWraparound returns how many transaction IDs the busiest database
+// of the cluster can consume before PostgreSQL stops assigning new ones
+func (p *PG) XIDsUntilWraparound(ctx context.Context) (int64, error) {
+	var age int64
+	if err := p.Pool.QueryRow(ctx, "SELECT max(age(datfrozenxid)) FROM pg_database").Scan(&age); err != nil {
+		return 0, err
+	}
+	return maxXIDAge - age, nil
+}
+
+// SetFreezeAges changes vacuum_freeze_min_age and vacuum_freeze_table_age
+// server-wide, e.g. to make vacuum freeze everything (0) or nothing.
+func (p *PG) SetFreezeAges(ctx context.Context, minAge, tableAge int64) error {
+	for _, stmt := range []string{
+		fmt.Sprintf("ALTER SYSTEM SET vacuum_freeze_min_age = %d", minAge),
+		fmt.Sprintf("ALTER SYSTEM SET vacuum_freeze_table_age = %d", tableAge),
+		"SELECT pg_reload_conf()",
+	} {
+		if _, err := p.Pool.Exec(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package pgxtest
+
+import (
+	"context"
+	"testing"
+)
+
+func TestConsumeXIDs(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+
+	pg, err := Start(ctx, Config{})
+	if err != nil {
+		t.Fatalf("failed to start pgxtest: %v", err)
+	}
+	defer func() {
+		if err = pg.Stop(); err != nil {
+			t.Errorf("failed to stop pgxtest: %v", err)
+		}
+	}()
+
+	before, err := pg.XIDAge(ctx)
+	if err != nil {
+		t.Fatalf("failed to get XID age: %v", err)
+	}
+	if err := pg.ConsumeXIDs(ctx, 1000); err != nil {
+		t.Fatalf("failed to consume XIDs: %v", err)
+	}
+	after, err := pg.XIDAge(ctx)
+	if err != nil {
+		t.Fatalf("failed to get XID age: %v", err)
+	}
+	if after-before < 1000 {
+		t.Errorf("expected XID age to grow by at least 1000, got %d -> %d", before, after)
+	}
+
+	remaining, err := pg.XIDsUntilWraparound(ctx)
+	if err != nil {
+		t.Fatalf("failed to get remaining XIDs: %v", err)
+	}
+	if remaining <= 0 || remaining > maxXIDAge-after {
+		t.Errorf("unexpected remaining XIDs %d for age %d", remaining, after)
+	}
+}